@@ -33,6 +33,11 @@ type CertificateStoreSpec struct {
 
 	// Parameters of the certificate store
 	Parameters runtime.RawExtension `json:"parameters,omitempty"`
+
+	// Interval at which certificates are fetched again from the provider, for example "1h".
+	// Certificates are only fetched on updates of the CertificateStore if not set.
+	// +optional
+	RefreshInterval string `json:"refreshInterval,omitempty"`
 }
 
 type CertificateStoreStatus struct {
@@ -48,6 +53,13 @@ type CertificateStoreStatus struct {
 	// provider specific properties of the each individual certificate
 	// +optional
 	Properties runtime.RawExtension `json:"properties,omitempty"`
+
+	// The time stamp of the next scheduled certificates fetch operation
+	// +optional
+	NextRefreshTime *metav1.Time `json:"nextrefreshtime,omitempty"`
+	// The expiry time of the certificate that expires first among the fetched certificates
+	// +optional
+	ExpiryTime *metav1.Time `json:"expirytime,omitempty"`
 }
 
 // CertificateStore is the Schema for the certificatestores API
@@ -40,6 +40,12 @@ type StoreSpec struct {
 // StoreStatus defines the observed state of Store
 type StoreStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Is successful in creating the store, including the download of its plugin
+	IsSuccess bool `json:"issuccess"`
+	// Error message if the store could not be created
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 // Store is the Schema for the stores API
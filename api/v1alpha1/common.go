@@ -28,4 +28,7 @@ type PluginSource struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// AuthProvider to use to authenticate to the OCI Artifact source, optional
 	AuthProvider runtime.RawExtension `json:"authProvider,omitempty"`
+
+	// Allow the plugin to be installed although it is neither pinned to a digest nor verified, optional
+	AllowUnverified bool `json:"allowUnverified,omitempty"`
 }
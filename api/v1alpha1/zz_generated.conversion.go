@@ -21,8 +21,6 @@ limitations under the License.
 package v1alpha1
 
 import (
-	unsafe "unsafe"
-
 	unversioned "github.com/deislabs/ratify/api/unversioned"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
@@ -60,31 +58,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*unversioned.CertificateStoreSpec)(nil), (*CertificateStoreSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_unversioned_CertificateStoreSpec_To_v1alpha1_CertificateStoreSpec(a.(*unversioned.CertificateStoreSpec), b.(*CertificateStoreSpec), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*CertificateStoreStatus)(nil), (*unversioned.CertificateStoreStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_CertificateStoreStatus_To_unversioned_CertificateStoreStatus(a.(*CertificateStoreStatus), b.(*unversioned.CertificateStoreStatus), scope)
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*unversioned.CertificateStoreStatus)(nil), (*CertificateStoreStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_unversioned_CertificateStoreStatus_To_v1alpha1_CertificateStoreStatus(a.(*unversioned.CertificateStoreStatus), b.(*CertificateStoreStatus), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*PluginSource)(nil), (*unversioned.PluginSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_PluginSource_To_unversioned_PluginSource(a.(*PluginSource), b.(*unversioned.PluginSource), scope)
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*unversioned.PluginSource)(nil), (*PluginSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_unversioned_PluginSource_To_v1alpha1_PluginSource(a.(*unversioned.PluginSource), b.(*PluginSource), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*Store)(nil), (*unversioned.Store)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_Store_To_unversioned_Store(a.(*Store), b.(*unversioned.Store), scope)
 	}); err != nil {
@@ -120,11 +103,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*unversioned.StoreStatus)(nil), (*StoreStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_unversioned_StoreStatus_To_v1alpha1_StoreStatus(a.(*unversioned.StoreStatus), b.(*StoreStatus), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*Verifier)(nil), (*unversioned.Verifier)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_Verifier_To_unversioned_Verifier(a.(*Verifier), b.(*unversioned.Verifier), scope)
 	}); err != nil {
@@ -165,6 +143,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*unversioned.CertificateStoreSpec)(nil), (*CertificateStoreSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_unversioned_CertificateStoreSpec_To_v1alpha1_CertificateStoreSpec(a.(*unversioned.CertificateStoreSpec), b.(*CertificateStoreSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*unversioned.CertificateStoreStatus)(nil), (*CertificateStoreStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_unversioned_CertificateStoreStatus_To_v1alpha1_CertificateStoreStatus(a.(*unversioned.CertificateStoreStatus), b.(*CertificateStoreStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*unversioned.PluginSource)(nil), (*PluginSource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_unversioned_PluginSource_To_v1alpha1_PluginSource(a.(*unversioned.PluginSource), b.(*PluginSource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*unversioned.StoreStatus)(nil), (*StoreStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_unversioned_StoreStatus_To_v1alpha1_StoreStatus(a.(*unversioned.StoreStatus), b.(*StoreStatus), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -202,7 +200,17 @@ func Convert_unversioned_CertificateStore_To_v1alpha1_CertificateStore(in *unver
 
 func autoConvert_v1alpha1_CertificateStoreList_To_unversioned_CertificateStoreList(in *CertificateStoreList, out *unversioned.CertificateStoreList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]unversioned.CertificateStore)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]unversioned.CertificateStore, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_CertificateStore_To_unversioned_CertificateStore(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -213,7 +221,17 @@ func Convert_v1alpha1_CertificateStoreList_To_unversioned_CertificateStoreList(i
 
 func autoConvert_unversioned_CertificateStoreList_To_v1alpha1_CertificateStoreList(in *unversioned.CertificateStoreList, out *CertificateStoreList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]CertificateStore)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertificateStore, len(*in))
+		for i := range *in {
+			if err := Convert_unversioned_CertificateStore_To_v1alpha1_CertificateStore(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -236,14 +254,10 @@ func Convert_v1alpha1_CertificateStoreSpec_To_unversioned_CertificateStoreSpec(i
 func autoConvert_unversioned_CertificateStoreSpec_To_v1alpha1_CertificateStoreSpec(in *unversioned.CertificateStoreSpec, out *CertificateStoreSpec, s conversion.Scope) error {
 	out.Provider = in.Provider
 	out.Parameters = in.Parameters
+	// WARNING: in.RefreshInterval requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_unversioned_CertificateStoreSpec_To_v1alpha1_CertificateStoreSpec is an autogenerated conversion function.
-func Convert_unversioned_CertificateStoreSpec_To_v1alpha1_CertificateStoreSpec(in *unversioned.CertificateStoreSpec, out *CertificateStoreSpec, s conversion.Scope) error {
-	return autoConvert_unversioned_CertificateStoreSpec_To_v1alpha1_CertificateStoreSpec(in, out, s)
-}
-
 func autoConvert_v1alpha1_CertificateStoreStatus_To_unversioned_CertificateStoreStatus(in *CertificateStoreStatus, out *unversioned.CertificateStoreStatus, s conversion.Scope) error {
 	return nil
 }
@@ -254,12 +268,19 @@ func Convert_v1alpha1_CertificateStoreStatus_To_unversioned_CertificateStoreStat
 }
 
 func autoConvert_unversioned_CertificateStoreStatus_To_v1alpha1_CertificateStoreStatus(in *unversioned.CertificateStoreStatus, out *CertificateStoreStatus, s conversion.Scope) error {
+	// WARNING: in.IsSuccess requires manual conversion: does not exist in peer-type
+	// WARNING: in.Error requires manual conversion: does not exist in peer-type
+	// WARNING: in.LastFetchedTime requires manual conversion: does not exist in peer-type
+	// WARNING: in.Properties requires manual conversion: does not exist in peer-type
+	// WARNING: in.NextRefreshTime requires manual conversion: does not exist in peer-type
+	// WARNING: in.ExpiryTime requires manual conversion: does not exist in peer-type
 	return nil
 }
 
 func autoConvert_v1alpha1_PluginSource_To_unversioned_PluginSource(in *PluginSource, out *unversioned.PluginSource, s conversion.Scope) error {
 	out.Artifact = in.Artifact
 	out.AuthProvider = in.AuthProvider
+	out.AllowUnverified = in.AllowUnverified
 	return nil
 }
 
@@ -271,14 +292,12 @@ func Convert_v1alpha1_PluginSource_To_unversioned_PluginSource(in *PluginSource,
 func autoConvert_unversioned_PluginSource_To_v1alpha1_PluginSource(in *unversioned.PluginSource, out *PluginSource, s conversion.Scope) error {
 	out.Artifact = in.Artifact
 	out.AuthProvider = in.AuthProvider
+	// WARNING: in.Digest requires manual conversion: does not exist in peer-type
+	// WARNING: in.Verifier requires manual conversion: does not exist in peer-type
+	out.AllowUnverified = in.AllowUnverified
 	return nil
 }
 
-// Convert_unversioned_PluginSource_To_v1alpha1_PluginSource is an autogenerated conversion function.
-func Convert_unversioned_PluginSource_To_v1alpha1_PluginSource(in *unversioned.PluginSource, out *PluginSource, s conversion.Scope) error {
-	return autoConvert_unversioned_PluginSource_To_v1alpha1_PluginSource(in, out, s)
-}
-
 func autoConvert_v1alpha1_Store_To_unversioned_Store(in *Store, out *unversioned.Store, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha1_StoreSpec_To_unversioned_StoreSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -313,7 +332,17 @@ func Convert_unversioned_Store_To_v1alpha1_Store(in *unversioned.Store, out *Sto
 
 func autoConvert_v1alpha1_StoreList_To_unversioned_StoreList(in *StoreList, out *unversioned.StoreList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]unversioned.Store)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]unversioned.Store, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_Store_To_unversioned_Store(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -324,7 +353,17 @@ func Convert_v1alpha1_StoreList_To_unversioned_StoreList(in *StoreList, out *unv
 
 func autoConvert_unversioned_StoreList_To_v1alpha1_StoreList(in *unversioned.StoreList, out *StoreList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]Store)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Store, len(*in))
+		for i := range *in {
+			if err := Convert_unversioned_Store_To_v1alpha1_Store(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -336,7 +375,15 @@ func Convert_unversioned_StoreList_To_v1alpha1_StoreList(in *unversioned.StoreLi
 func autoConvert_v1alpha1_StoreSpec_To_unversioned_StoreSpec(in *StoreSpec, out *unversioned.StoreSpec, s conversion.Scope) error {
 	out.Name = in.Name
 	out.Address = in.Address
-	out.Source = (*unversioned.PluginSource)(unsafe.Pointer(in.Source))
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(unversioned.PluginSource)
+		if err := Convert_v1alpha1_PluginSource_To_unversioned_PluginSource(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Source = nil
+	}
 	out.Parameters = in.Parameters
 	return nil
 }
@@ -349,7 +396,15 @@ func Convert_v1alpha1_StoreSpec_To_unversioned_StoreSpec(in *StoreSpec, out *unv
 func autoConvert_unversioned_StoreSpec_To_v1alpha1_StoreSpec(in *unversioned.StoreSpec, out *StoreSpec, s conversion.Scope) error {
 	out.Name = in.Name
 	out.Address = in.Address
-	out.Source = (*PluginSource)(unsafe.Pointer(in.Source))
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(PluginSource)
+		if err := Convert_unversioned_PluginSource_To_v1alpha1_PluginSource(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Source = nil
+	}
 	out.Parameters = in.Parameters
 	return nil
 }
@@ -369,14 +424,11 @@ func Convert_v1alpha1_StoreStatus_To_unversioned_StoreStatus(in *StoreStatus, ou
 }
 
 func autoConvert_unversioned_StoreStatus_To_v1alpha1_StoreStatus(in *unversioned.StoreStatus, out *StoreStatus, s conversion.Scope) error {
+	// WARNING: in.IsSuccess requires manual conversion: does not exist in peer-type
+	// WARNING: in.Error requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_unversioned_StoreStatus_To_v1alpha1_StoreStatus is an autogenerated conversion function.
-func Convert_unversioned_StoreStatus_To_v1alpha1_StoreStatus(in *unversioned.StoreStatus, out *StoreStatus, s conversion.Scope) error {
-	return autoConvert_unversioned_StoreStatus_To_v1alpha1_StoreStatus(in, out, s)
-}
-
 func autoConvert_v1alpha1_Verifier_To_unversioned_Verifier(in *Verifier, out *unversioned.Verifier, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha1_VerifierSpec_To_unversioned_VerifierSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -411,7 +463,17 @@ func Convert_unversioned_Verifier_To_v1alpha1_Verifier(in *unversioned.Verifier,
 
 func autoConvert_v1alpha1_VerifierList_To_unversioned_VerifierList(in *VerifierList, out *unversioned.VerifierList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]unversioned.Verifier)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]unversioned.Verifier, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_Verifier_To_unversioned_Verifier(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -422,7 +484,17 @@ func Convert_v1alpha1_VerifierList_To_unversioned_VerifierList(in *VerifierList,
 
 func autoConvert_unversioned_VerifierList_To_v1alpha1_VerifierList(in *unversioned.VerifierList, out *VerifierList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]Verifier)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Verifier, len(*in))
+		for i := range *in {
+			if err := Convert_unversioned_Verifier_To_v1alpha1_Verifier(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -435,7 +507,15 @@ func autoConvert_v1alpha1_VerifierSpec_To_unversioned_VerifierSpec(in *VerifierS
 	out.Name = in.Name
 	out.ArtifactTypes = in.ArtifactTypes
 	out.Address = in.Address
-	out.Source = (*unversioned.PluginSource)(unsafe.Pointer(in.Source))
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(unversioned.PluginSource)
+		if err := Convert_v1alpha1_PluginSource_To_unversioned_PluginSource(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Source = nil
+	}
 	out.Parameters = in.Parameters
 	return nil
 }
@@ -449,7 +529,15 @@ func autoConvert_unversioned_VerifierSpec_To_v1alpha1_VerifierSpec(in *unversion
 	out.Name = in.Name
 	out.ArtifactTypes = in.ArtifactTypes
 	out.Address = in.Address
-	out.Source = (*PluginSource)(unsafe.Pointer(in.Source))
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(PluginSource)
+		if err := Convert_unversioned_PluginSource_To_v1alpha1_PluginSource(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Source = nil
+	}
 	out.Parameters = in.Parameters
 	return nil
 }
@@ -33,6 +33,11 @@ type CertificateStoreSpec struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// Parameters of the certificate store
 	Parameters runtime.RawExtension `json:"parameters,omitempty"`
+
+	// Interval at which certificates are fetched again from the provider, for example "1h".
+	// Certificates are only fetched on updates of the CertificateStore if not set.
+	// +optional
+	RefreshInterval string `json:"refreshInterval,omitempty"`
 }
 
 // CertificateStoreStatus defines the observed state of CertificateStore
@@ -50,6 +55,13 @@ type CertificateStoreStatus struct {
 	// provider specific parameters of the each individual certificate
 	// +optional
 	Properties runtime.RawExtension `json:"properties,omitempty"`
+
+	// The time stamp of the next scheduled certificates fetch operation
+	// +optional
+	NextRefreshTime *metav1.Time `json:"nextrefreshtime,omitempty"`
+	// The expiry time of the certificate that expires first among the fetched certificates
+	// +optional
+	ExpiryTime *metav1.Time `json:"expirytime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -59,6 +71,7 @@ type CertificateStoreStatus struct {
 // +kubebuilder:printcolumn:name="IsSuccess",type=boolean,JSONPath=`.status.issuccess`
 // +kubebuilder:printcolumn:name="Error",type=string,JSONPath=`.status.error`
 // +kubebuilder:printcolumn:name="LastFetchedTime",type=date,JSONPath=`.status.lastfetchedtime`
+// +kubebuilder:printcolumn:name="ExpiryTime",type=date,JSONPath=`.status.expirytime`
 type CertificateStore struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
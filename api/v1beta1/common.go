@@ -27,4 +27,14 @@ type PluginSource struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// AuthProvider to use to authenticate to the OCI Artifact source, optional
 	AuthProvider runtime.RawExtension `json:"authProvider,omitempty"`
+
+	// Digest of the manifest of the OCI Artifact the plugin is pinned to, optional
+	Digest string `json:"digest,omitempty"`
+
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// Verifier to check the signatures of the OCI Artifact with before the plugin is installed, optional
+	Verifier runtime.RawExtension `json:"verifier,omitempty"`
+
+	// Allow the plugin to be installed although it is neither pinned to a digest nor verified, optional
+	AllowUnverified bool `json:"allowUnverified,omitempty"`
 }
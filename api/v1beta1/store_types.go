@@ -40,12 +40,21 @@ type StoreSpec struct {
 // StoreStatus defines the observed state of Store
 type StoreStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Is successful in creating the store, including the download of its plugin
+	IsSuccess bool `json:"issuccess"`
+	// Error message if the store could not be created
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope="Cluster"
+// +kubebuilder:subresource:status
 // +kubebuilder:storageversion
 // Store is the Schema for the stores API
+// +kubebuilder:printcolumn:name="IsSuccess",type=boolean,JSONPath=`.status.issuccess`
+// +kubebuilder:printcolumn:name="Error",type=string,JSONPath=`.status.error`
 type Store struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
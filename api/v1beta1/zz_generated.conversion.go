@@ -24,6 +24,7 @@ import (
 	unsafe "unsafe"
 
 	unversioned "github.com/deislabs/ratify/api/unversioned"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -225,6 +226,7 @@ func Convert_unversioned_CertificateStoreList_To_v1beta1_CertificateStoreList(in
 func autoConvert_v1beta1_CertificateStoreSpec_To_unversioned_CertificateStoreSpec(in *CertificateStoreSpec, out *unversioned.CertificateStoreSpec, s conversion.Scope) error {
 	out.Provider = in.Provider
 	out.Parameters = in.Parameters
+	out.RefreshInterval = in.RefreshInterval
 	return nil
 }
 
@@ -236,6 +238,7 @@ func Convert_v1beta1_CertificateStoreSpec_To_unversioned_CertificateStoreSpec(in
 func autoConvert_unversioned_CertificateStoreSpec_To_v1beta1_CertificateStoreSpec(in *unversioned.CertificateStoreSpec, out *CertificateStoreSpec, s conversion.Scope) error {
 	out.Provider = in.Provider
 	out.Parameters = in.Parameters
+	out.RefreshInterval = in.RefreshInterval
 	return nil
 }
 
@@ -245,6 +248,12 @@ func Convert_unversioned_CertificateStoreSpec_To_v1beta1_CertificateStoreSpec(in
 }
 
 func autoConvert_v1beta1_CertificateStoreStatus_To_unversioned_CertificateStoreStatus(in *CertificateStoreStatus, out *unversioned.CertificateStoreStatus, s conversion.Scope) error {
+	out.IsSuccess = in.IsSuccess
+	out.Error = in.Error
+	out.LastFetchedTime = (*v1.Time)(unsafe.Pointer(in.LastFetchedTime))
+	out.Properties = in.Properties
+	out.NextRefreshTime = (*v1.Time)(unsafe.Pointer(in.NextRefreshTime))
+	out.ExpiryTime = (*v1.Time)(unsafe.Pointer(in.ExpiryTime))
 	return nil
 }
 
@@ -254,6 +263,12 @@ func Convert_v1beta1_CertificateStoreStatus_To_unversioned_CertificateStoreStatu
 }
 
 func autoConvert_unversioned_CertificateStoreStatus_To_v1beta1_CertificateStoreStatus(in *unversioned.CertificateStoreStatus, out *CertificateStoreStatus, s conversion.Scope) error {
+	out.IsSuccess = in.IsSuccess
+	out.Error = in.Error
+	out.LastFetchedTime = (*v1.Time)(unsafe.Pointer(in.LastFetchedTime))
+	out.Properties = in.Properties
+	out.NextRefreshTime = (*v1.Time)(unsafe.Pointer(in.NextRefreshTime))
+	out.ExpiryTime = (*v1.Time)(unsafe.Pointer(in.ExpiryTime))
 	return nil
 }
 
@@ -265,6 +280,9 @@ func Convert_unversioned_CertificateStoreStatus_To_v1beta1_CertificateStoreStatu
 func autoConvert_v1beta1_PluginSource_To_unversioned_PluginSource(in *PluginSource, out *unversioned.PluginSource, s conversion.Scope) error {
 	out.Artifact = in.Artifact
 	out.AuthProvider = in.AuthProvider
+	out.Digest = in.Digest
+	out.Verifier = in.Verifier
+	out.AllowUnverified = in.AllowUnverified
 	return nil
 }
 
@@ -276,6 +294,9 @@ func Convert_v1beta1_PluginSource_To_unversioned_PluginSource(in *PluginSource,
 func autoConvert_unversioned_PluginSource_To_v1beta1_PluginSource(in *unversioned.PluginSource, out *PluginSource, s conversion.Scope) error {
 	out.Artifact = in.Artifact
 	out.AuthProvider = in.AuthProvider
+	out.Digest = in.Digest
+	out.Verifier = in.Verifier
+	out.AllowUnverified = in.AllowUnverified
 	return nil
 }
 
@@ -365,6 +386,8 @@ func Convert_unversioned_StoreSpec_To_v1beta1_StoreSpec(in *unversioned.StoreSpe
 }
 
 func autoConvert_v1beta1_StoreStatus_To_unversioned_StoreStatus(in *StoreStatus, out *unversioned.StoreStatus, s conversion.Scope) error {
+	out.IsSuccess = in.IsSuccess
+	out.Error = in.Error
 	return nil
 }
 
@@ -374,6 +397,8 @@ func Convert_v1beta1_StoreStatus_To_unversioned_StoreStatus(in *StoreStatus, out
 }
 
 func autoConvert_unversioned_StoreStatus_To_v1beta1_StoreStatus(in *unversioned.StoreStatus, out *StoreStatus, s conversion.Scope) error {
+	out.IsSuccess = in.IsSuccess
+	out.Error = in.Error
 	return nil
 }
 
@@ -108,6 +108,14 @@ func (in *CertificateStoreStatus) DeepCopyInto(out *CertificateStoreStatus) {
 		*out = (*in).DeepCopy()
 	}
 	in.Properties.DeepCopyInto(&out.Properties)
+	if in.NextRefreshTime != nil {
+		in, out := &in.NextRefreshTime, &out.NextRefreshTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiryTime != nil {
+		in, out := &in.ExpiryTime, &out.ExpiryTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateStoreStatus.
@@ -124,6 +132,7 @@ func (in *CertificateStoreStatus) DeepCopy() *CertificateStoreStatus {
 func (in *PluginSource) DeepCopyInto(out *PluginSource) {
 	*out = *in
 	in.AuthProvider.DeepCopyInto(&out.AuthProvider)
+	in.Verifier.DeepCopyInto(&out.Verifier)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginSource.
@@ -0,0 +1,127 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deislabs/ratify/pkg/common/plugin/conformance"
+	"github.com/spf13/cobra"
+)
+
+const (
+	pluginUse = "plugin"
+
+	pluginTypeVerifier = "verifier"
+	pluginTypeStore    = "store"
+)
+
+type pluginTestCmdOptions struct {
+	pluginType string
+	config     string
+	subject    string
+	version    string
+}
+
+func NewCmdPlugin(argv ...string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   pluginUse,
+		Short: "Develop verifier and store plugins",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(NewCmdPluginTest(argv...))
+	return cmd
+}
+
+func NewCmdPluginTest(argv ...string) *cobra.Command {
+	var opts pluginTestCmdOptions
+
+	if len(argv) == 0 {
+		argv = []string{os.Args[0]}
+	}
+
+	eg := fmt.Sprintf(`  # Check a verifier plugin against in-memory content
+  %[1]s test ./sample --config '{"artifactTypes":"application/vnd.ratify.sample"}'
+
+  # Check a store plugin reading the referrers of a subject
+  %[1]s test ./mystore --type store -s myregistry/myrepo@sha256:34343`, strings.Join(argv, " "))
+
+	cmd := &cobra.Command{
+		Use:     "test PLUGIN_PATH [OPTIONS]",
+		Short:   "Check that a plugin conforms to the plugin protocol",
+		Example: eg,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return testPlugin(args[0], opts)
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringVar(&opts.pluginType, "type", pluginTypeVerifier, "Plugin type, verifier or store")
+	flags.StringVar(&opts.config, "config", "", "Plugin config as JSON, the name defaults to the name of the binary")
+	flags.StringVarP(&opts.subject, "subject", "s", "", "Subject Reference in the store, required to check the content a store plugin reads")
+	flags.StringVar(&opts.version, "version", conformance.DefaultVersion, "Spec version of the commands")
+	return cmd
+}
+
+func testPlugin(pluginPath string, opts pluginTestCmdOptions) error {
+	pluginConfig := map[string]interface{}{}
+	if opts.config != "" {
+		if err := json.Unmarshal([]byte(opts.config), &pluginConfig); err != nil {
+			return fmt.Errorf("invalid plugin config: %w", err)
+		}
+	}
+
+	var report *conformance.Report
+	var err error
+	switch opts.pluginType {
+	case pluginTypeVerifier:
+		report, err = conformance.CheckVerifier(context.Background(), conformance.VerifierOptions{
+			PluginPath: pluginPath,
+			Version:    opts.version,
+			Config:     pluginConfig,
+		})
+	case pluginTypeStore:
+		report, err = conformance.CheckStore(context.Background(), conformance.StoreOptions{
+			PluginPath: pluginPath,
+			Version:    opts.version,
+			Config:     pluginConfig,
+			Subject:    opts.subject,
+		})
+	default:
+		return fmt.Errorf("unknown plugin type %s, must be %s or %s", opts.pluginType, pluginTypeVerifier, pluginTypeStore)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := report.Write(os.Stdout); err != nil {
+		return err
+	}
+	if report.Failed() {
+		return fmt.Errorf("plugin %s failed %d conformance checks", pluginPath, len(report.Failures()))
+	}
+	return nil
+}
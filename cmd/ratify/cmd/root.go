@@ -49,6 +49,7 @@ func New(use, short string) *cobra.Command {
 	root.AddCommand(NewCmdDiscover(use, discoverUse))
 	root.AddCommand(NewCmdVersion(use, versionUse))
 	root.AddCommand(NewCmdResolve(use, resolveUse))
+	root.AddCommand(NewCmdPlugin(use, pluginUse))
 
 	// TODO debug logging
 	return root
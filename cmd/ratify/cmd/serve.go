@@ -22,6 +22,7 @@ import (
 
 	"github.com/deislabs/ratify/config"
 	"github.com/deislabs/ratify/httpserver"
+	"github.com/deislabs/ratify/pkg/certificatecache"
 	"github.com/deislabs/ratify/pkg/manager"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -42,6 +43,7 @@ type serveCmdOptions struct {
 	metricsEnabled    bool
 	metricsType       string
 	metricsPort       int
+	certExpiryWarning []int
 }
 
 func NewCmdServe(argv ...string) *cobra.Command {
@@ -69,10 +71,16 @@ func NewCmdServe(argv ...string) *cobra.Command {
 	flags.BoolVar(&opts.metricsEnabled, "metrics-enabled", false, "Enable metrics exporter if enabled (default: false)")
 	flags.StringVar(&opts.metricsType, "metrics-type", httpserver.DefaultMetricsType, fmt.Sprintf("Metrics exporter type to use (default: %s)", httpserver.DefaultMetricsType))
 	flags.IntVar(&opts.metricsPort, "metrics-port", httpserver.DefaultMetricsPort, fmt.Sprintf("Metrics exporter port to use (default: %d)", httpserver.DefaultMetricsPort))
+	flags.IntSliceVar(&opts.certExpiryWarning, "cert-expiry-warning-days", certificatecache.DefaultExpiryWarningThresholds, "Days before certificate expiry at which warnings are logged")
 	return cmd
 }
 
 func serve(opts serveCmdOptions) error {
+	certificatecache.SetExpiryWarningThresholds(opts.certExpiryWarning)
+	// the cache subscribes to CertificateStore updates and monitors the expiry of their certificates before the
+	// manager starts, whether or not a verifier reads certificates from it
+	certificatecache.Default()
+
 	// in crd mode, the manager gets latest store/verifier from crd and pass on to the http server
 	if opts.enableCrdManager {
 		logrus.Infof("starting crd manager")
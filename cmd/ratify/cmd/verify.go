@@ -25,6 +25,7 @@ import (
 	ef "github.com/deislabs/ratify/pkg/executor/core"
 	pf "github.com/deislabs/ratify/pkg/policyprovider/factory"
 	sf "github.com/deislabs/ratify/pkg/referrerstore/factory"
+	"github.com/deislabs/ratify/pkg/referrerstore/orchestrator"
 	"github.com/deislabs/ratify/pkg/utils"
 	vf "github.com/deislabs/ratify/pkg/verifier/factory"
 	"github.com/spf13/cobra"
@@ -93,6 +94,8 @@ func verify(opts verifyCmdOptions) error {
 	if err != nil {
 		return err
 	}
+	// the content of the stores is served to verifier plugins until the verification completes
+	defer orchestrator.StopAllShared()
 
 	verifiers, err := vf.CreateVerifiersFromConfig(cf.VerifiersConfig, config.GetDefaultPluginPath())
 
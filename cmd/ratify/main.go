@@ -15,17 +15,15 @@ limitations under the License.
 
 package main
 
-import (
-	"os"
+//go:generate go run ../ratifygen -o zz_generated.cosign.go -tag ratify_cosign cosign
+//go:generate go run ../ratifygen -o zz_generated.licensechecker.go -tag ratify_licensechecker licensechecker
+//go:generate go run ../ratifygen -o zz_generated.sbom.go -tag ratify_sbom sbom
 
-	"github.com/deislabs/ratify/cmd/ratify/cmd"
-	_ "github.com/deislabs/ratify/pkg/policyprovider/configpolicy"
-	_ "github.com/deislabs/ratify/pkg/referrerstore/oras"
-	_ "github.com/deislabs/ratify/pkg/verifier/notaryv2"
+import (
+	"github.com/deislabs/ratify"
 )
 
+// main runs the verifiers selected with build tags in-process, e.g. go build -tags ratify_cosign,ratify_sbom
 func main() {
-	if err := cmd.Root.Execute(); err != nil {
-		os.Exit(1)
-	}
+	ratify.Main()
 }
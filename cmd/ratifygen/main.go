@@ -0,0 +1,177 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// ratifygen generates the Go file registering verifier plugins compiled into a custom distribution of Ratify.
+// Plugins are named by the packages exporting their skel.Plugin as Plugin, or by the names of the plugins of this
+// repository:
+//
+//	ratifygen -o plugins.go -tag ratify_plugins cosign sbom example.com/myplugin/pluginverifier
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// bundledPlugins are the packages of the verifier plugins of this repository
+var bundledPlugins = map[string]string{
+	"cosign":         "github.com/deislabs/ratify/plugins/verifier/cosign/cosignverifier",
+	"licensechecker": "github.com/deislabs/ratify/plugins/verifier/licensechecker/licensecheckerverifier",
+	"sbom":           "github.com/deislabs/ratify/plugins/verifier/sbom/sbomverifier",
+}
+
+var registrationTemplate = template.Must(template.New("registration").Parse(`{{if .Tag}}//go:build {{.Tag}}
+// +build {{.Tag}}
+
+{{end}}/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by ratifygen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"github.com/deislabs/ratify"
+{{range .Plugins}}	{{.Name}} "{{.ImportPath}}"
+{{end}})
+
+{{if .Main}}func main() {
+{{else}}func init() {
+{{end}}	ratify.NewBuilder().
+{{range .Plugins}}		WithVerifierPlugin({{.Name}}.Plugin).
+{{end}}{{if .Main}}		Main()
+{{else}}		Register()
+{{end}}}
+`))
+
+type pluginPackage struct {
+	Name       string
+	ImportPath string
+}
+
+type options struct {
+	Package string
+	Tag     string
+	Main    bool
+	Plugins []pluginPackage
+}
+
+func main() {
+	var opts options
+	var output string
+	flag.StringVar(&output, "o", "", "output file, the standard output if not set")
+	flag.StringVar(&opts.Package, "package", "main", "package of the generated file")
+	flag.StringVar(&opts.Tag, "tag", "", "build tag the generated file is compiled with, always compiled if not set")
+	flag.BoolVar(&opts.Main, "main", false, "generate the main function of the distribution instead of registering the plugins on init")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: ratifygen [flags] PLUGIN...\n\nPLUGIN is an import path or one of %s\n\n", strings.Join(bundledPluginNames(), ", "))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(opts, flag.Args(), output); err != nil {
+		fmt.Fprintf(os.Stderr, "ratifygen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts options, plugins []string, output string) error {
+	var err error
+	if opts.Plugins, err = resolvePlugins(plugins); err != nil {
+		return err
+	}
+	if output == "" {
+		return generate(os.Stdout, opts)
+	}
+	buf := &bytes.Buffer{}
+	if err := generate(buf, opts); err != nil {
+		return err
+	}
+	return os.WriteFile(output, buf.Bytes(), 0600)
+}
+
+// resolvePlugins returns the packages of the plugins, named by the last element of their import path
+func resolvePlugins(plugins []string) ([]pluginPackage, error) {
+	if len(plugins) == 0 {
+		return nil, fmt.Errorf("no plugins given")
+	}
+	packages := []pluginPackage{}
+	names := map[string]bool{}
+	for _, plugin := range plugins {
+		importPath, ok := bundledPlugins[plugin]
+		if !ok {
+			if !strings.Contains(plugin, "/") {
+				return nil, fmt.Errorf("unknown plugin %s, plugins are named by import path or one of %s", plugin, strings.Join(bundledPluginNames(), ", "))
+			}
+			importPath = plugin
+		}
+		name := path.Base(importPath)
+		if !token.IsIdentifier(name) || name == "ratify" {
+			return nil, fmt.Errorf("the last element of import path %s is not a valid package name", importPath)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("more than one plugin package is named %s", name)
+		}
+		names[name] = true
+		packages = append(packages, pluginPackage{Name: name, ImportPath: importPath})
+	}
+	return packages, nil
+}
+
+func generate(w io.Writer, opts options) error {
+	if opts.Tag != "" && !token.IsIdentifier(opts.Tag) {
+		return fmt.Errorf("invalid build tag %q", opts.Tag)
+	}
+	buf := &bytes.Buffer{}
+	if err := registrationTemplate.Execute(buf, opts); err != nil {
+		return err
+	}
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("generated invalid source: %w", err)
+	}
+	_, err = w.Write(source)
+	return err
+}
+
+func bundledPluginNames() []string {
+	names := []string{}
+	for name := range bundledPlugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,100 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      options
+		plugins   []string
+		expectErr bool
+		contains  []string
+	}{
+		{
+			name:    "bundled plugins with tag",
+			opts:    options{Package: "main", Tag: "ratify_plugins"},
+			plugins: []string{"cosign", "sbom"},
+			contains: []string{
+				"//go:build ratify_plugins",
+				`cosignverifier "github.com/deislabs/ratify/plugins/verifier/cosign/cosignverifier"`,
+				"WithVerifierPlugin(sbomverifier.Plugin)",
+				"func init()",
+				"Register()",
+			},
+		},
+		{
+			name:     "import path with main",
+			opts:     options{Package: "main", Main: true},
+			plugins:  []string{"example.com/plugins/myverifier"},
+			contains: []string{"func main()", "WithVerifierPlugin(myverifier.Plugin)", "Main()"},
+		},
+		{
+			name:      "unknown plugin",
+			opts:      options{Package: "main"},
+			plugins:   []string{"unknown"},
+			expectErr: true,
+		},
+		{
+			name:      "duplicate package names",
+			opts:      options{Package: "main"},
+			plugins:   []string{"sbom", "example.com/sbomverifier"},
+			expectErr: true,
+		},
+		{
+			name:      "no plugins",
+			opts:      options{Package: "main"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid tag",
+			opts:      options{Package: "main", Tag: "a || b"},
+			plugins:   []string{"sbom"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packages, err := resolvePlugins(tt.plugins)
+			if err == nil {
+				tt.opts.Plugins = packages
+				buf := &bytes.Buffer{}
+				if err = generate(buf, tt.opts); err == nil {
+					source := buf.String()
+					if _, err := parser.ParseFile(token.NewFileSet(), "generated.go", source, parser.AllErrors); err != nil {
+						t.Fatalf("generated invalid source: %v", err)
+					}
+					for _, expected := range tt.contains {
+						if !strings.Contains(source, expected) {
+							t.Fatalf("expected generated source to contain %q, got:\n%s", expected, source)
+						}
+					}
+				}
+			}
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
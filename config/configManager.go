@@ -16,10 +16,13 @@ limitations under the License.
 package config
 
 import (
+	"io"
 	"os"
 	"time"
 
 	ef "github.com/deislabs/ratify/pkg/executor/core"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/orchestrator"
 	"github.com/fsnotify/fsnotify"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -90,14 +93,29 @@ func reloadExecutor(configFilePath string) {
 			return
 		}
 
+		previousStores := executor.ReferrerStores
 		executor = newExecutor
 		configHash = cf.fileHash
+		releaseStores(previousStores)
 		logrus.Infof("configuration file has been updated, reloading executor succeeded")
 	} else {
 		logrus.Infof("no change found in config file, no executor update needed")
 	}
 }
 
+// releaseStores stops the shared content servers of stores replaced by a reload and closes their plugin connections
+func releaseStores(stores []referrerstore.ReferrerStore) {
+	for _, store := range stores {
+		orchestrator.StopShared(store)
+		// stores served over gRPC hold a connection to the plugin
+		if closer, ok := store.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				logrus.Warnf("failed to close store %s: %v", store.Name(), err)
+			}
+		}
+	}
+}
+
 // Setup a watcher on file at configFilePath, reload executor on file change
 func watchForConfigurationChange(configFilePath string) error {
 	watcher, err := fsnotify.NewWatcher()
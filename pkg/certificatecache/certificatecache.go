@@ -0,0 +1,251 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificatecache
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/deislabs/ratify/pkg/controllers"
+	"github.com/deislabs/ratify/pkg/utils"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	defaultCache     *CertificateCache
+	defaultCacheOnce sync.Once
+)
+
+// CertificateCache is an in-memory index of parsed certificates shared by verifiers.
+// Certificates loaded from trust store paths are indexed by path and invalidated when
+// the underlying files change. Certificates fetched by CertificateStores are indexed by
+// store name and kept up to date through CertificateStoreReconciler updates.
+// The expiry of all certificates loaded is reported as metrics.
+type CertificateCache struct {
+	lock sync.RWMutex
+	// a map between a cleaned trust store path and the certificates loaded from it
+	pathCerts map[string][]*x509.Certificate
+	// a map between a watched directory and the cached paths depending on it
+	watchedDirs map[string]map[string]struct{}
+	// a copy-on-write map between CertificateStore name and its certificates
+	storeCerts map[string][]*x509.Certificate
+	watcher    *fsnotify.Watcher
+	expiry     *expiryMonitor
+	// generation is incremented on every invalidation, certificates read while it changed are not cached
+	generation uint64
+}
+
+// Default returns the process wide certificate cache subscribed to CertificateStore updates.
+func Default() *CertificateCache {
+	defaultCacheOnce.Do(func() {
+		cache, err := NewCertificateCache()
+		if err != nil {
+			logrus.Warnf("failed to watch trust store paths, certificates will be loaded from disk for each verification, err: %v", err)
+		}
+		controllers.SubscribeCertificateStoreUpdates(cache.SetStoreCertificates)
+		go cache.expiry.run()
+		defaultCache = cache
+	})
+	return defaultCache
+}
+
+// NewCertificateCache creates a certificate cache with a file watcher used to invalidate path entries.
+// If the watcher cannot be created, the returned cache is still usable but path lookups are not cached.
+func NewCertificateCache() (*CertificateCache, error) {
+	cache := &CertificateCache{
+		pathCerts:   map[string][]*x509.Certificate{},
+		watchedDirs: map[string]map[string]struct{}{},
+		storeCerts:  map[string][]*x509.Certificate{},
+		expiry:      newExpiryMonitor(),
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return cache, errors.Wrap(err, "new file watcher on trust store paths failed")
+	}
+	cache.watcher = watcher
+	go cache.watch()
+
+	return cache, nil
+}
+
+// GetCertificatesFromPath returns the certificates under path, reading them from disk only on a cache miss.
+func (c *CertificateCache) GetCertificatesFromPath(path string) ([]*x509.Certificate, error) {
+	key := filepath.Clean(utils.ReplaceHomeShortcut(path))
+
+	c.lock.RLock()
+	certs, ok := c.pathCerts[key]
+	c.lock.RUnlock()
+	if ok {
+		return certs, nil
+	}
+
+	// the path is watched before it is read so that changes made while reading invalidate the result
+	watched, generation := c.watchPath(key)
+
+	certs, err := utils.GetCertificatesFromPath(key)
+	if err != nil {
+		// the certificates of a path that can no longer be read are no longer monitored
+		c.expiry.update(SourceTypeTrustStorePath, key, nil)
+		return nil, err
+	}
+	c.expiry.update(SourceTypeTrustStorePath, key, certs)
+
+	// paths that cannot be watched are not cached since changes would go unnoticed
+	if !watched {
+		return certs, nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.generation == generation {
+		c.pathCerts[key] = certs
+	}
+
+	return certs, nil
+}
+
+// LoadPaths loads the certificates of trust store paths so their expiry is reported before any verification reads them.
+func (c *CertificateCache) LoadPaths(paths []string) {
+	for _, path := range paths {
+		if _, err := c.GetCertificatesFromPath(path); err != nil {
+			logrus.Warnf("failed to load certificates from trust store path '%v', err: %v", path, err)
+		}
+	}
+}
+
+// watchPath watches the directories path depends on, and returns whether they are watched and the generation of the
+// cache once they are
+func (c *CertificateCache) watchPath(key string) (bool, uint64) {
+	if c.watcher == nil {
+		return false, 0
+	}
+	dirs, err := utils.GetDirectoriesToWatch(key)
+	if err != nil {
+		logrus.Debugf("trust store path '%v' is not cached, err: %v", key, err)
+		return false, 0
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, dir := range dirs {
+		if _, watched := c.watchedDirs[dir]; !watched {
+			if err := c.watcher.Add(dir); err != nil {
+				logrus.Warnf("adding trust store watcher on '%v' failed, err: %v", dir, err)
+				return false, 0
+			}
+			c.watchedDirs[dir] = map[string]struct{}{}
+		}
+		c.watchedDirs[dir][key] = struct{}{}
+	}
+	return true, c.generation
+}
+
+// GetStoreCertificates returns a read-only snapshot of certificates indexed by CertificateStore name.
+func (c *CertificateCache) GetStoreCertificates() map[string][]*x509.Certificate {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.storeCerts
+}
+
+// SetStoreCertificates updates the certificates of a CertificateStore, nil certificates removes the store.
+func (c *CertificateCache) SetStoreCertificates(storeName string, certificates []*x509.Certificate) {
+	c.expiry.update(SourceTypeCertificateStore, storeName, certificates)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	storeCerts := make(map[string][]*x509.Certificate, len(c.storeCerts)+1)
+	for name, certs := range c.storeCerts {
+		storeCerts[name] = certs
+	}
+	if certificates == nil {
+		delete(storeCerts, storeName)
+	} else {
+		storeCerts[storeName] = certificates
+	}
+	c.storeCerts = storeCerts
+}
+
+// InvalidatePath removes the cached certificates of path so they are read again on next access.
+func (c *CertificateCache) InvalidatePath(path string) {
+	key := filepath.Clean(utils.ReplaceHomeShortcut(path))
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.generation++
+	delete(c.pathCerts, key)
+}
+
+// Close stops watching trust store paths.
+func (c *CertificateCache) Close() error {
+	if c.watcher == nil {
+		return nil
+	}
+	return c.watcher.Close()
+}
+
+// watch invalidates cached paths on changes to the files or directories they depend on
+func (c *CertificateCache) watch() {
+	for {
+		select {
+		case event, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+			logrus.Debugf("trust store watcher event detected %v", event)
+			c.invalidateDir(filepath.Dir(event.Name))
+			// the event may refer to a watched directory itself being removed or renamed
+			c.invalidateDir(event.Name)
+		case err, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+			logrus.Warnf("trust store watcher returned error: %v, invalidating all cached paths", err)
+			c.lock.Lock()
+			c.generation++
+			c.pathCerts = map[string][]*x509.Certificate{}
+			c.lock.Unlock()
+		}
+	}
+}
+
+func (c *CertificateCache) invalidateDir(dir string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	paths, ok := c.watchedDirs[dir]
+	if !ok {
+		return
+	}
+	c.generation++
+	for path := range paths {
+		delete(c.pathCerts, path)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			c.expiry.update(SourceTypeTrustStorePath, path, nil)
+			delete(paths, path)
+		}
+	}
+	// directories are watched again when the paths are reloaded
+	if _, err := os.Stat(dir); err != nil {
+		delete(c.watchedDirs, dir)
+		_ = c.watcher.Remove(dir)
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificatecache
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deislabs/ratify/pkg/utils"
+)
+
+const (
+	certStr = "-----BEGIN CERTIFICATE-----\nMIIDsDCCApigAwIBAgIQMdNmNTKwQ9aOe6iuMRokDzANBgkqhkiG9w0BAQsFADBa\nMQswCQYDVQQGEwJVUzELMAkGA1UECBMCV0ExEDAOBgNVBAcTB1NlYXR0bGUxDzAN\nBgNVBAoTBk5vdGFyeTEbMBkGA1UEAxMSd2FiYml0LW5ldHdvcmtzLmlvMB4XDTIy\nMTIxNDIxNTAzMVoXDTIzMTIxNDIyMDAzMVowWjELMAkGA1UEBhMCVVMxCzAJBgNV\nBAgTAldBMRAwDgYDVQQHEwdTZWF0dGxlMQ8wDQYDVQQKEwZOb3RhcnkxGzAZBgNV\nBAMTEndhYmJpdC1uZXR3b3Jrcy5pbzCCASIwDQYJKoZIhvcNAQEBBQADggEPADCC\nAQoCggEBAOP6AHCFz41kRqsAiv6guFtQVsrzMgzoCX7o9NtQ57rr8BESP1LTGRAO\n4bjyP0i+at5uwIm4tdz0gW+g0P+f9bmfiScYgOFuxAJxLkMkBWPN3dJ9ulP/OGgB\n6mSCsEGreB3uaGc5rMbWCRaux65bMPjEzx5ex0qRSsn+fFMTwINPQUJpXSvi/W2/\n1umEWE1x59x0vlkP2dN7CXtB5/Bh01QNNbMdKU9saYn0kaBrCYZLwr6AxFRzLqLM\nQggy/6bOp/+cTTVqTiChMcdyIX52GRr2lChRsB34dDPYxDeKSI5LoRy07bveLjex\n4wm9+vx/WOSS5z0QPvE/v8avuIkMXR0CAwEAAaNyMHAwDgYDVR0PAQH/BAQDAgeA\nMAkGA1UdEwQCMAAwEwYDVR0lBAwwCgYIKwYBBQUHAwMwHwYDVR0jBBgwFoAUwVvE\nvqQPxnE6j6pfX6jpSyv2dOAwHQYDVR0OBBYEFMFbxL6kD8ZxOo+qX1+o6Usr9nTg\nMA0GCSqGSIb3DQEBCwUAA4IBAQDE61FLbagvlCcXf0zcv+mUQ+0HvDVs7ofQe3Yw\naz7gAwxgTspr+jIFQWnPOOBupsyx/jucoz78ndbc5DGWPs2Qz/pIEGnLto2W/PYy\nas/9n8xHxembS4n/Mxxp60PF6ladi/nJAtDJds67sBeqLOfJzh6jV2uQvW7PXe1P\nOMSUHbBn8AfArZ/9njusiLs75+XcAgpnBFqKVv2Vd/INp2YQpVzusuiodeM8A9Qt\n/5xykjdCJw3ceZxD7dSkHgchKZPINFBYHt/EkN/d8mXFOKjGXZyntp4PO6PJ2HYN\nhMMDwdNu4mBmlMTdZMPEpIZIeW7G0P9KpCuvvD7po7NxdBgI\n-----END CERTIFICATE-----\n"
+)
+
+func TestGetCertificatesFromPath_CachedUntilChanged(t *testing.T) {
+	cache, err := NewCertificateCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	dir := t.TempDir()
+	writeCertFile(t, filepath.Join(dir, "cert1.crt"))
+
+	certs, err := cache.GetCertificatesFromPath(dir)
+	if err != nil || len(certs) != 1 {
+		t.Fatalf("expected 1 certificate, got %v, err: %v", len(certs), err)
+	}
+
+	cache.lock.RLock()
+	_, cached := cache.pathCerts[filepath.Clean(dir)]
+	cache.lock.RUnlock()
+	if !cached {
+		t.Fatalf("expected path %v to be cached", dir)
+	}
+
+	writeCertFile(t, filepath.Join(dir, "cert2.crt"))
+
+	// the watcher invalidates the entry asynchronously
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		certs, err = cache.GetCertificatesFromPath(dir)
+		if err == nil && len(certs) == 2 {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("expected 2 certificates after update, got %v, err: %v", len(certs), err)
+}
+
+func TestGetCertificatesFromPath_RemovedPathNotMonitored(t *testing.T) {
+	cache, err := NewCertificateCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	path := filepath.Join(t.TempDir(), "cert.crt")
+	writeCertFile(t, path)
+	if _, err := cache.GetCertificatesFromPath(path); err != nil {
+		t.Fatalf("failed to get certificates: %v", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove certificate: %v", err)
+	}
+
+	key := SourceTypeTrustStorePath + "/" + filepath.Clean(path)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		cache.expiry.lock.Lock()
+		_, monitored := cache.expiry.sources[key]
+		cache.expiry.lock.Unlock()
+		if !monitored {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("expected removed path %v to no longer be monitored", path)
+}
+
+func TestGetCertificatesFromPath_MissingPathNotCached(t *testing.T) {
+	cache, err := NewCertificateCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	path := filepath.Join(t.TempDir(), "missing")
+	if certs, err := cache.GetCertificatesFromPath(path); err != nil || len(certs) != 0 {
+		t.Fatalf("expected no certificates, got %v, err: %v", len(certs), err)
+	}
+
+	if err := os.Mkdir(path, 0700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	writeCertFile(t, filepath.Join(path, "cert.crt"))
+
+	if certs, err := cache.GetCertificatesFromPath(path); err != nil || len(certs) != 1 {
+		t.Fatalf("expected 1 certificate, got %v, err: %v", len(certs), err)
+	}
+}
+
+func TestLoadPaths_MonitoredBeforeVerification(t *testing.T) {
+	cache, err := NewCertificateCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	dir := t.TempDir()
+	writeCertFile(t, filepath.Join(dir, "cert.crt"))
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	cache.LoadPaths([]string{dir, missing})
+
+	cache.expiry.lock.Lock()
+	defer cache.expiry.lock.Unlock()
+	if certs := cache.expiry.sources[SourceTypeTrustStorePath+"/"+filepath.Clean(dir)]; len(certs) != 1 {
+		t.Fatalf("expected 1 monitored certificate for %v, got %v", dir, len(certs))
+	}
+	if certs := cache.expiry.sources[SourceTypeTrustStorePath+"/"+filepath.Clean(missing)]; len(certs) != 0 {
+		t.Fatalf("expected no monitored certificates for %v, got %v", missing, len(certs))
+	}
+}
+
+func TestInvalidatePath(t *testing.T) {
+	cache, err := NewCertificateCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	dir := t.TempDir()
+	writeCertFile(t, filepath.Join(dir, "cert.crt"))
+	if _, err := cache.GetCertificatesFromPath(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cache.InvalidatePath(dir)
+
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	if _, cached := cache.pathCerts[filepath.Clean(dir)]; cached {
+		t.Fatalf("expected path %v to be invalidated", dir)
+	}
+}
+
+func TestSetStoreCertificates(t *testing.T) {
+	cache, err := NewCertificateCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	cert := &x509.Certificate{}
+	cache.SetStoreCertificates("store1", []*x509.Certificate{cert})
+	snapshot := cache.GetStoreCertificates()
+	if len(snapshot["store1"]) != 1 {
+		t.Fatalf("expected 1 certificate for store1, got %v", len(snapshot["store1"]))
+	}
+
+	cache.SetStoreCertificates("store1", nil)
+	if _, ok := cache.GetStoreCertificates()["store1"]; ok {
+		t.Fatalf("expected store1 to be removed")
+	}
+
+	// snapshots handed out earlier must not change
+	if len(snapshot["store1"]) != 1 {
+		t.Fatalf("expected previous snapshot to be unchanged")
+	}
+}
+
+func BenchmarkGetCertificatesFromPath(b *testing.B) {
+	dir := b.TempDir()
+	for _, name := range []string{"cert1.crt", "cert2.crt", "cert3.crt", "cert4.crt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(certStr), 0600); err != nil {
+			b.Fatalf("failed to write cert: %v", err)
+		}
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := utils.GetCertificatesFromPath(dir); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		cache, err := NewCertificateCache()
+		if err != nil {
+			b.Fatalf("failed to create cache: %v", err)
+		}
+		defer cache.Close()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := cache.GetCertificatesFromPath(dir); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func writeCertFile(t *testing.T, path string) {
+	if err := os.WriteFile(path, []byte(certStr), 0600); err != nil {
+		t.Fatalf("failed to write cert: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificatecache
+
+import (
+	"crypto/x509"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deislabs/ratify/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// SourceTypeTrustStorePath identifies certificates loaded from a trust store path
+	SourceTypeTrustStorePath = "trustStorePath"
+	// SourceTypeCertificateStore identifies certificates fetched by a CertificateStore
+	SourceTypeCertificateStore = "certificateStore"
+
+	// how often certificates are checked against the warning thresholds
+	expiryCheckInterval = time.Hour
+)
+
+var (
+	// DefaultExpiryWarningThresholds are the days before expiry at which warnings are logged
+	DefaultExpiryWarningThresholds = []int{30, 7, 1}
+
+	expiryWarningThresholds     = DefaultExpiryWarningThresholds
+	expiryWarningThresholdsLock sync.RWMutex
+)
+
+// SetExpiryWarningThresholds configures the days before certificate expiry at which warnings are logged.
+func SetExpiryWarningThresholds(days []int) {
+	thresholds := make([]int, 0, len(days))
+	for _, day := range days {
+		if day > 0 {
+			thresholds = append(thresholds, day)
+		}
+	}
+	expiryWarningThresholdsLock.Lock()
+	defer expiryWarningThresholdsLock.Unlock()
+	expiryWarningThresholds = thresholds
+}
+
+func getExpiryWarningThresholds() []int {
+	expiryWarningThresholdsLock.RLock()
+	defer expiryWarningThresholdsLock.RUnlock()
+	return expiryWarningThresholds
+}
+
+// expiryMonitor reports the expiry of loaded certificates as metrics and logs a warning
+// each time a certificate crosses one of the warning thresholds.
+type expiryMonitor struct {
+	lock sync.Mutex
+	// a map between a source key and the certificates loaded from it
+	sources map[string][]*x509.Certificate
+	// a map between a source and certificate key and the last threshold a warning was logged for
+	warned map[string]int
+	now    func() time.Time
+}
+
+func newExpiryMonitor() *expiryMonitor {
+	return &expiryMonitor{
+		sources: map[string][]*x509.Certificate{},
+		warned:  map[string]int{},
+		now:     time.Now,
+	}
+}
+
+// update records the certificates of a source and checks them against the warning thresholds, nil certificates removes the source
+func (m *expiryMonitor) update(sourceType, source string, certificates []*x509.Certificate) {
+	metrics.ReportCertificateExpiry(sourceType, source, certificates)
+
+	key := sourceType + "/" + source
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if certificates == nil {
+		delete(m.sources, key)
+		for certKey := range m.warned {
+			if strings.HasPrefix(certKey, key+"/") {
+				delete(m.warned, certKey)
+			}
+		}
+		return
+	}
+	m.sources[key] = certificates
+	m.checkSource(key, certificates)
+}
+
+// check verifies all recorded certificates against the warning thresholds
+func (m *expiryMonitor) check() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key, certs := range m.sources {
+		m.checkSource(key, certs)
+	}
+}
+
+// run checks certificates periodically so warnings are logged for long lived sources
+func (m *expiryMonitor) run() {
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.check()
+	}
+}
+
+func (m *expiryMonitor) checkSource(key string, certificates []*x509.Certificate) {
+	now := m.now()
+	for _, cert := range certificates {
+		certKey := key + "/" + cert.SerialNumber.Text(16)
+		remaining := cert.NotAfter.Sub(now)
+		if remaining <= 0 {
+			if m.warned[certKey] != -1 {
+				logrus.Warnf("certificate '%v' with serial %v from %v expired at %v", cert.Subject, cert.SerialNumber.Text(16), key, cert.NotAfter.Format(time.RFC3339))
+				m.warned[certKey] = -1
+			}
+			continue
+		}
+
+		threshold := crossedThreshold(remaining, getExpiryWarningThresholds())
+		if threshold == 0 {
+			delete(m.warned, certKey)
+			continue
+		}
+		if last, ok := m.warned[certKey]; ok && last <= threshold {
+			continue
+		}
+		logrus.Warnf("certificate '%v' with serial %v from %v expires in %d days at %v, within the %d days warning threshold", cert.Subject, cert.SerialNumber.Text(16), key, int(remaining.Hours()/24), cert.NotAfter.Format(time.RFC3339), threshold)
+		m.warned[certKey] = threshold
+	}
+}
+
+// crossedThreshold returns the smallest threshold in days that remaining is within, 0 if none
+func crossedThreshold(remaining time.Duration, thresholds []int) int {
+	crossed := 0
+	for _, days := range thresholds {
+		if remaining <= time.Duration(days)*24*time.Hour && (crossed == 0 || days < crossed) {
+			crossed = days
+		}
+	}
+	return crossed
+}
@@ -0,0 +1,85 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificatecache
+
+import (
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestCrossedThreshold(t *testing.T) {
+	thresholds := []int{30, 7, 1}
+	testCases := []struct {
+		name      string
+		remaining time.Duration
+		expected  int
+	}{
+		{name: "not within any threshold", remaining: 60 * 24 * time.Hour, expected: 0},
+		{name: "within largest threshold", remaining: 20 * 24 * time.Hour, expected: 30},
+		{name: "within middle threshold", remaining: 3 * 24 * time.Hour, expected: 7},
+		{name: "within smallest threshold", remaining: time.Hour, expected: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := crossedThreshold(tc.remaining, thresholds); actual != tc.expected {
+				t.Fatalf("expected threshold %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExpiryMonitor_WarnsOncePerThreshold(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+	SetExpiryWarningThresholds([]int{30, 7})
+	defer SetExpiryWarningThresholds(DefaultExpiryWarningThresholds)
+
+	now := time.Now()
+	monitor := newExpiryMonitor()
+	monitor.now = func() time.Time { return now }
+	cert := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: now.Add(10 * 24 * time.Hour)}
+
+	monitor.update(SourceTypeCertificateStore, "store1", []*x509.Certificate{cert})
+	monitor.check()
+	if len(hook.Entries) != 1 || hook.LastEntry().Level != logrus.WarnLevel {
+		t.Fatalf("expected a single warning for the 30 days threshold, got %v entries", len(hook.Entries))
+	}
+
+	// crossing the next threshold logs another warning
+	now = now.Add(5 * 24 * time.Hour)
+	monitor.check()
+	if len(hook.Entries) != 2 {
+		t.Fatalf("expected a warning for the 7 days threshold, got %v entries", len(hook.Entries))
+	}
+
+	now = now.Add(6 * 24 * time.Hour)
+	monitor.check()
+	monitor.check()
+	if len(hook.Entries) != 3 {
+		t.Fatalf("expected a single warning for the expired certificate, got %v entries", len(hook.Entries))
+	}
+
+	monitor.update(SourceTypeCertificateStore, "store1", nil)
+	if len(monitor.sources) != 0 || len(monitor.warned) != 0 {
+		t.Fatalf("expected removed source to be cleared")
+	}
+}
@@ -35,6 +35,13 @@ type CertificateProvider interface {
 	GetCertificates(ctx context.Context, attrib map[string]string) ([]*x509.Certificate, CertificatesStatus, error)
 }
 
+// WatchableCertificateProvider is implemented by providers whose certificates may change without an update to the CertificateStore
+type WatchableCertificateProvider interface {
+	CertificateProvider
+	// Watch starts watching the certificates defined in attrib map and invokes notify on change until ctx is done
+	Watch(ctx context.Context, attrib map[string]string, notify func()) error
+}
+
 var certificateProviders = make(map[string]CertificateProvider)
 
 // returns the internal certificate provider map
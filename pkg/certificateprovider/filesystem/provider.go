@@ -0,0 +1,256 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deislabs/ratify/pkg/certificateprovider"
+	"github.com/deislabs/ratify/pkg/utils"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PathsParameter is the name of the parameter that contains a comma separated list of certificate files or directories
+	PathsParameter        = "paths"
+	providerName   string = "filesystem"
+
+	// CertificatesStatus is the key of the per-file status array
+	CertificatesStatus = "certificates"
+	// FilePath is the path of the file the certificates were read from
+	FilePath = "path"
+	// FileDigest is the sha256 digest of the file content, it changes on rotation
+	FileDigest = "digest"
+	// CertificateCount is the number of certificates read from the file
+	CertificateCount = "certificateCount"
+	// FileError is the reason the file was skipped
+	FileError = "error"
+	// CertificateLastRefreshed is the time the file was read
+	CertificateLastRefreshed = "lastRefreshed"
+
+	// Kubernetes volume updates consist of several file events, changes are reported once they settle
+	defaultDebounceInterval = time.Second
+)
+
+type fileCertProvider struct {
+	debounceInterval time.Duration
+}
+
+// init calls to register the provider
+func init() {
+	certificateprovider.Register(providerName, Create())
+}
+
+func Create() certificateprovider.CertificateProvider {
+	return &fileCertProvider{debounceInterval: defaultDebounceInterval}
+}
+
+// returns an array of certificates read from the PEM or DER files under the paths defined in attrib map
+func (s *fileCertProvider) GetCertificates(ctx context.Context, attrib map[string]string) ([]*x509.Certificate, certificateprovider.CertificatesStatus, error) {
+	paths, err := getPaths(attrib)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certs := []*x509.Certificate{}
+	certsStatus := []map[string]string{}
+	lastRefreshed := time.Now().Format(time.RFC3339)
+
+	for _, path := range paths {
+		files, err := listCertificateFiles(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read certificate path %s, error: %w", path, err)
+		}
+
+		for _, file := range files {
+			fileCerts, status, err := readCertificateFile(file)
+			if err != nil {
+				return nil, nil, err
+			}
+			status[CertificateLastRefreshed] = lastRefreshed
+			certsStatus = append(certsStatus, status)
+			certs = append(certs, fileCerts...)
+		}
+	}
+
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("no certificates found under paths %v", paths)
+	}
+
+	logrus.Debugf("%v certificates read from paths %v", len(certs), paths)
+	return certs, certificateprovider.CertificatesStatus{CertificatesStatus: certsStatus}, nil
+}
+
+// Watch calls notify whenever a file under the paths defined in attrib changes, until ctx is done
+func (s *fileCertProvider) Watch(ctx context.Context, attrib map[string]string, notify func()) error {
+	paths, err := getPaths(attrib)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("new file watcher on certificate paths failed, error: %w", err)
+	}
+
+	watched := map[string]bool{}
+	for _, path := range paths {
+		dirs, err := utils.GetDirectoriesToWatch(path)
+		if err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch certificate path %s, error: %w", path, err)
+		}
+		for _, dir := range dirs {
+			if watched[dir] {
+				continue
+			}
+			if err := watcher.Add(dir); err != nil {
+				watcher.Close()
+				return fmt.Errorf("adding certificate watcher on %s failed, error: %w", dir, err)
+			}
+			watched[dir] = true
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		// the timer is only armed once an event has been received
+		timer := time.NewTimer(s.debounceInterval)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				logrus.Debugf("certificate file watcher event detected %v", event)
+				timer.Reset(s.debounceInterval)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logrus.Warnf("certificate file watcher returned error: %v", err)
+			case <-timer.C:
+				logrus.Infof("certificate files changed under paths %v", paths)
+				notify()
+			}
+		}
+	}()
+
+	return nil
+}
+
+func getPaths(attrib map[string]string) ([]string, error) {
+	value, ok := attrib[PathsParameter]
+	if !ok || strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("paths parameter is not set")
+	}
+
+	paths := []string{}
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, utils.ReplaceHomeShortcut(path))
+		}
+	}
+	return paths, nil
+}
+
+// listCertificateFiles returns the regular files under path with symbolic links resolved.
+// Kubernetes atomic writer internals (entries prefixed with "..") are skipped since
+// the projected files already link to them.
+func listCertificateFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	seen := map[string]bool{}
+	files := []string{}
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if file != path && strings.HasPrefix(info.Name(), "..") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target, err := filepath.EvalSymlinks(file)
+		if err != nil {
+			logrus.Warnf("unable to resolve symbolic link %v, error: %v", file, err)
+			return nil
+		}
+		targetInfo, err := os.Stat(target)
+		if err != nil || targetInfo.IsDir() || seen[target] {
+			return nil
+		}
+		seen[target] = true
+		files = append(files, file)
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// readCertificateFile decodes certificates from a PEM bundle or a DER encoded file.
+// Files that do not hold certificates, like private keys or tokens in a mounted secret, are reported in the status but not treated as errors.
+func readCertificateFile(file string) ([]*x509.Certificate, map[string]string, error) {
+	status := map[string]string{FilePath: file}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read certificate file %s, error: %w", file, err)
+	}
+	digest := sha256.Sum256(data)
+	status[FileDigest] = "sha256:" + hex.EncodeToString(digest[:])
+
+	var certs []*x509.Certificate
+	if block, _ := pem.Decode(data); block != nil {
+		certs, err = certificateprovider.DecodeCertificates(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode certificate file %s, error: %w", file, err)
+		}
+	} else if certs, err = x509.ParseCertificates(data); err != nil {
+		certs = nil
+		status[FileError] = "not a PEM or DER encoded certificate"
+	}
+
+	if len(certs) == 0 && status[FileError] == "" {
+		status[FileError] = "no certificate found"
+	}
+	status[CertificateCount] = strconv.Itoa(len(certs))
+	return certs, status, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashicorpvault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/deislabs/ratify/pkg/certificateprovider/hashicorpvault/types"
+)
+
+const (
+	vaultTokenEnvVar               = "VAULT_TOKEN"                                         //nolint
+	vaultSecretIDEnvVar            = "VAULT_SECRET_ID"                                     //nolint
+	defaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token" //nolint
+)
+
+// loginResponse is the subset of a Vault auth method login response
+type loginResponse struct {
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+}
+
+// authenticate returns a Vault token using the auth method defined in attrib map
+func (c *vaultClient) authenticate(ctx context.Context, attrib map[string]string) error {
+	authMethod := strings.ToLower(types.GetParameter(attrib, types.AuthMethodParameter))
+	if authMethod == "" {
+		authMethod = types.AuthMethodToken
+	}
+	mountPath := strings.Trim(types.GetParameter(attrib, types.AuthMountPathParameter), "/")
+	if mountPath == "" {
+		mountPath = authMethod
+	}
+
+	var body map[string]string
+	switch authMethod {
+	case types.AuthMethodToken:
+		token := types.GetParameter(attrib, types.TokenParameter)
+		if token == "" {
+			token = strings.TrimSpace(os.Getenv(vaultTokenEnvVar))
+		}
+		if token == "" {
+			return fmt.Errorf("token is not set")
+		}
+		c.token = token
+		return nil
+	case types.AuthMethodAppRole:
+		roleID := types.GetParameter(attrib, types.RoleIDParameter)
+		if roleID == "" {
+			return fmt.Errorf("roleID is not set")
+		}
+		secretID, err := appRoleSecretID(attrib)
+		if err != nil {
+			return err
+		}
+		body = map[string]string{"role_id": roleID, "secret_id": secretID}
+	case types.AuthMethodKubernetes:
+		role := types.GetParameter(attrib, types.RoleParameter)
+		if role == "" {
+			return fmt.Errorf("role is not set")
+		}
+		tokenPath := types.GetParameter(attrib, types.ServiceAccountTokenPathParameter)
+		if tokenPath == "" {
+			tokenPath = defaultServiceAccountTokenPath
+		}
+		jwt, err := os.ReadFile(tokenPath)
+		if err != nil {
+			return fmt.Errorf("failed to read service account token, error: %w", err)
+		}
+		body = map[string]string{"role": role, "jwt": strings.TrimSpace(string(jwt))}
+	default:
+		return fmt.Errorf("unsupported authMethod %s, supported values are %s, %s and %s", authMethod, types.AuthMethodToken, types.AuthMethodAppRole, types.AuthMethodKubernetes)
+	}
+
+	var response loginResponse
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("auth/%s/login", mountPath), nil, body, &response); err != nil {
+		return fmt.Errorf("failed to login with %s auth method, error: %w", authMethod, err)
+	}
+	if response.Auth.ClientToken == "" {
+		return fmt.Errorf("no client token returned by %s auth method", authMethod)
+	}
+	c.token = response.Auth.ClientToken
+	return nil
+}
+
+// appRoleSecretID returns the AppRole secret ID read from secretIDPath, the inline
+// secretID parameter or the VAULT_SECRET_ID environment variable, in that order
+func appRoleSecretID(attrib map[string]string) (string, error) {
+	if secretIDPath := types.GetParameter(attrib, types.SecretIDPathParameter); secretIDPath != "" {
+		secretID, err := os.ReadFile(secretIDPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secretID, error: %w", err)
+		}
+		return strings.TrimSpace(string(secretID)), nil
+	}
+	if secretID := types.GetParameter(attrib, types.SecretIDParameter); secretID != "" {
+		return secretID, nil
+	}
+	if secretID := strings.TrimSpace(os.Getenv(vaultSecretIDEnvVar)); secretID != "" {
+		return secretID, nil
+	}
+	return "", fmt.Errorf("secretID is not set")
+}
@@ -0,0 +1,333 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashicorpvault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deislabs/ratify/pkg/certificateprovider"
+	"github.com/deislabs/ratify/pkg/certificateprovider/hashicorpvault/types"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	providerName string = "hashicorpvault"
+
+	defaultKVKey     = "certificate"
+	defaultPKISerial = "ca_chain"
+	requestTimeout   = 30 * time.Second
+	// limits the size of responses read from Vault
+	maxResponseBytes = 10 << 20
+)
+
+type vaultCertProvider struct{}
+
+// vaultClient is a minimal client of the Vault HTTP API
+type vaultClient struct {
+	address    string
+	namespace  string
+	token      string
+	httpClient *http.Client
+}
+
+// kvResponse is the subset of a KV version 2 read response
+type kvResponse struct {
+	Data struct {
+		Data     map[string]interface{} `json:"data"`
+		Metadata struct {
+			Version int `json:"version"`
+		} `json:"metadata"`
+	} `json:"data"`
+}
+
+// pkiResponse is the subset of a PKI read certificate response
+type pkiResponse struct {
+	Data struct {
+		Certificate string `json:"certificate"`
+	} `json:"data"`
+}
+
+// init calls to register the provider
+func init() {
+	certificateprovider.Register(providerName, Create())
+}
+
+func Create() certificateprovider.CertificateProvider {
+	return &vaultCertProvider{}
+}
+
+// returns an array of certificates based on certificate properties defined in attrib map
+func (s *vaultCertProvider) GetCertificates(ctx context.Context, attrib map[string]string) ([]*x509.Certificate, certificateprovider.CertificatesStatus, error) {
+	vaultAddress := types.GetParameter(attrib, types.VaultAddressParameter)
+	if vaultAddress == "" {
+		return nil, nil, fmt.Errorf("vaultAddress is not set")
+	}
+
+	vaultCerts, err := getVaultRequestObj(attrib)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get vault request object from provider attributes, error: %w", err)
+	}
+	if len(vaultCerts) == 0 {
+		return nil, nil, fmt.Errorf("no vault certificate configured")
+	}
+
+	client, err := newVaultClient(vaultAddress, types.GetParameter(attrib, types.NamespaceParameter), types.GetParameter(attrib, types.CACertPathParameter))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := client.authenticate(ctx, attrib); err != nil {
+		return nil, nil, fmt.Errorf("failed to authenticate to vault, error: %w", err)
+	}
+
+	certs := []*x509.Certificate{}
+	certsStatus := []map[string]string{}
+	lastRefreshed := time.Now().Format(time.RFC3339)
+
+	for _, vaultCert := range vaultCerts {
+		logrus.Debugf("fetching object from vault, certName %v, engine %v, mount %v", vaultCert.CertificateName, vaultCert.Engine, vaultCert.MountPath)
+
+		content, version, err := client.getCertificate(ctx, vaultCert)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		decodedCerts, err := certificateprovider.DecodeCertificates([]byte(content))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode certificate %s, error: %w", vaultCert.CertificateName, err)
+		}
+		if len(decodedCerts) == 0 {
+			return nil, nil, fmt.Errorf("no certificate found in %s", vaultCert.CertificateName)
+		}
+		if version == "" {
+			version = decodedCerts[0].SerialNumber.Text(16)
+		}
+
+		certs = append(certs, decodedCerts...)
+		logrus.Debugf("cert '%v', version '%v' added", vaultCert.CertificateName, version)
+
+		certsStatus = append(certsStatus, getCertStatusProperty(vaultCert.CertificateName, version, lastRefreshed))
+	}
+
+	return certs, getCertStatusMap(certsStatus), nil
+}
+
+// vault provider certificate status is a map from "certificates" key to an array of of certificate status
+func getCertStatusMap(certsStatus []map[string]string) certificateprovider.CertificatesStatus {
+	status := certificateprovider.CertificatesStatus{}
+	status[types.CertificatesStatus] = certsStatus
+	return status
+}
+
+// return a certificate status object that consist of the cert name, version and last refreshed time
+func getCertStatusProperty(certificateName, version, lastRefreshed string) map[string]string {
+	certProperty := map[string]string{}
+	certProperty[types.CertificateName] = certificateName
+	certProperty[types.CertificateVersion] = version
+	certProperty[types.CertificateLastRefreshed] = lastRefreshed
+	return certProperty
+}
+
+// parse the requested vault cert objects from the input attributes
+func getVaultRequestObj(attrib map[string]string) ([]types.VaultCertificate, error) {
+	vaultCerts := []types.VaultCertificate{}
+
+	certificatesStrings := types.GetParameter(attrib, types.CertificatesParameter)
+	if certificatesStrings == "" {
+		return nil, fmt.Errorf("certificates is not set")
+	}
+
+	objects, err := types.GetCertificatesArray(certificatesStrings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to yaml unmarshal objects, error: %w", err)
+	}
+
+	for i, object := range objects.Array {
+		var vaultCert types.VaultCertificate
+		if err = yaml.Unmarshal([]byte(object), &vaultCert); err != nil {
+			return nil, fmt.Errorf("unmarshal failed for vault certificates at index %d, error: %w", i, err)
+		}
+		formatVaultCertificate(&vaultCert)
+		if err := validateVaultCertificate(vaultCert); err != nil {
+			return nil, fmt.Errorf("invalid vault certificate at index %d, error: %w", i, err)
+		}
+
+		vaultCerts = append(vaultCerts, vaultCert)
+	}
+
+	return vaultCerts, nil
+}
+
+// formatVaultCertificate trims the fields in VaultCertificate and applies defaults
+func formatVaultCertificate(object *types.VaultCertificate) {
+	objectValue := reflect.ValueOf(object).Elem()
+	for i := 0; i < objectValue.NumField(); i++ {
+		field := objectValue.Field(i)
+		if field.Kind() == reflect.String {
+			field.SetString(strings.TrimSpace(field.String()))
+		}
+	}
+
+	object.Engine = strings.ToLower(object.Engine)
+	object.MountPath = strings.Trim(object.MountPath, "/")
+	object.Path = strings.Trim(object.Path, "/")
+	if object.Engine == types.EngineKV && object.Key == "" {
+		object.Key = defaultKVKey
+	}
+	if object.Engine == types.EnginePKI && object.Serial == "" {
+		object.Serial = defaultPKISerial
+	}
+}
+
+func validateVaultCertificate(object types.VaultCertificate) error {
+	if object.CertificateName == "" {
+		return fmt.Errorf("certificateName is not set")
+	}
+	if object.MountPath == "" {
+		return fmt.Errorf("mountPath is not set")
+	}
+	switch object.Engine {
+	case types.EngineKV:
+		if object.Path == "" {
+			return fmt.Errorf("path is not set")
+		}
+		if object.Version != "" {
+			if _, err := strconv.Atoi(object.Version); err != nil {
+				return fmt.Errorf("version %s is not a number", object.Version)
+			}
+		}
+	case types.EnginePKI:
+	default:
+		return fmt.Errorf("unsupported engine %s, supported values are %s and %s", object.Engine, types.EngineKV, types.EnginePKI)
+	}
+	return nil
+}
+
+func newVaultClient(address, namespace, caCertPath string) (*vaultClient, error) {
+	if _, err := url.ParseRequestURI(address); err != nil {
+		return nil, fmt.Errorf("vaultAddress %s is not valid, error: %w", address, err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caCertPath != "" {
+		caCert, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault CA certificate, error: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificate found in vault CA certificate %s", caCertPath)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &vaultClient{
+		address:    strings.TrimSuffix(address, "/"),
+		namespace:  namespace,
+		httpClient: &http.Client{Transport: transport, Timeout: requestTimeout},
+	}, nil
+}
+
+// getCertificate returns the PEM content and version of the certificate
+func (c *vaultClient) getCertificate(ctx context.Context, object types.VaultCertificate) (string, string, error) {
+	switch object.Engine {
+	case types.EngineKV:
+		query := url.Values{}
+		if object.Version != "" {
+			query.Set("version", object.Version)
+		}
+		var response kvResponse
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/data/%s", object.MountPath, object.Path), query, nil, &response); err != nil {
+			return "", "", fmt.Errorf("failed to get certificate objectName:%s, path:%s, error: %w", object.CertificateName, object.Path, err)
+		}
+		value, ok := response.Data.Data[object.Key].(string)
+		if !ok {
+			return "", "", fmt.Errorf("key %s not found in secret %s", object.Key, object.Path)
+		}
+		return value, strconv.Itoa(response.Data.Metadata.Version), nil
+	default:
+		var response pkiResponse
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/cert/%s", object.MountPath, object.Serial), nil, nil, &response); err != nil {
+			return "", "", fmt.Errorf("failed to get certificate objectName:%s, serial:%s, error: %w", object.CertificateName, object.Serial, err)
+		}
+		if response.Data.Certificate == "" {
+			return "", "", fmt.Errorf("certificate value is empty")
+		}
+		return response.Data.Certificate, "", nil
+	}
+}
+
+// do sends a request to the Vault API and decodes the JSON response into result
+func (c *vaultClient) do(ctx context.Context, method, path string, query url.Values, body interface{}, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	reqURL := fmt.Sprintf("%s/v1/%s", c.address, path)
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(data, &vaultErr)
+		return fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.Join(vaultErr.Errors, ", "))
+	}
+	return json.Unmarshal(data, result)
+}
@@ -0,0 +1,328 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashicorpvault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deislabs/ratify/pkg/certificateprovider/hashicorpvault/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	certStr    = "-----BEGIN CERTIFICATE-----\nMIIDsDCCApigAwIBAgIQMdNmNTKwQ9aOe6iuMRokDzANBgkqhkiG9w0BAQsFADBa\nMQswCQYDVQQGEwJVUzELMAkGA1UECBMCV0ExEDAOBgNVBAcTB1NlYXR0bGUxDzAN\nBgNVBAoTBk5vdGFyeTEbMBkGA1UEAxMSd2FiYml0LW5ldHdvcmtzLmlvMB4XDTIy\nMTIxNDIxNTAzMVoXDTIzMTIxNDIyMDAzMVowWjELMAkGA1UEBhMCVVMxCzAJBgNV\nBAgTAldBMRAwDgYDVQQHEwdTZWF0dGxlMQ8wDQYDVQQKEwZOb3RhcnkxGzAZBgNV\nBAMTEndhYmJpdC1uZXR3b3Jrcy5pbzCCASIwDQYJKoZIhvcNAQEBBQADggEPADCC\nAQoCggEBAOP6AHCFz41kRqsAiv6guFtQVsrzMgzoCX7o9NtQ57rr8BESP1LTGRAO\n4bjyP0i+at5uwIm4tdz0gW+g0P+f9bmfiScYgOFuxAJxLkMkBWPN3dJ9ulP/OGgB\n6mSCsEGreB3uaGc5rMbWCRaux65bMPjEzx5ex0qRSsn+fFMTwINPQUJpXSvi/W2/\n1umEWE1x59x0vlkP2dN7CXtB5/Bh01QNNbMdKU9saYn0kaBrCYZLwr6AxFRzLqLM\nQggy/6bOp/+cTTVqTiChMcdyIX52GRr2lChRsB34dDPYxDeKSI5LoRy07bveLjex\n4wm9+vx/WOSS5z0QPvE/v8avuIkMXR0CAwEAAaNyMHAwDgYDVR0PAQH/BAQDAgeA\nMAkGA1UdEwQCMAAwEwYDVR0lBAwwCgYIKwYBBQUHAwMwHwYDVR0jBBgwFoAUwVvE\nvqQPxnE6j6pfX6jpSyv2dOAwHQYDVR0OBBYEFMFbxL6kD8ZxOo+qX1+o6Usr9nTg\nMA0GCSqGSIb3DQEBCwUAA4IBAQDE61FLbagvlCcXf0zcv+mUQ+0HvDVs7ofQe3Yw\naz7gAwxgTspr+jIFQWnPOOBupsyx/jucoz78ndbc5DGWPs2Qz/pIEGnLto2W/PYy\nas/9n8xHxembS4n/Mxxp60PF6ladi/nJAtDJds67sBeqLOfJzh6jV2uQvW7PXe1P\nOMSUHbBn8AfArZ/9njusiLs75+XcAgpnBFqKVv2Vd/INp2YQpVzusuiodeM8A9Qt\n/5xykjdCJw3ceZxD7dSkHgchKZPINFBYHt/EkN/d8mXFOKjGXZyntp4PO6PJ2HYN\nhMMDwdNu4mBmlMTdZMPEpIZIeW7G0P9KpCuvvD7po7NxdBgI\n-----END CERTIFICATE-----\n"
+	testToken  = "s.testtoken"
+	kvCerts    = "array:\n  - |\n    certificateName: root-ca\n    engine: kv\n    mountPath: secret\n    path: ratify/root-ca\n"
+	pkiCerts   = "array:\n  - |\n    certificateName: pki-ca\n    engine: pki\n    mountPath: pki\n"
+	roleID     = "test-role-id"
+	secretID   = "test-secret-id"
+	k8sRole    = "ratify"
+	k8sJWT     = "test-jwt"
+	kvVersion  = 3
+	loginToken = "s.logintoken"
+)
+
+// newVaultStandIn returns a server implementing the subset of the Vault API used by the provider
+func newVaultStandIn(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	authorized := func(w http.ResponseWriter, r *http.Request) bool {
+		token := r.Header.Get("X-Vault-Token")
+		if token != testToken && token != loginToken {
+			w.WriteHeader(http.StatusForbidden)
+			writeJSON(w, map[string]interface{}{"errors": []string{"permission denied"}})
+			return false
+		}
+		return true
+	}
+
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != http.MethodPost || body["role_id"] != roleID || body["secret_id"] != secretID {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]interface{}{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		writeJSON(w, map[string]interface{}{"auth": map[string]interface{}{"client_token": loginToken}})
+	})
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role"] != k8sRole || body["jwt"] != k8sJWT {
+			w.WriteHeader(http.StatusForbidden)
+			writeJSON(w, map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		writeJSON(w, map[string]interface{}{"auth": map[string]interface{}{"client_token": loginToken}})
+	})
+	mux.HandleFunc("/v1/secret/data/ratify/root-ca", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		version := kvVersion
+		if r.URL.Query().Get("version") == "1" {
+			version = 1
+		}
+		writeJSON(w, map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     map[string]interface{}{"certificate": certStr},
+				"metadata": map[string]interface{}{"version": version},
+			},
+		})
+	})
+	mux.HandleFunc("/v1/pki/cert/ca_chain", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"certificate": certStr + certStr}})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetCertificates(t *testing.T) {
+	server := newVaultStandIn(t)
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte(k8sJWT), 0600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+	secretIDPath := filepath.Join(t.TempDir(), "secret-id")
+	if err := os.WriteFile(secretIDPath, []byte(secretID+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write secret ID: %v", err)
+	}
+
+	cases := []struct {
+		desc            string
+		parameters      map[string]string
+		env             map[string]string
+		expectedErr     bool
+		expectedCerts   int
+		expectedVersion string
+	}{
+		{
+			desc:        "vaultAddress not provided",
+			parameters:  map[string]string{types.CertificatesParameter: kvCerts, types.TokenParameter: testToken},
+			expectedErr: true,
+		},
+		{
+			desc:        "certificates not provided",
+			parameters:  map[string]string{types.VaultAddressParameter: server.URL, types.TokenParameter: testToken},
+			expectedErr: true,
+		},
+		{
+			desc: "unsupported auth method",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: kvCerts,
+				types.AuthMethodParameter:   "userpass",
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "invalid token",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: kvCerts,
+				types.TokenParameter:        "invalid",
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "kv with token auth",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: kvCerts,
+				types.TokenParameter:        testToken,
+			},
+			expectedCerts:   1,
+			expectedVersion: "3",
+		},
+		{
+			desc: "pinned kv version",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: kvCerts + "    version: 1\n",
+				types.TokenParameter:        testToken,
+			},
+			expectedCerts:   1,
+			expectedVersion: "1",
+		},
+		{
+			desc: "pki with approle auth",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: pkiCerts,
+				types.AuthMethodParameter:   types.AuthMethodAppRole,
+				types.RoleIDParameter:       roleID,
+				types.SecretIDParameter:     secretID,
+			},
+			expectedCerts: 2,
+		},
+		{
+			desc: "invalid approle secret",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: pkiCerts,
+				types.AuthMethodParameter:   types.AuthMethodAppRole,
+				types.RoleIDParameter:       roleID,
+				types.SecretIDParameter:     "invalid",
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "approle secret from file",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: pkiCerts,
+				types.AuthMethodParameter:   types.AuthMethodAppRole,
+				types.RoleIDParameter:       roleID,
+				types.SecretIDPathParameter: secretIDPath,
+			},
+			expectedCerts: 2,
+		},
+		{
+			desc: "approle secret from environment",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: pkiCerts,
+				types.AuthMethodParameter:   types.AuthMethodAppRole,
+				types.RoleIDParameter:       roleID,
+			},
+			env:           map[string]string{vaultSecretIDEnvVar: secretID},
+			expectedCerts: 2,
+		},
+		{
+			desc: "approle secret file missing",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: pkiCerts,
+				types.AuthMethodParameter:   types.AuthMethodAppRole,
+				types.RoleIDParameter:       roleID,
+				types.SecretIDPathParameter: filepath.Join(t.TempDir(), "missing"),
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "approle secret not set",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: pkiCerts,
+				types.AuthMethodParameter:   types.AuthMethodAppRole,
+				types.RoleIDParameter:       roleID,
+			},
+			env:         map[string]string{vaultSecretIDEnvVar: ""},
+			expectedErr: true,
+		},
+		{
+			desc: "kv with kubernetes auth",
+			parameters: map[string]string{
+				types.VaultAddressParameter:            server.URL,
+				types.CertificatesParameter:            kvCerts,
+				types.AuthMethodParameter:              types.AuthMethodKubernetes,
+				types.RoleParameter:                    k8sRole,
+				types.ServiceAccountTokenPathParameter: tokenPath,
+			},
+			expectedCerts:   1,
+			expectedVersion: "3",
+		},
+		{
+			desc: "secret not found",
+			parameters: map[string]string{
+				types.VaultAddressParameter: server.URL,
+				types.CertificatesParameter: "array:\n  - |\n    certificateName: missing\n    engine: kv\n    mountPath: secret\n    path: ratify/missing\n",
+				types.TokenParameter:        testToken,
+			},
+			expectedErr: true,
+		},
+	}
+
+	provider := Create()
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			certs, status, err := provider.GetCertificates(context.TODO(), tc.parameters)
+
+			assert.Equal(t, tc.expectedErr, err != nil)
+			assert.Equal(t, tc.expectedCerts, len(certs))
+			if tc.expectedVersion != "" {
+				certsStatus := status[types.CertificatesStatus].([]map[string]string)
+				assert.Equal(t, tc.expectedVersion, certsStatus[0][types.CertificateVersion])
+			}
+		})
+	}
+}
+
+func TestGetVaultRequestObj(t *testing.T) {
+	cases := []struct {
+		desc        string
+		certs       string
+		expectedErr bool
+		expected    types.VaultCertificate
+	}{
+		{
+			desc:     "kv defaults",
+			certs:    "array:\n  - |\n    certificateName: ca \n    engine: KV\n    mountPath: /secret/\n    path: ratify/ca\n",
+			expected: types.VaultCertificate{CertificateName: "ca", Engine: types.EngineKV, MountPath: "secret", Path: "ratify/ca", Key: defaultKVKey},
+		},
+		{
+			desc:     "pki defaults",
+			certs:    pkiCerts,
+			expected: types.VaultCertificate{CertificateName: "pki-ca", Engine: types.EnginePKI, MountPath: "pki", Serial: defaultPKISerial},
+		},
+		{
+			desc:        "kv path missing",
+			certs:       "array:\n  - |\n    certificateName: ca\n    engine: kv\n    mountPath: secret\n",
+			expectedErr: true,
+		},
+		{
+			desc:        "invalid kv version",
+			certs:       kvCerts + "    version: latest\n",
+			expectedErr: true,
+		},
+		{
+			desc:        "unsupported engine",
+			certs:       "array:\n  - |\n    certificateName: ca\n    engine: transit\n    mountPath: transit\n",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := getVaultRequestObj(map[string]string{types.CertificatesParameter: tc.certs})
+
+			assert.Equal(t, tc.expectedErr, err != nil)
+			if !tc.expectedErr {
+				assert.Equal(t, []types.VaultCertificate{tc.expected}, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// VaultAddressParameter is the name of the Vault server address parameter
+	VaultAddressParameter = "vaultAddress"
+	// NamespaceParameter is the name of the Vault Enterprise namespace parameter
+	NamespaceParameter = "namespace"
+	// CACertPathParameter is the path of the CA bundle used to verify the Vault server certificate
+	CACertPathParameter = "caCertPath"
+	// AuthMethodParameter is the name of the auth method parameter, one of token, approle or kubernetes
+	AuthMethodParameter = "authMethod"
+	// AuthMountPathParameter is the mount path of the auth method, defaults to the auth method name
+	AuthMountPathParameter = "authMountPath"
+	// TokenParameter is the Vault token used by the token auth method, defaults to the VAULT_TOKEN environment variable
+	TokenParameter = "token"
+	// RoleIDParameter is the AppRole role ID
+	RoleIDParameter = "roleID"
+	// SecretIDParameter is the AppRole secret ID
+	SecretIDParameter = "secretID"
+	// SecretIDPathParameter is the path of a file containing the AppRole secret ID
+	SecretIDPathParameter = "secretIDPath"
+	// RoleParameter is the Vault role used by the kubernetes auth method
+	RoleParameter = "role"
+	// ServiceAccountTokenPathParameter is the path of the service account token used by the kubernetes auth method
+	ServiceAccountTokenPathParameter = "serviceAccountTokenPath"
+	// CertificatesParameter is the name of the objects parameter
+	CertificatesParameter = "certificates"
+
+	// AuthMethodToken authenticates with a static Vault token
+	AuthMethodToken = "token"
+	// AuthMethodAppRole authenticates with an AppRole role ID and secret ID
+	AuthMethodAppRole = "approle"
+	// AuthMethodKubernetes authenticates with the pod service account token
+	AuthMethodKubernetes = "kubernetes"
+
+	// EngineKV reads certificates from a KV version 2 secrets engine
+	EngineKV = "kv"
+	// EnginePKI reads certificates from a PKI secrets engine
+	EnginePKI = "pki"
+
+	// key of the certificate status property
+	CertificatesStatus = "Certificates"
+	// Static string for certificate name for the certificate status property
+	CertificateName = "CertificateName"
+	// Certificate version string for the certificate status property
+	CertificateVersion = "Version"
+	// Last refreshed string for the certificate status property
+	CertificateLastRefreshed = "LastRefreshed"
+)
+
+// VaultCertificate holds the location of a certificate (bundle) in Vault
+type VaultCertificate struct {
+	// the name of the certificate used in the status
+	CertificateName string `json:"certificateName" yaml:"certificateName"`
+	// the secrets engine type, kv or pki
+	Engine string `json:"engine" yaml:"engine"`
+	// the mount path of the secrets engine
+	MountPath string `json:"mountPath" yaml:"mountPath"`
+	// the secret path within a kv engine
+	Path string `json:"path" yaml:"path"`
+	// the key of the PEM value within a kv secret, defaults to "certificate"
+	Key string `json:"key" yaml:"key"`
+	// the version of a kv secret, latest if empty
+	Version string `json:"version" yaml:"version"`
+	// the certificate serial within a pki engine, defaults to "ca_chain"
+	Serial string `json:"serial" yaml:"serial"`
+}
+
+// StringArray holds a list of strings
+type StringArray struct {
+	Array []string `json:"array" yaml:"array"`
+}
+
+// GetParameter returns the parameter value with leading and trailing spaces removed
+func GetParameter(parameters map[string]string, name string) string {
+	return strings.TrimSpace(parameters[name])
+}
+
+// GetCertificatesArray returns the vault objects array
+func GetCertificatesArray(objects string) (StringArray, error) {
+	var a StringArray
+	err := yaml.Unmarshal([]byte(objects), &a)
+
+	return a, err
+}
@@ -0,0 +1,225 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance drives verifier and store plugin binaries through the plugin protocol and reports where they
+// deviate from the behavior Ratify expects, i.e. the behavior of plugins built with the skeletons. Plugin authors
+// run the checks with the ratify plugin test command or from their own tests with AssertVerifier and AssertStore.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
+)
+
+const (
+	// DefaultVersion is the spec version of the commands sent to the plugin if not configured
+	DefaultVersion = "1.0.0"
+	// DefaultTimeout bounds every command sent to the plugin if not configured
+	DefaultTimeout = 30 * time.Second
+
+	// unknownCommand is sent to check that plugins reject commands they do not implement
+	unknownCommand = "CONFORMANCE"
+	// unsupportedVersion is sent to check that plugins reject versions they do not implement
+	unsupportedVersion = "0.0.0-conformance"
+
+	// the error codes shared by the verifier and store plugin protocols
+	errUnknown                     uint = 0
+	errConfigParsingFailure        uint = 1
+	errInvalidConfig               uint = 2
+	errUnknownCommand              uint = 3
+	errMissingEnvironmentVariables uint = 4
+	errVersionNotSupported         uint = 6
+)
+
+// Result is the outcome of a conformance check
+type Result struct {
+	Check string
+	// Err describes how the plugin deviates from the protocol, nil if it conforms
+	Err error
+	// Skipped is the reason the check did not run, empty if it ran
+	Skipped string
+}
+
+// Report lists the results of the conformance checks run against a plugin
+type Report struct {
+	PluginPath string
+	Results    []Result
+}
+
+// Failed returns true if the plugin failed any check
+func (r *Report) Failed() bool {
+	return len(r.Failures()) > 0
+}
+
+// Failures returns the results of the checks the plugin failed
+func (r *Report) Failures() []Result {
+	var failures []Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Write writes a line per check and a summary to w
+func (r *Report) Write(w io.Writer) error {
+	passed, skipped := 0, 0
+	for _, result := range r.Results {
+		var line string
+		switch {
+		case result.Err != nil:
+			line = fmt.Sprintf("FAIL  %s: %v\n", result.Check, result.Err)
+		case result.Skipped != "":
+			skipped++
+			line = fmt.Sprintf("SKIP  %s: %s\n", result.Check, result.Skipped)
+		default:
+			passed++
+			line = fmt.Sprintf("PASS  %s\n", result.Check)
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "plugin %s: %d passed, %d failed, %d skipped\n", r.PluginPath, passed, len(r.Failures()), skipped)
+	return err
+}
+
+// T is the part of testing.T the checks report failures to
+type T interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Assert reports every failed check to t
+func (r *Report) Assert(t T) {
+	t.Helper()
+	for _, failure := range r.Failures() {
+		t.Errorf("plugin %s failed conformance check %s: %v", r.PluginPath, failure.Check, failure.Err)
+	}
+}
+
+// runner runs the checks against a plugin binary and records their results
+type runner struct {
+	pluginPath string
+	executor   pluginCommon.Executor
+	timeout    time.Duration
+	report     *Report
+}
+
+func newRunner(pluginPath string) *runner {
+	return &runner{
+		pluginPath: pluginPath,
+		executor:   &pluginCommon.DefaultExecutor{},
+		timeout:    DefaultTimeout,
+		report:     &Report{PluginPath: pluginPath},
+	}
+}
+
+func (r *runner) check(ctx context.Context, name string, fn func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	r.report.Results = append(r.report.Results, Result{Check: name, Err: fn(ctx)})
+}
+
+func (r *runner) skip(name, reason string) {
+	r.report.Results = append(r.report.Results, Result{Check: name, Skipped: reason})
+}
+
+func (r *runner) execute(ctx context.Context, stdin []byte, environ []string) ([]byte, error) {
+	return r.executor.ExecutePlugin(ctx, r.pluginPath, nil, stdin, environ)
+}
+
+// checkVersion runs the VERSION command and returns the version the remaining commands are sent in, which is the
+// version the host negotiates with the plugin
+func (r *runner) checkVersion(ctx context.Context, environ []string, requested string) string {
+	version := requested
+	r.check(ctx, "version", func(ctx context.Context) error {
+		stdout, err := r.execute(ctx, nil, environ)
+		if err != nil {
+			return fmt.Errorf("the %s command failed: %w", pluginCommon.VersionCommand, err)
+		}
+		info := pluginCommon.PluginInfo{}
+		if err := json.Unmarshal(stdout, &info); err != nil {
+			return fmt.Errorf("invalid output of the %s command %q: %w", pluginCommon.VersionCommand, truncate(stdout), err)
+		}
+		if info.Name == "" {
+			return errors.New("the plugin info has no name")
+		}
+		negotiated, err := pluginCommon.HighestCompatibleVersion(requested, info.SupportedVersions)
+		if err != nil {
+			return err
+		}
+		version = negotiated
+		return nil
+	})
+	return version
+}
+
+// expectError returns an error unless err is a plugin error with code
+func expectError(err error, code uint) error {
+	if err == nil {
+		return fmt.Errorf("expected error code %d, the plugin succeeded", code)
+	}
+	var pluginErr *pluginCommon.Error
+	if !errors.As(err, &pluginErr) {
+		return fmt.Errorf("expected error code %d: %w", code, err)
+	}
+	if pluginErr.Code != code {
+		return fmt.Errorf("expected error code %d, got %d: %v", code, pluginErr.Code, pluginErr)
+	}
+	return nil
+}
+
+// expectResult returns nil if err is nil or a structured error of the plugin, plugins may fail a command on content
+// they cannot handle but must report it as an error with a code
+func expectResult(err error) error {
+	if err == nil {
+		return nil
+	}
+	var pluginErr *pluginCommon.Error
+	if errors.As(err, &pluginErr) && pluginErr.Code != errUnknown {
+		return nil
+	}
+	return fmt.Errorf("expected a result or an error with a code: %w", err)
+}
+
+// environWithout returns the environment of the current process without the variables prefixed with prefix
+func environWithout(prefix string) []string {
+	environ := []string{}
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, prefix) {
+			environ = append(environ, env)
+		}
+	}
+	return environ
+}
+
+// truncate shortens output quoted in errors
+func truncate(output []byte) string {
+	const maxQuotedOutput = 256
+	if len(output) > maxQuotedOutput {
+		return string(output[:maxQuotedOutput]) + "..."
+	}
+	return string(output)
+}
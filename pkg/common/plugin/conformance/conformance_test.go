@@ -0,0 +1,203 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	ss "github.com/deislabs/ratify/pkg/referrerstore/plugin/skel"
+	"github.com/deislabs/ratify/pkg/verifier"
+	vs "github.com/deislabs/ratify/pkg/verifier/plugin/skel"
+	"github.com/opencontainers/go-digest"
+)
+
+// testPluginEnvKey selects the plugin the test binary runs as when it is executed by the checks
+const testPluginEnvKey = "RATIFY_CONFORMANCE_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(testPluginEnvKey) {
+	case "verifier":
+		vs.PluginMain("conformance-verifier", "1.0.0", verifyTestReference, []string{"1.0.0"})
+		os.Exit(0)
+	case "store":
+		runTestStorePlugin()
+		os.Exit(0)
+	case "broken":
+		// a plugin ignoring the protocol
+		_, _ = os.Stdout.WriteString("not a result")
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// verifyTestReference reads the blobs of the reference and checks their digests
+func verifyTestReference(args *vs.CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, store referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+	manifest, err := store.GetReferenceManifest(context.Background(), subjectReference, referenceDescriptor)
+	if err != nil {
+		return nil, err
+	}
+	for _, blob := range manifest.Blobs {
+		content, err := store.GetBlobContent(context.Background(), subjectReference, blob.Digest)
+		if err != nil {
+			return nil, err
+		}
+		if digest.FromBytes(content) != blob.Digest {
+			return &verifier.VerifierResult{Name: "conformance-verifier", Message: fmt.Sprintf("blob %s does not match its digest", blob.Digest)}, nil
+		}
+	}
+	return &verifier.VerifierResult{Name: "conformance-verifier", IsSuccess: true}, nil
+}
+
+// runTestStorePlugin serves the default content of the verifier checks
+func runTestStorePlugin() {
+	store, _ := NewVerifierContent(DefaultArtifactType)
+	ss.PluginMain("conformance-store", "1.0.0",
+		func(args *ss.CmdArgs, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (*referrerstore.ListReferrersResult, error) {
+			result, err := store.ListReferrers(context.Background(), subjectReference, artifactTypes, nextToken, subjectDesc)
+			return &result, err
+		},
+		func(args *ss.CmdArgs, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+			return store.GetBlobContent(context.Background(), subjectReference, digest)
+		},
+		func(args *ss.CmdArgs, subjectReference common.Reference, digest digest.Digest) (ocispecs.ReferenceManifest, error) {
+			referenceDesc := ocispecs.ReferenceDescriptor{}
+			referenceDesc.Digest = digest
+			return store.GetReferenceManifest(context.Background(), subjectReference, referenceDesc)
+		},
+		func(args *ss.CmdArgs, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+			return store.GetSubjectDescriptor(context.Background(), subjectReference)
+		},
+		[]string{"1.0.0"})
+}
+
+// testPluginPath returns the path of the test binary, which runs as the plugin selected by testPluginEnvKey
+func testPluginPath(t *testing.T, plugin string) string {
+	t.Setenv(testPluginEnvKey, plugin)
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatalf("failed to find the test binary: %v", err)
+	}
+	return path
+}
+
+func TestCheckVerifier_SkelPlugin_Conforms(t *testing.T) {
+	report, err := CheckVerifier(context.Background(), VerifierOptions{PluginPath: testPluginPath(t, "verifier")})
+	if err != nil {
+		t.Fatalf("failed to run checks: %v", err)
+	}
+	for _, failure := range report.Failures() {
+		t.Errorf("check %s failed: %v", failure.Check, failure.Err)
+	}
+	// version, the five error checks and the verification of the two referrers
+	if len(report.Results) != 8 {
+		t.Fatalf("expected 8 results, got %d", len(report.Results))
+	}
+}
+
+func TestCheckVerifier_StoreWithoutSubject_ReturnsError(t *testing.T) {
+	store, _ := NewVerifierContent(DefaultArtifactType)
+	if _, err := CheckVerifier(context.Background(), VerifierOptions{PluginPath: testPluginPath(t, "verifier"), Store: store}); err == nil {
+		t.Fatalf("expected an error without a subject")
+	}
+}
+
+func TestCheckVerifier_BrokenPlugin_FailsAllChecks(t *testing.T) {
+	report, err := CheckVerifier(context.Background(), VerifierOptions{PluginPath: testPluginPath(t, "broken")})
+	if err != nil {
+		t.Fatalf("failed to run checks: %v", err)
+	}
+	if len(report.Failures()) != len(report.Results) {
+		t.Fatalf("expected all %d checks to fail, %d failed", len(report.Results), len(report.Failures()))
+	}
+}
+
+func TestCheckStore_SkelPlugin_Conforms(t *testing.T) {
+	_, subject := NewVerifierContent(DefaultArtifactType)
+	report, err := CheckStore(context.Background(), StoreOptions{PluginPath: testPluginPath(t, "store"), Subject: subject})
+	if err != nil {
+		t.Fatalf("failed to run checks: %v", err)
+	}
+	for _, result := range report.Results {
+		if result.Err != nil || result.Skipped != "" {
+			t.Errorf("check %s did not pass: %v %s", result.Check, result.Err, result.Skipped)
+		}
+	}
+	// version, the five error checks, the subject, the referrers and the manifests and blobs of the two referrers
+	if len(report.Results) != 12 {
+		t.Fatalf("expected 12 results, got %d", len(report.Results))
+	}
+}
+
+func TestCheckStore_NoSubject_SkipsContentChecks(t *testing.T) {
+	report, err := CheckStore(context.Background(), StoreOptions{PluginPath: testPluginPath(t, "store")})
+	if err != nil {
+		t.Fatalf("failed to run checks: %v", err)
+	}
+	if report.Failed() {
+		t.Fatalf("expected no failures, got %v", report.Failures())
+	}
+	skipped := 0
+	for _, result := range report.Results {
+		if result.Skipped != "" {
+			skipped++
+		}
+	}
+	if skipped != 4 {
+		t.Fatalf("expected 4 skipped checks, got %d", skipped)
+	}
+}
+
+func TestCheckStore_BrokenPlugin_FailsAllChecks(t *testing.T) {
+	_, subject := NewVerifierContent(DefaultArtifactType)
+	report, err := CheckStore(context.Background(), StoreOptions{PluginPath: testPluginPath(t, "broken"), Subject: subject})
+	if err != nil {
+		t.Fatalf("failed to run checks: %v", err)
+	}
+	for _, result := range report.Results {
+		if result.Err == nil && result.Skipped == "" {
+			t.Errorf("expected check %s to fail", result.Check)
+		}
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	report := &Report{PluginPath: "test-plugin", Results: []Result{
+		{Check: "version"},
+		{Check: "unknown command", Err: fmt.Errorf("expected error code 3")},
+		{Check: "get blobs", Skipped: "no subject given"},
+	}}
+	out := &bytes.Buffer{}
+	if err := report.Write(out); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+	expected := []string{
+		"PASS  version",
+		"FAIL  unknown command: expected error code 3",
+		"SKIP  get blobs: no subject given",
+		"plugin test-plugin: 1 passed, 1 failed, 1 skipped",
+	}
+	if actual := strings.Split(strings.TrimSpace(out.String()), "\n"); strings.Join(actual, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected report %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore/config"
+	sp "github.com/deislabs/ratify/pkg/referrerstore/plugin"
+	"github.com/deislabs/ratify/pkg/referrerstore/types"
+	"github.com/deislabs/ratify/pkg/utils"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// maxListPages bounds the pages of referrers listed, a plugin returning more likely never ends its listing
+const maxListPages = 100
+
+// StoreOptions configure the conformance checks of a store plugin
+type StoreOptions struct {
+	// PluginPath is the path of the plugin binary
+	PluginPath string
+	// Version is the spec version of the commands, defaults to DefaultVersion
+	Version string
+	// Config is the store config sent to the plugin, its name defaults to the name of the binary
+	Config config.StorePluginConfig
+	// Subject is the reference of a subject in the store, the commands reading content are skipped without a subject
+	Subject string
+}
+
+// CheckStore runs the conformance checks against the store plugin configured by opts. Every referrer of the subject
+// is listed, and its manifest and blobs are read and checked against their digests. An error is only returned if
+// the checks cannot be run, failed checks are recorded in the report.
+func CheckStore(ctx context.Context, opts StoreOptions) (*Report, error) {
+	if opts.PluginPath == "" {
+		return nil, errors.New("the path of the plugin is required")
+	}
+	if opts.Version == "" {
+		opts.Version = DefaultVersion
+	}
+	storeConfig := config.StorePluginConfig{types.Name: filepath.Base(opts.PluginPath)}
+	for key, value := range opts.Config {
+		storeConfig[key] = value
+	}
+	storeConfigBytes, err := json.Marshal(storeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store config: %w", err)
+	}
+	subject := opts.Subject
+	if subject == "" {
+		// the error checks only need a subject the plugin can parse
+		subject = defaultSubjectRepository + ":v1"
+	}
+	subjectReference, err := utils.ParseSubjectReference(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newRunner(opts.PluginPath)
+	args := func(command, version string, pluginArgs ...[2]string) []string {
+		storeArgs := sp.ReferrerStorePluginArgs{
+			Command:          command,
+			Version:          version,
+			SubjectReference: subject,
+			PluginArgs:       pluginArgs,
+		}
+		return storeArgs.AsEnviron()
+	}
+
+	version := r.checkVersion(ctx, args(pluginCommon.VersionCommand, opts.Version), opts.Version)
+
+	r.check(ctx, "missing environment variables", func(ctx context.Context) error {
+		_, err := r.execute(ctx, storeConfigBytes, environWithout("RATIFY_STORE_"))
+		return expectError(err, errMissingEnvironmentVariables)
+	})
+	r.check(ctx, "unknown command", func(ctx context.Context) error {
+		_, err := r.execute(ctx, storeConfigBytes, args(unknownCommand, version, [2]string{"nextToken", ""}))
+		return expectError(err, errUnknownCommand)
+	})
+	r.check(ctx, "unsupported version", func(ctx context.Context) error {
+		_, err := r.execute(ctx, storeConfigBytes, args(sp.GetSubjectDescriptor, unsupportedVersion))
+		return expectError(err, errVersionNotSupported)
+	})
+	r.check(ctx, "malformed config", func(ctx context.Context) error {
+		_, err := r.execute(ctx, []byte("{"), args(sp.GetSubjectDescriptor, version))
+		return expectError(err, errConfigParsingFailure)
+	})
+	r.check(ctx, "missing store name", func(ctx context.Context) error {
+		_, err := r.execute(ctx, []byte("{}"), args(sp.GetSubjectDescriptor, version))
+		return expectError(err, errInvalidConfig)
+	})
+
+	if opts.Subject == "" {
+		for _, check := range []string{"get subject descriptor", "list referrers", "get reference manifests", "get blobs"} {
+			r.skip(check, "no subject given")
+		}
+		return r.report, nil
+	}
+
+	var subjectDesc *ocispecs.SubjectDescriptor
+	r.check(ctx, "get subject descriptor", func(ctx context.Context) error {
+		stdout, err := r.execute(ctx, storeConfigBytes, args(sp.GetSubjectDescriptor, version))
+		if err != nil {
+			return err
+		}
+		desc, err := types.GetSubjectDescriptorResult(stdout)
+		if err != nil {
+			return fmt.Errorf("invalid subject descriptor %q: %w", truncate(stdout), err)
+		}
+		if err := desc.Digest.Validate(); err != nil {
+			return fmt.Errorf("invalid digest of the subject descriptor: %w", err)
+		}
+		if subjectReference.Digest != "" && desc.Digest != subjectReference.Digest {
+			return fmt.Errorf("expected subject digest %s, got %s", subjectReference.Digest, desc.Digest)
+		}
+		subjectDesc = desc
+		return nil
+	})
+
+	var referrers []ocispecs.ReferenceDescriptor
+	r.check(ctx, "list referrers", func(ctx context.Context) error {
+		nextToken := ""
+		for page := 0; page < maxListPages; page++ {
+			stdout, err := r.execute(ctx, storeConfigBytes, args(sp.ListReferrersCommand, version, [2]string{"nextToken", nextToken}, [2]string{"artifactTypes", ""}))
+			if err != nil {
+				return err
+			}
+			result, err := types.GetListReferrersResult(stdout)
+			if err != nil {
+				return fmt.Errorf("invalid referrers %q: %w", truncate(stdout), err)
+			}
+			for _, referrer := range result.Referrers {
+				if err := referrer.Digest.Validate(); err != nil {
+					return fmt.Errorf("invalid digest of referrer %v: %w", referrer, err)
+				}
+			}
+			referrers = append(referrers, result.Referrers...)
+			if result.NextToken == "" {
+				return nil
+			}
+			if result.NextToken == nextToken {
+				return fmt.Errorf("the next token %s repeats the token of the listed page", nextToken)
+			}
+			nextToken = result.NextToken
+		}
+		return fmt.Errorf("the listing does not end within %d pages", maxListPages)
+	})
+	if subjectDesc == nil || len(referrers) == 0 {
+		r.skip("get reference manifests", "no referrers listed")
+		r.skip("get blobs", "no referrers listed")
+		return r.report, nil
+	}
+
+	var blobs []oci.Descriptor
+	for _, referrer := range referrers {
+		referrer := referrer
+		r.check(ctx, fmt.Sprintf("get reference manifest %s", referrer.Digest), func(ctx context.Context) error {
+			stdout, err := r.execute(ctx, storeConfigBytes, args(sp.GetRefManifestCommand, version, [2]string{"digest", referrer.Digest.String()}))
+			if err != nil {
+				return err
+			}
+			manifest, err := types.GetReferenceManifestResult(stdout)
+			if err != nil {
+				return fmt.Errorf("invalid reference manifest %q: %w", truncate(stdout), err)
+			}
+			blobs = append(blobs, manifest.Blobs...)
+			return nil
+		})
+	}
+	if len(blobs) == 0 {
+		r.skip("get blobs", "no blobs in the reference manifests")
+	}
+	for _, blob := range blobs {
+		blob := blob
+		r.check(ctx, fmt.Sprintf("get blob %s", blob.Digest), func(ctx context.Context) error {
+			stdout, err := r.execute(ctx, storeConfigBytes, args(sp.GetBlobContentCommand, version, [2]string{"digest", blob.Digest.String()}))
+			if err != nil {
+				return err
+			}
+			if blob.Size > 0 && int64(len(stdout)) != blob.Size {
+				return fmt.Errorf("expected %d bytes of blob %s, got %d", blob.Size, blob.Digest, len(stdout))
+			}
+			if err := blob.Digest.Validate(); err != nil {
+				return fmt.Errorf("invalid digest of blob: %w", err)
+			}
+			if actual := blob.Digest.Algorithm().FromBytes(stdout); actual != blob.Digest {
+				return fmt.Errorf("content of blob %s has digest %s", blob.Digest, actual)
+			}
+			return nil
+		})
+	}
+	return r.report, nil
+}
+
+// AssertStore runs the conformance checks against the store plugin configured by opts and reports failures to t
+func AssertStore(t T, opts StoreOptions) {
+	t.Helper()
+	report, err := CheckStore(context.Background(), opts)
+	if err != nil {
+		t.Errorf("failed to run the conformance checks of plugin %s: %v", opts.PluginPath, err)
+		return
+	}
+	report.Assert(t)
+}
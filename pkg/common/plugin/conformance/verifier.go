@@ -0,0 +1,229 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore/mocks"
+	"github.com/deislabs/ratify/pkg/referrerstore/orchestrator"
+	"github.com/deislabs/ratify/pkg/utils"
+	"github.com/deislabs/ratify/pkg/verifier/config"
+	vp "github.com/deislabs/ratify/pkg/verifier/plugin"
+	"github.com/deislabs/ratify/pkg/verifier/types"
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// DefaultArtifactType is the artifact type of the referrers of the default content if the verifier config does
+	// not name one
+	DefaultArtifactType = "application/vnd.ratify.conformance.v0"
+
+	// largeBlobSize is the size of the blob of a referrer of the default content, beyond the buffers of pipes and
+	// the default message size of gRPC
+	largeBlobSize = 8 * 1024 * 1024
+
+	defaultSubjectRepository = "localhost:5000/conformance"
+)
+
+// VerifierOptions configure the conformance checks of a verifier plugin
+type VerifierOptions struct {
+	// PluginPath is the path of the plugin binary
+	PluginPath string
+	// Version is the spec version of the commands, defaults to DefaultVersion
+	Version string
+	// Config is the verifier config sent to the plugin, its name defaults to the name of the binary
+	Config config.VerifierConfig
+	// Store serves the content the plugin verifies, defaults to the store returned by NewVerifierContent
+	Store *mocks.MemoryStore
+	// Subject is the reference of the subject in Store whose referrers the plugin verifies, required with Store
+	Subject string
+}
+
+// NewVerifierContent returns a store holding an image with two referrers of artifactType, one of them with a blob
+// larger than the buffers of the transports, along with the subject reference of the image
+func NewVerifierContent(artifactType string) (*mocks.MemoryStore, string) {
+	store := mocks.NewMemoryStore()
+	imageDigest := digest.FromString("ratify conformance image")
+	store.Subjects[imageDigest] = &ocispecs.SubjectDescriptor{
+		Descriptor: oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: imageDigest},
+	}
+
+	for _, content := range [][]byte{
+		[]byte("ratify conformance blob"),
+		bytes.Repeat([]byte("ratify conformance large blob\n"), largeBlobSize/30),
+	} {
+		blobDesc := oci.Descriptor{MediaType: "application/octet-stream", Digest: digest.FromBytes(content), Size: int64(len(content))}
+		store.Blobs[blobDesc.Digest] = content
+
+		manifest := ocispecs.ReferenceManifest{
+			MediaType:    oci.MediaTypeArtifactManifest,
+			ArtifactType: artifactType,
+			Blobs:        []oci.Descriptor{blobDesc},
+			Subject:      &store.Subjects[imageDigest].Descriptor,
+		}
+		// the manifest is marshaled for a digest only, a failure is not possible for the types involved
+		manifestBytes, _ := json.Marshal(manifest)
+		manifestDigest := digest.FromBytes(manifestBytes)
+		store.Manifests[manifestDigest] = manifest
+		store.Referrers[imageDigest] = append(store.Referrers[imageDigest], ocispecs.ReferenceDescriptor{
+			Descriptor:   oci.Descriptor{MediaType: oci.MediaTypeArtifactManifest, Digest: manifestDigest, Size: int64(len(manifestBytes))},
+			ArtifactType: artifactType,
+		})
+	}
+	return store, fmt.Sprintf("%s@%s", defaultSubjectRepository, imageDigest)
+}
+
+// CheckVerifier runs the conformance checks against the verifier plugin configured by opts. An error is only
+// returned if the checks cannot be run, failed checks are recorded in the report.
+func CheckVerifier(ctx context.Context, opts VerifierOptions) (*Report, error) {
+	if opts.PluginPath == "" {
+		return nil, errors.New("the path of the plugin is required")
+	}
+	if opts.Version == "" {
+		opts.Version = DefaultVersion
+	}
+	verifierConfig := config.VerifierConfig{types.Name: filepath.Base(opts.PluginPath)}
+	for key, value := range opts.Config {
+		verifierConfig[key] = value
+	}
+	if opts.Store == nil {
+		opts.Store, opts.Subject = NewVerifierContent(artifactTypeOf(verifierConfig))
+	} else if opts.Subject == "" {
+		return nil, errors.New("the subject is required with a store")
+	}
+	subjectReference, err := utils.ParseSubjectReference(opts.Subject)
+	if err != nil {
+		return nil, err
+	}
+	referrers, err := opts.Store.ListReferrers(ctx, subjectReference, nil, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the referrers of subject %s: %w", opts.Subject, err)
+	}
+
+	// commands are served the content of the store like the host serves the content of its stores
+	contentServer, err := orchestrator.Serve(opts.Store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serve the content of the store: %w", err)
+	}
+	defer contentServer.Stop()
+
+	r := newRunner(opts.PluginPath)
+	args := func(command, version string) []string {
+		pluginArgs := vp.VerifierPluginArgs{
+			Command:          command,
+			Version:          version,
+			SubjectReference: opts.Subject,
+			ContentSocket:    contentServer.SocketPath(),
+		}
+		return pluginArgs.AsEnviron()
+	}
+	input := func(referenceDesc ocispecs.ReferenceDescriptor) []byte {
+		// the input is marshaled from JSON values only
+		inputBytes, _ := json.Marshal(config.PluginInputConfig{
+			Config:       verifierConfig,
+			StoreConfig:  *opts.Store.GetConfig(),
+			ReferencDesc: referenceDesc,
+		})
+		return inputBytes
+	}
+	var referenceDesc ocispecs.ReferenceDescriptor
+	if len(referrers.Referrers) > 0 {
+		referenceDesc = referrers.Referrers[0]
+	}
+
+	version := r.checkVersion(ctx, args(pluginCommon.VersionCommand, opts.Version), opts.Version)
+
+	r.check(ctx, "missing environment variables", func(ctx context.Context) error {
+		_, err := r.execute(ctx, input(referenceDesc), environWithout("RATIFY_VERIFIER_"))
+		return expectError(err, errMissingEnvironmentVariables)
+	})
+	r.check(ctx, "unknown command", func(ctx context.Context) error {
+		_, err := r.execute(ctx, input(referenceDesc), args(unknownCommand, version))
+		return expectError(err, errUnknownCommand)
+	})
+	r.check(ctx, "unsupported version", func(ctx context.Context) error {
+		_, err := r.execute(ctx, input(referenceDesc), args(vp.VerifyCommand, unsupportedVersion))
+		return expectError(err, errVersionNotSupported)
+	})
+	r.check(ctx, "malformed config", func(ctx context.Context) error {
+		_, err := r.execute(ctx, []byte("{"), args(vp.VerifyCommand, version))
+		return expectError(err, errConfigParsingFailure)
+	})
+	r.check(ctx, "missing verifier name", func(ctx context.Context) error {
+		_, err := r.execute(ctx, []byte(`{"config":{}}`), args(vp.VerifyCommand, version))
+		return expectError(err, errInvalidConfig)
+	})
+
+	if len(referrers.Referrers) == 0 {
+		r.skip("verify", fmt.Sprintf("subject %s has no referrers", opts.Subject))
+	}
+	for _, referrer := range referrers.Referrers {
+		referrer := referrer
+		r.check(ctx, fmt.Sprintf("verify %s", describeReferrer(opts.Store, referrer)), func(ctx context.Context) error {
+			stdout, err := r.execute(ctx, input(referrer), args(vp.VerifyCommand, version))
+			if err != nil {
+				return expectResult(err)
+			}
+			if _, err := types.GetVerifierResult(stdout); err != nil {
+				return fmt.Errorf("invalid verifier result %q: %w", truncate(stdout), err)
+			}
+			return nil
+		})
+	}
+	return r.report, nil
+}
+
+// AssertVerifier runs the conformance checks against the verifier plugin configured by opts and reports failures to t
+func AssertVerifier(t T, opts VerifierOptions) {
+	t.Helper()
+	report, err := CheckVerifier(context.Background(), opts)
+	if err != nil {
+		t.Errorf("failed to run the conformance checks of plugin %s: %v", opts.PluginPath, err)
+		return
+	}
+	report.Assert(t)
+}
+
+// artifactTypeOf returns the first artifact type the verifier is configured with, DefaultArtifactType if none
+func artifactTypeOf(verifierConfig config.VerifierConfig) string {
+	if artifactTypes, ok := verifierConfig[types.ArtifactTypes]; ok {
+		for _, artifactType := range strings.Split(fmt.Sprintf("%s", artifactTypes), ",") {
+			if artifactType != "" && artifactType != "*" {
+				return artifactType
+			}
+		}
+	}
+	return DefaultArtifactType
+}
+
+// describeReferrer names a referrer in the check names by its digest and the size of its blobs
+func describeReferrer(store *mocks.MemoryStore, referrer ocispecs.ReferenceDescriptor) string {
+	size := int64(0)
+	for _, blob := range store.Manifests[referrer.Digest].Blobs {
+		size += blob.Size
+	}
+	return fmt.Sprintf("%s with %d bytes of blobs", referrer.Digest, size)
+}
@@ -22,20 +22,59 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/deislabs/ratify/pkg/common/oras/authprovider"
 	commonutils "github.com/deislabs/ratify/pkg/common/utils"
 	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/utils"
+	"github.com/deislabs/ratify/pkg/verifier"
+	"github.com/opencontainers/go-digest"
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
+	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
 )
 
+// maxPluginManifestSize bounds the manifest of a plugin artifact read into memory
+const maxPluginManifestSize = 4 * 1024 * 1024
+
+var (
+	// sourceStoreReleasers release what verifiers hold for the store of a plugin source, like shared content servers
+	sourceStoreReleasers     []func(referrerstore.ReferrerStore)
+	sourceStoreReleasersLock sync.Mutex
+)
+
+// SubscribeSourceStoreRelease registers release to be called with the store of a plugin source once its signatures
+// are verified, the store is not used afterwards.
+func SubscribeSourceStoreRelease(release func(referrerstore.ReferrerStore)) {
+	sourceStoreReleasersLock.Lock()
+	defer sourceStoreReleasersLock.Unlock()
+	sourceStoreReleasers = append(sourceStoreReleasers, release)
+}
+
+func releaseSourceStore(store referrerstore.ReferrerStore) {
+	sourceStoreReleasersLock.Lock()
+	releasers := sourceStoreReleasers
+	sourceStoreReleasersLock.Unlock()
+	for _, release := range releasers {
+		release(store)
+	}
+}
+
 type PluginSource struct {
 	Artifact     string                          `json:"artifact"`
 	AuthProvider authprovider.AuthProviderConfig `json:"authProvider,omitempty"`
+	// Digest pins the manifest of the artifact, the download fails if the artifact resolves to another digest
+	Digest string `json:"digest,omitempty"`
+	// Verifier is the config of the verifier checking the signatures of the artifact before the plugin is installed
+	Verifier map[string]interface{} `json:"verifier,omitempty"`
+	// AllowUnverified installs the plugin although the artifact is neither pinned to a digest nor verified
+	AllowUnverified bool `json:"allowUnverified,omitempty"`
 }
 
 func ParsePluginSource(source interface{}) (PluginSource, error) {
@@ -52,105 +91,220 @@ func ParsePluginSource(source interface{}) (PluginSource, error) {
 		return pluginSource, err
 	}
 
+	if pluginSource.Digest != "" {
+		if _, err := digest.Parse(pluginSource.Digest); err != nil {
+			return pluginSource, fmt.Errorf("invalid digest %s: %w", pluginSource.Digest, err)
+		}
+	}
+
 	return pluginSource, nil
 }
 
-func DownloadPlugin(source PluginSource, targetPath string) error {
+// DownloadPlugin downloads the plugin artifact of source and installs it at targetPath. The manifest must match the
+// pinned digest of the source if set, and have a signature successfully verified by signatureVerifier if not nil. A
+// source that is neither pinned nor verified is rejected unless it allows unverified plugins. The plugin is only installed once it is verified, replacing any previous plugin at targetPath atomically.
+func DownloadPlugin(source PluginSource, targetPath string, signatureVerifier verifier.ReferenceVerifier) error {
 	ctx := context.TODO()
 
-	// initialize a repository
-	repository, err := remote.NewRepository(source.Artifact)
+	if source.Digest == "" && signatureVerifier == nil {
+		if !source.AllowUnverified {
+			return fmt.Errorf("plugin %s is neither pinned to a digest nor verified, set allowUnverified to install it anyway", source.Artifact)
+		}
+		logrus.Warnf("plugin %s is neither pinned to a digest nor verified, its integrity is not checked", source.Artifact)
+	}
+
+	repository, err := newSourceRepository(source)
 	if err != nil {
 		return err
 	}
 
-	repository.Client = &auth.Client{
-		Client: &http.Client{Timeout: 10 * time.Second, Transport: http.DefaultTransport.(*http.Transport).Clone()},
-		Header: http.Header{
-			"User-Agent": {"ratify"},
-		},
-		Cache: auth.NewCache(),
-		Credential: func(ctx context.Context, registry string) (auth.Credential, error) {
-			authProvider, err := authprovider.CreateAuthProviderFromConfig(source.AuthProvider)
-			if err != nil {
-				return auth.EmptyCredential, err
-			}
-
-			authConfig, err := authProvider.Provide(ctx, registry)
-			if err != nil {
-				return auth.EmptyCredential, err
-			}
-
-			if authConfig.Username != "" || authConfig.Password != "" || authConfig.IdentityToken != "" {
-				return auth.Credential{
-					Username:     authConfig.Username,
-					Password:     authConfig.Password,
-					RefreshToken: authConfig.IdentityToken,
-				}, nil
-			}
-			return auth.EmptyCredential, nil
-		},
+	reference := source.Artifact
+	if source.Digest != "" {
+		reference = fmt.Sprintf("%s/%s@%s", repository.Reference.Registry, repository.Reference.Repository, source.Digest)
 	}
 
 	// read the reference manifest
-	referenceManifestDescriptor, err := repository.Resolve(ctx, source.Artifact)
+	referenceManifestDescriptor, err := repository.Resolve(ctx, reference)
 	if err != nil {
 		return err
 	}
 	logrus.Debugf("Resolved plugin manifest: %v", referenceManifestDescriptor)
+	if source.Digest != "" && referenceManifestDescriptor.Digest.String() != source.Digest {
+		return fmt.Errorf("plugin %s resolved to digest %s instead of the pinned digest %s", source.Artifact, referenceManifestDescriptor.Digest, source.Digest)
+	}
+	if referenceManifestDescriptor.Size > maxPluginManifestSize {
+		return fmt.Errorf("plugin manifest of %d bytes is larger than %d bytes", referenceManifestDescriptor.Size, maxPluginManifestSize)
+	}
 
 	manifestReader, err := repository.Fetch(ctx, referenceManifestDescriptor)
 	if err != nil {
 		return err
 	}
+	defer manifestReader.Close()
+
+	// the content is verified against the digest of the descriptor
+	manifestBytes, err := content.ReadAll(manifestReader, referenceManifestDescriptor)
+	if err != nil {
+		return fmt.Errorf("failed to read plugin manifest: %w", err)
+	}
 
-	manifestBytes, err := io.ReadAll(manifestReader)
+	referenceManifest, err := parseReferenceManifest(referenceManifestDescriptor.MediaType, manifestBytes)
 	if err != nil {
 		return err
 	}
+	if len(referenceManifest.Blobs) == 0 {
+		return fmt.Errorf("plugin manifest %s has no blobs", referenceManifestDescriptor.Digest)
+	}
 
-	referenceManifest := ocispecs.ReferenceManifest{}
-	// marshal manifest bytes into reference manifest descriptor
-	if referenceManifestDescriptor.MediaType == oci.MediaTypeImageManifest {
-		var imageManifest oci.Manifest
-		if err := json.Unmarshal(manifestBytes, &imageManifest); err != nil {
+	if signatureVerifier != nil {
+		store := &sourceStore{repository: repository, source: source}
+		if err := verifyPluginSignature(ctx, store, signatureVerifier, referenceManifestDescriptor); err != nil {
 			return err
 		}
-		referenceManifest = commonutils.OciManifestToReferenceManifest(imageManifest)
-	} else if referenceManifestDescriptor.MediaType == oci.MediaTypeArtifactManifest {
-		if err := json.Unmarshal(manifestBytes, &referenceManifest); err != nil {
-			return err
-		}
-	} else {
-		return fmt.Errorf("unsupported manifest media type: %s", referenceManifestDescriptor.MediaType)
 	}
 
-	// download the first blob to the target path
-	blobReference := fmt.Sprintf("%s@%s", source, referenceManifest.Blobs[0].Digest)
-	logrus.Debugf("Downloading blob %s", blobReference)
-	_, blobReader, err := repository.Blobs().FetchReference(ctx, blobReference)
+	// download the first blob next to the target path, so that it can be renamed into place once it is verified
+	blobDescriptor := referenceManifest.Blobs[0]
+	logrus.Debugf("Downloading blob %s", blobDescriptor.Digest)
+	blobReader, err := repository.Blobs().Fetch(ctx, blobDescriptor)
 	if err != nil {
 		return err
 	}
+	defer blobReader.Close()
 
-	logrus.Debugf("writing plugin bytes to %s", targetPath)
-	pluginFile, err := os.Create(targetPath)
+	pluginFile, err := os.CreateTemp(filepath.Dir(targetPath), "."+filepath.Base(targetPath)+"-")
 	if err != nil {
 		return err
 	}
+	tempPath := pluginFile.Name()
+	installed := false
+	defer func() {
+		if !installed {
+			_ = os.Remove(tempPath)
+		}
+	}()
 
-	defer pluginFile.Close()
-	_, err = io.Copy(pluginFile, blobReader)
-	if err != nil {
+	logrus.Debugf("writing plugin bytes to %s", tempPath)
+	verifyReader := content.NewVerifyReader(blobReader, blobDescriptor)
+	if _, err := io.Copy(pluginFile, verifyReader); err != nil {
+		pluginFile.Close()
+		return err
+	}
+	if err := verifyReader.Verify(); err != nil {
+		pluginFile.Close()
+		return fmt.Errorf("failed to verify plugin blob %s: %w", blobDescriptor.Digest, err)
+	}
+	if err := pluginFile.Sync(); err != nil {
+		pluginFile.Close()
+		return err
+	}
+	if err := pluginFile.Close(); err != nil {
 		return err
 	}
 
 	// mark the plugin as executable
-	logrus.Debugf("marking %s as executable", targetPath)
-	err = os.Chmod(targetPath, 0700)
+	logrus.Debugf("marking %s as executable", tempPath)
+	if err := os.Chmod(tempPath, 0700); err != nil {
+		return err
+	}
+
+	logrus.Debugf("installing plugin to %s", targetPath)
+	if err := os.Rename(tempPath, targetPath); err != nil {
+		return err
+	}
+	installed = true
+
+	return nil
+}
+
+// verifyPluginSignature succeeds if a signature of the plugin manifest is successfully verified by signatureVerifier
+func verifyPluginSignature(ctx context.Context, store *sourceStore, signatureVerifier verifier.ReferenceVerifier, manifestDescriptor oci.Descriptor) error {
+	defer releaseSourceStore(store)
+	subjectReference, err := utils.ParseSubjectReference(fmt.Sprintf("%s/%s@%s", store.repository.Reference.Registry, store.repository.Reference.Repository, manifestDescriptor.Digest))
 	if err != nil {
 		return err
 	}
+	referrers, err := store.listReferrers(ctx, manifestDescriptor, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list signatures of plugin %s: %w", store.source.Artifact, err)
+	}
 
+	verified := false
+	for _, referrer := range referrers {
+		if !signatureVerifier.CanVerify(ctx, referrer) {
+			continue
+		}
+		result, err := signatureVerifier.Verify(ctx, subjectReference, referrer, store)
+		if err != nil {
+			logrus.Warnf("failed to verify signature %s of plugin %s: %v", referrer.Digest, store.source.Artifact, err)
+			continue
+		}
+		if !result.IsSuccess {
+			logrus.Warnf("signature %s of plugin %s is not valid: %s", referrer.Digest, store.source.Artifact, result.Message)
+			continue
+		}
+		logrus.Infof("verified signature %s of plugin %s with verifier %s", referrer.Digest, store.source.Artifact, signatureVerifier.Name())
+		verified = true
+	}
+	if !verified {
+		return fmt.Errorf("no signature of plugin %s was verified by verifier %s", store.source.Artifact, signatureVerifier.Name())
+	}
 	return nil
 }
+
+func newSourceRepository(source PluginSource) (*remote.Repository, error) {
+	// initialize a repository
+	repository, err := remote.NewRepository(source.Artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	repository.Client = &auth.Client{
+		Client: &http.Client{Timeout: 10 * time.Second, Transport: http.DefaultTransport.(*http.Transport).Clone()},
+		Header: http.Header{
+			"User-Agent": {"ratify"},
+		},
+		Cache: auth.NewCache(),
+		Credential: func(ctx context.Context, registry string) (auth.Credential, error) {
+			authProvider, err := authprovider.CreateAuthProviderFromConfig(source.AuthProvider)
+			if err != nil {
+				return auth.EmptyCredential, err
+			}
+
+			authConfig, err := authProvider.Provide(ctx, registry)
+			if err != nil {
+				return auth.EmptyCredential, err
+			}
+
+			if authConfig.Username != "" || authConfig.Password != "" || authConfig.IdentityToken != "" {
+				return auth.Credential{
+					Username:     authConfig.Username,
+					Password:     authConfig.Password,
+					RefreshToken: authConfig.IdentityToken,
+				}, nil
+			}
+			return auth.EmptyCredential, nil
+		},
+	}
+	return repository, nil
+}
+
+func parseReferenceManifest(mediaType string, manifestBytes []byte) (ocispecs.ReferenceManifest, error) {
+	referenceManifest := ocispecs.ReferenceManifest{}
+	// marshal manifest bytes into reference manifest descriptor
+	switch mediaType {
+	case oci.MediaTypeImageManifest:
+		var imageManifest oci.Manifest
+		if err := json.Unmarshal(manifestBytes, &imageManifest); err != nil {
+			return referenceManifest, err
+		}
+		referenceManifest = commonutils.OciManifestToReferenceManifest(imageManifest)
+	case oci.MediaTypeArtifactManifest:
+		if err := json.Unmarshal(manifestBytes, &referenceManifest); err != nil {
+			return referenceManifest, err
+		}
+	default:
+		return referenceManifest, fmt.Errorf("unsupported manifest media type: %s", mediaType)
+	}
+	return referenceManifest, nil
+}
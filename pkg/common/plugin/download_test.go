@@ -16,10 +16,24 @@ limitations under the License.
 package plugin
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/deislabs/ratify/api/v1beta1"
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/verifier"
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 func TestParsePluginSource_HandlesJSON(t *testing.T) {
@@ -73,3 +87,238 @@ func TestParsePluginSource_HandlesCRD(t *testing.T) {
 		t.Fatalf("unexpected artifact: %s", source.Artifact)
 	}
 }
+
+func TestParsePluginSource_InvalidDigest(t *testing.T) {
+	if _, err := ParsePluginSource(map[string]interface{}{"artifact": "localhost/plugin:v1", "digest": "sha256:invalid"}); err == nil {
+		t.Fatalf("expected error for an invalid digest")
+	}
+}
+
+const testSignatureType = "application/vnd.test.signature"
+
+// testRegistry serves a plugin artifact and its signatures
+type testRegistry struct {
+	plugin     []byte
+	served     []byte
+	manifest   []byte
+	digest     digest.Digest
+	signatures map[digest.Digest][]byte
+	referrers  []oci.Descriptor
+	// cosignSignature is the digest of the signature tagged with the cosign signature tag of the plugin
+	cosignSignature digest.Digest
+}
+
+func newTestRegistry(t *testing.T, plugin string) (*testRegistry, string) {
+	t.Helper()
+	registry := &testRegistry{plugin: []byte(plugin), served: []byte(plugin), signatures: map[digest.Digest][]byte{}}
+	manifest := oci.Manifest{
+		MediaType: oci.MediaTypeImageManifest,
+		Config:    oci.Descriptor{MediaType: "application/vnd.test.config", Digest: digest.FromString("{}"), Size: 2},
+		Layers:    []oci.Descriptor{{MediaType: "application/vnd.test.plugin", Digest: digest.FromBytes(registry.plugin), Size: int64(len(registry.plugin))}},
+	}
+	manifest.SchemaVersion = 2
+	registry.manifest, _ = json.Marshal(manifest)
+	registry.digest = digest.FromBytes(registry.manifest)
+
+	server := httptest.NewTLSServer(registry)
+	t.Cleanup(server.Close)
+	// the download clones the default transport, which must trust the test server
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+	return registry, strings.TrimPrefix(server.URL, "https://") + "/test/plugin"
+}
+
+func (r *testRegistry) sign(content string) {
+	signature := []byte(content)
+	r.signatures[digest.FromBytes(signature)] = signature
+	r.referrers = append(r.referrers, oci.Descriptor{MediaType: oci.MediaTypeImageManifest, ArtifactType: testSignatureType, Digest: digest.FromBytes(signature), Size: int64(len(signature))})
+}
+
+func (r *testRegistry) signCosign(content string) {
+	signature := []byte(content)
+	r.signatures[digest.FromBytes(signature)] = signature
+	r.cosignSignature = digest.FromBytes(signature)
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, "/v2/test/plugin/")
+	switch {
+	case path == "manifests/v1" || path == "manifests/"+r.digest.String():
+		w.Header().Set("Content-Type", oci.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", r.digest.String())
+		w.Header().Set("Content-Length", fmt.Sprint(len(r.manifest)))
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(r.manifest)
+		}
+	case r.cosignSignature != "" && path == "manifests/"+referrerstore.CosignAttachmentTag(r.digest, referrerstore.CosignSignatureTagSuffix):
+		w.Header().Set("Content-Type", oci.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", r.cosignSignature.String())
+		w.Header().Set("Content-Length", fmt.Sprint(len(r.signatures[r.cosignSignature])))
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(r.signatures[r.cosignSignature])
+		}
+	case path == "blobs/"+digest.FromBytes(r.plugin).String():
+		_, _ = w.Write(r.served)
+	case strings.HasPrefix(path, "blobs/") && r.signatures[digest.Digest(strings.TrimPrefix(path, "blobs/"))] != nil:
+		_, _ = w.Write(r.signatures[digest.Digest(strings.TrimPrefix(path, "blobs/"))])
+	case path == "referrers/"+r.digest.String():
+		w.Header().Set("Content-Type", oci.MediaTypeImageIndex)
+		index := oci.Index{MediaType: oci.MediaTypeImageIndex, Manifests: r.referrers}
+		index.SchemaVersion = 2
+		_ = json.NewEncoder(w).Encode(index)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// testSignatureVerifier accepts signatures with the expected content, read from the store
+type testSignatureVerifier struct {
+	expected string
+}
+
+func (v *testSignatureVerifier) Name() string {
+	return "test"
+}
+
+func (v *testSignatureVerifier) CanVerify(ctx context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
+	return referenceDescriptor.ArtifactType == testSignatureType || referenceDescriptor.ArtifactType == referrerstore.CosignSignatureArtifactType
+}
+
+func (v *testSignatureVerifier) Verify(ctx context.Context, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	signature, err := referrerStore.GetBlobContent(ctx, subjectReference, referenceDescriptor.Digest)
+	if err != nil {
+		return verifier.VerifierResult{}, err
+	}
+	return verifier.VerifierResult{IsSuccess: string(signature) == v.expected, Message: string(signature)}, nil
+}
+
+func (v *testSignatureVerifier) GetNestedReferences() []string {
+	return nil
+}
+
+func TestDownloadPlugin(t *testing.T) {
+	testCases := []struct {
+		name            string
+		digest          func(registry *testRegistry) string
+		allowUnverified bool
+		tamper          bool
+		signature       string
+		cosignSignature string
+		verifier        verifier.ReferenceVerifier
+		expectErr       bool
+	}{
+		{name: "not verified", expectErr: true},
+		{name: "unverified allowed", allowUnverified: true},
+		{name: "pinned digest", digest: func(registry *testRegistry) string { return registry.digest.String() }},
+		{name: "pinned digest mismatch", digest: func(registry *testRegistry) string { return digest.FromString("other").String() }, expectErr: true},
+		{name: "tampered blob", allowUnverified: true, tamper: true, expectErr: true},
+		{name: "valid signature", signature: "signed", verifier: &testSignatureVerifier{expected: "signed"}},
+		{name: "valid cosign signature", cosignSignature: "signed", verifier: &testSignatureVerifier{expected: "signed"}},
+		{name: "invalid cosign signature", cosignSignature: "forged", verifier: &testSignatureVerifier{expected: "signed"}, expectErr: true},
+		{name: "invalid signature", signature: "forged", verifier: &testSignatureVerifier{expected: "signed"}, expectErr: true},
+		{name: "missing signature", verifier: &testSignatureVerifier{expected: "signed"}, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registry, repository := newTestRegistry(t, "new plugin")
+			if tc.tamper {
+				registry.served = []byte("bad plugin")
+			}
+			if tc.signature != "" {
+				registry.sign(tc.signature)
+			}
+			if tc.cosignSignature != "" {
+				registry.signCosign(tc.cosignSignature)
+			}
+			source := PluginSource{Artifact: repository + ":v1", AllowUnverified: tc.allowUnverified}
+			if tc.digest != nil {
+				source.Digest = tc.digest(registry)
+			}
+
+			dir := t.TempDir()
+			targetPath := filepath.Join(dir, "plugin")
+			if err := os.WriteFile(targetPath, []byte("old plugin"), 0700); err != nil {
+				t.Fatalf("failed to write plugin: %v", err)
+			}
+
+			err := DownloadPlugin(source, targetPath, tc.verifier)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			expected := "new plugin"
+			if tc.expectErr {
+				expected = "old plugin"
+			}
+			if content, _ := os.ReadFile(targetPath); string(content) != expected {
+				t.Fatalf("expected plugin %q to be installed, got %q", expected, content)
+			}
+			if info, _ := os.Stat(targetPath); info.Mode().Perm() != 0700 {
+				t.Fatalf("expected the plugin to be executable, got %v", info.Mode())
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Fatalf("expected no temporary files, got %v", entries)
+			}
+		})
+	}
+}
+
+func TestDownloadPlugin_ReleasesSourceStore(t *testing.T) {
+	defaultReleasers := sourceStoreReleasers
+	defer func() { sourceStoreReleasers = defaultReleasers }()
+	var released []referrerstore.ReferrerStore
+	SubscribeSourceStoreRelease(func(store referrerstore.ReferrerStore) {
+		released = append(released, store)
+	})
+
+	registry, repository := newTestRegistry(t, "new plugin")
+	registry.sign("signed")
+	source := PluginSource{Artifact: repository + ":v1"}
+	if err := DownloadPlugin(source, filepath.Join(t.TempDir(), "plugin"), &testSignatureVerifier{expected: "signed"}); err != nil {
+		t.Fatalf("failed to download plugin: %v", err)
+	}
+	if len(released) != 1 {
+		t.Fatalf("expected the source store to be released once, got %d releases", len(released))
+	}
+	if _, ok := released[0].(*sourceStore); !ok {
+		t.Fatalf("expected the source store to be released, got %T", released[0])
+	}
+}
+
+func TestSourceStore_GetBlobReader(t *testing.T) {
+	registry, repository := newTestRegistry(t, "new plugin")
+	registry.sign("signed")
+	signatureDigest := digest.FromString("signed")
+	oversizedDigest := digest.FromString("oversized")
+	registry.signatures[oversizedDigest] = make([]byte, maxSignatureBlobSize+1)
+
+	remoteRepository, err := newSourceRepository(PluginSource{Artifact: repository + ":v1"})
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	store := &sourceStore{repository: remoteRepository}
+
+	reader, err := store.GetBlobReader(context.Background(), common.Reference{}, signatureDigest)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if content, err := io.ReadAll(reader); err != nil || string(content) != "signed" {
+		t.Fatalf("expected the signature, got %q and %v", content, err)
+	}
+	reader.Close()
+
+	if _, err := store.GetBlobReader(context.Background(), common.Reference{}, oversizedDigest); err == nil {
+		t.Fatalf("expected an error for a blob larger than %d bytes", maxSignatureBlobSize)
+	}
+
+	registry.signatures[signatureDigest] = []byte("forged")
+	reader, err = store.GetBlobReader(context.Background(), common.Reference{}, signatureDigest)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer reader.Close()
+	if _, err := io.ReadAll(reader); err == nil {
+		t.Fatalf("expected an error for a blob not matching its digest")
+	}
+}
@@ -21,9 +21,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os/exec"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/sirupsen/logrus"
 )
@@ -31,6 +31,8 @@ import (
 const (
 	maxRetryCount = 5
 	waitDuration  = time.Second
+	// MaxStderrTail is the number of bytes at the end of the stderr of a failed plugin kept in its error
+	MaxStderrTail = 4096
 )
 
 // Executor is an interface that defines methods to lookup a plugin and execute it.
@@ -44,16 +46,12 @@ type Executor interface {
 // DefaultExecutor finds the plugin executable and invokes it as a os command
 type DefaultExecutor struct {
 	Stderr io.Writer
+	// Policy restricts the environment and resources of the plugin, nil runs the plugin with the environment of Ratify
+	Policy *ExecutionPolicy
 }
 
 func (e *DefaultExecutor) ExecutePlugin(ctx context.Context, pluginPath string, cmdArgs []string, stdinData []byte, environ []string) ([]byte, error) {
-	stdout := &bytes.Buffer{}
-	stderr := &bytes.Buffer{}
-	c := exec.CommandContext(ctx, pluginPath, cmdArgs...)
-	c.Env = environ
-	c.Stdin = bytes.NewBuffer(stdinData)
-	c.Stdout = stdout
-	c.Stderr = stderr
+	environ = e.Policy.filterEnviron(environ)
 
 	// DEBUG: log the process details used to launch the binary plugin
 	if logrus.IsLevelEnabled(logrus.DebugLevel) {
@@ -71,9 +69,41 @@ func (e *DefaultExecutor) ExecutePlugin(ctx context.Context, pluginPath string,
 		logrus.Debugf("stdin: %s", stdinData)
 	}
 
+	limits, err := e.Policy.limits()
+	if err != nil {
+		return nil, err
+	}
+	if pluginPath, err = e.Policy.pluginPath(pluginPath); err != nil {
+		return nil, err
+	}
+	workDir, removeWorkDir, err := e.Policy.createWorkDir()
+	if err != nil {
+		return nil, err
+	}
+	defer removeWorkDir()
+
+	var stdout, stderr *limitedWriter
+	var stderrTail *tailWriter
 	// Retry the command on "text file busy" errors
 	for i := 0; i <= maxRetryCount; i++ {
-		err := c.Run()
+		// stdout beyond the limit fails the plugin, stderr is only informational and truncated
+		stdout = &limitedWriter{limit: limits.outputSize, strict: true}
+		stderr = &limitedWriter{limit: limits.outputSize}
+		stderrTail = &tailWriter{size: MaxStderrTail}
+		c, cmdErr := sandboxedCommand(ctx, pluginPath, cmdArgs, environ, limits)
+		if cmdErr != nil {
+			return nil, cmdErr
+		}
+		c.Dir = workDir
+		c.Stdin = bytes.NewBuffer(stdinData)
+		c.Stdout = stdout
+		c.Stderr = io.MultiWriter(stderr, stderrTail)
+
+		err = c.Run()
+
+		if stdout.exceeded {
+			return nil, &OutputSizeError{Limit: limits.outputSize}
+		}
 
 		// Command succeeded
 		if err == nil {
@@ -88,33 +118,63 @@ func (e *DefaultExecutor) ExecutePlugin(ctx context.Context, pluginPath string,
 		}
 
 		// For all other errors return failed.
-		return nil, e.pluginErr(err, stdout.Bytes(), stderr.Bytes())
+		return nil, e.pluginErr(err, stdout.buf, stderrTail.String())
 	}
 
 	// Copy stderr to caller's buffer in case plugin printed to both
 	// stdout and stderr for some reason. Ignore failures as stderr is
 	// only informational.
-	if e.Stderr != nil && stderr.Len() > 0 {
-		_, _ = stderr.WriteTo(e.Stderr)
+	if e.Stderr != nil && len(stderr.buf) > 0 {
+		_, _ = e.Stderr.Write(stderr.buf)
 	}
 	// TODO stdout reader
-	return stdout.Bytes(), nil
+	return stdout.buf, nil
 }
 
-func (e *DefaultExecutor) pluginErr(err error, stdout, stderr []byte) error {
+func (e *DefaultExecutor) pluginErr(err error, stdout []byte, stderr string) error {
 	errMsg := Error{}
 	if len(stdout) == 0 {
 		if len(stderr) == 0 {
 			errMsg.Msg = fmt.Sprintf("plugin failed with no proper error message: %v", err)
 		} else {
-			errMsg.Msg = fmt.Sprintf("plugin failed with error: %q", string(stderr))
+			errMsg.Msg = fmt.Sprintf("plugin failed with error: %q", stderr)
 		}
 	} else if perr := json.Unmarshal(stdout, &errMsg); perr != nil {
 		errMsg.Msg = fmt.Sprintf("plugin failed and parsing its error message also failed with error %q: %v", string(stdout), perr)
 	}
+	errMsg.Stderr = stderr
 	return &errMsg
 }
 
+// tailWriter keeps the last size bytes written to it
+type tailWriter struct {
+	buf  []byte
+	size int
+	// truncated is set once bytes were dropped from the start
+	truncated bool
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	if drop := len(w.buf) - w.size; drop > 0 {
+		w.buf = append(w.buf[:0], w.buf[drop:]...)
+		w.truncated = true
+	}
+	return len(p), nil
+}
+
+// String returns the tail, starting on a valid UTF-8 character if truncated
+func (w *tailWriter) String() string {
+	if !w.truncated {
+		return string(w.buf)
+	}
+	tail := w.buf
+	for i := 0; i < utf8.UTFMax && len(tail) > 0 && !utf8.RuneStart(tail[0]); i++ {
+		tail = tail[1:]
+	}
+	return "..." + string(tail)
+}
+
 func (e *DefaultExecutor) FindInPaths(plugin string, paths []string) (string, error) {
 	return FindInPaths(plugin, paths)
 }
@@ -0,0 +1,155 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcplugin
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+
+	pb "github.com/deislabs/ratify/experimental/proto/v1/common"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+)
+
+// The first attributes of a descriptor hold its properties, and the optional second attributes its annotations
+const (
+	digestAttribute       = "digest"
+	mediaTypeAttribute    = "mediaType"
+	sizeAttribute         = "size"
+	artifactTypeAttribute = "artifactType"
+)
+
+// ToProtoDescriptor converts a descriptor, rawPath is the reference of the subject the descriptor is read with
+func ToProtoDescriptor(rawPath string, desc oci.Descriptor) *pb.Descriptor {
+	properties := map[string]string{}
+	if desc.Digest != "" {
+		properties[digestAttribute] = desc.Digest.String()
+	}
+	if desc.MediaType != "" {
+		properties[mediaTypeAttribute] = desc.MediaType
+	}
+	if desc.Size != 0 {
+		properties[sizeAttribute] = strconv.FormatInt(desc.Size, 10)
+	}
+	if desc.ArtifactType != "" {
+		properties[artifactTypeAttribute] = desc.ArtifactType
+	}
+	attributes := []*pb.Descriptor_Attributes{{Values: properties}}
+	if len(desc.Annotations) > 0 {
+		attributes = append(attributes, &pb.Descriptor_Attributes{Values: desc.Annotations})
+	}
+	return &pb.Descriptor{RawPath: rawPath, Attributes: attributes}
+}
+
+// FromProtoDescriptor converts a descriptor encoded by ToProtoDescriptor
+func FromProtoDescriptor(desc *pb.Descriptor) (oci.Descriptor, error) {
+	if desc == nil || len(desc.Attributes) == 0 {
+		return oci.Descriptor{}, fmt.Errorf("descriptor has no attributes")
+	}
+	properties := desc.Attributes[0].GetValues()
+	result := oci.Descriptor{
+		MediaType:    properties[mediaTypeAttribute],
+		ArtifactType: properties[artifactTypeAttribute],
+	}
+	if value, ok := properties[digestAttribute]; ok {
+		dgst, err := digest.Parse(value)
+		if err != nil {
+			return oci.Descriptor{}, fmt.Errorf("invalid descriptor digest %q: %w", value, err)
+		}
+		result.Digest = dgst
+	}
+	if value, ok := properties[sizeAttribute]; ok {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return oci.Descriptor{}, fmt.Errorf("invalid descriptor size %q: %w", value, err)
+		}
+		result.Size = size
+	}
+	if len(desc.Attributes) > 1 {
+		result.Annotations = desc.Attributes[1].GetValues()
+	}
+	return result, nil
+}
+
+// ToProtoReferrer converts the descriptor of a referrer
+func ToProtoReferrer(rawPath string, referenceDesc ocispecs.ReferenceDescriptor) *pb.Referrer {
+	return &pb.Referrer{ArtifactType: referenceDesc.ArtifactType, Descriptor_: ToProtoDescriptor(rawPath, referenceDesc.Descriptor)}
+}
+
+// FromProtoReferrer converts a referrer encoded by ToProtoReferrer
+func FromProtoReferrer(referrer *pb.Referrer) (ocispecs.ReferenceDescriptor, error) {
+	if referrer == nil {
+		return ocispecs.ReferenceDescriptor{}, fmt.Errorf("referrer is required")
+	}
+	desc, err := FromProtoDescriptor(referrer.Descriptor_)
+	if err != nil {
+		return ocispecs.ReferenceDescriptor{}, err
+	}
+	return ocispecs.ReferenceDescriptor{Descriptor: desc, ArtifactType: referrer.ArtifactType}, nil
+}
+
+// ToProtoManifest converts a reference manifest, the descriptor of the referrer holds the media type and annotations of the manifest
+func ToProtoManifest(rawPath string, manifest ocispecs.ReferenceManifest) *pb.Manifest {
+	result := &pb.Manifest{
+		Referrer: &pb.Referrer{
+			ArtifactType: manifest.ArtifactType,
+			Descriptor_:  ToProtoDescriptor(rawPath, oci.Descriptor{MediaType: manifest.MediaType, Annotations: manifest.Annotations}),
+		},
+		Blobs:   &pb.Manifest_Blobs{},
+		Subject: &pb.Manifest_Subjects{},
+	}
+	for _, blob := range manifest.Blobs {
+		result.Blobs.Descriptor_ = append(result.Blobs.Descriptor_, ToProtoDescriptor(rawPath, blob))
+	}
+	if manifest.Subject != nil {
+		result.Subject.Descriptor_ = append(result.Subject.Descriptor_, ToProtoDescriptor(rawPath, *manifest.Subject))
+	}
+	return result
+}
+
+// FromProtoManifest converts a manifest encoded by ToProtoManifest
+func FromProtoManifest(manifest *pb.Manifest) (ocispecs.ReferenceManifest, error) {
+	if manifest == nil {
+		return ocispecs.ReferenceManifest{}, fmt.Errorf("manifest is required")
+	}
+	referrer, err := FromProtoReferrer(manifest.Referrer)
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, err
+	}
+	result := ocispecs.ReferenceManifest{
+		MediaType:    referrer.MediaType,
+		ArtifactType: referrer.ArtifactType,
+		Blobs:        []oci.Descriptor{},
+		Annotations:  referrer.Annotations,
+	}
+	for _, blob := range manifest.GetBlobs().GetDescriptor_() {
+		desc, err := FromProtoDescriptor(blob)
+		if err != nil {
+			return ocispecs.ReferenceManifest{}, fmt.Errorf("invalid manifest blob: %w", err)
+		}
+		result.Blobs = append(result.Blobs, desc)
+	}
+	if subjects := manifest.GetSubject().GetDescriptor_(); len(subjects) > 0 {
+		subject, err := FromProtoDescriptor(subjects[0])
+		if err != nil {
+			return ocispecs.ReferenceManifest{}, fmt.Errorf("invalid manifest subject: %w", err)
+		}
+		result.Subject = &subject
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcplugin
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/deislabs/ratify/pkg/common/plugin"
+)
+
+// errVersionNotSupported is the code of the error of the skeletons for a request in a version they do not support
+const errVersionNotSupported = 6
+
+// StatusError returns the gRPC error of a failed request, carrying the code and details of pluginErr in a struct
+// detail so that clients report the error of an executed plugin
+func StatusError(code codes.Code, pluginErr *plugin.Error) error {
+	st := status.New(code, pluginErr.Msg)
+	detail, err := ToStruct(pluginErr)
+	if err != nil {
+		return st.Err()
+	}
+	withDetail, err := st.WithDetails(detail)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetail.Err()
+}
+
+// PluginError returns the plugin error carried by an error created with StatusError, false for other errors
+func PluginError(err error) (*plugin.Error, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil, false
+	}
+	for _, detail := range st.Details() {
+		s, ok := detail.(*structpb.Struct)
+		if !ok {
+			continue
+		}
+		if _, ok := s.GetFields()["code"]; !ok {
+			continue
+		}
+		pluginErr := &plugin.Error{}
+		if err := FromStruct(s, pluginErr); err != nil {
+			return nil, false
+		}
+		return pluginErr, true
+	}
+	return nil, false
+}
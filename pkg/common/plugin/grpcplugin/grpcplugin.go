@@ -0,0 +1,134 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package grpcplugin holds the helpers shared by plugins served over gRPC with the experimental protos
+// and the clients calling them.
+package grpcplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// TCPScheme prefixes the host and port of a plugin served over TCP, e.g. grpc://localhost:9000
+	TCPScheme = "grpc://"
+	// UnixScheme prefixes the path of a plugin served on a Unix socket, e.g. unix:///var/run/ratify/sbom.sock
+	UnixScheme = "unix://"
+)
+
+// IsAddress returns true if address is the address of a plugin served over gRPC rather than a directory of plugin binaries
+func IsAddress(address string) bool {
+	return strings.HasPrefix(address, TCPScheme) || strings.HasPrefix(address, UnixScheme)
+}
+
+// Dial connects to the plugin served at address. Plugins are expected to run next to Ratify, as sidecars or
+// in the same pod, so the connection is not encrypted.
+func Dial(address string) (*grpc.ClientConn, error) {
+	if !IsAddress(address) {
+		return nil, fmt.Errorf("invalid plugin address %s: expected %s<host>:<port> or %s<path>", address, TCPScheme, UnixScheme)
+	}
+	target := strings.TrimPrefix(address, TCPScheme)
+	conn, err := grpc.Dial(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// blobs are bounded by the maximum size of the store
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(math.MaxInt32)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to plugin at %s: %w", address, err)
+	}
+	return conn, nil
+}
+
+// Listen listens on the address a plugin is served at, a stale Unix socket left by a previous run is removed
+func Listen(address string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(address, TCPScheme):
+		return net.Listen("tcp", strings.TrimPrefix(address, TCPScheme))
+	case strings.HasPrefix(address, UnixScheme):
+		path := strings.TrimPrefix(address, UnixScheme)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale plugin socket %s: %w", path, err)
+		}
+		return net.Listen("unix", path)
+	default:
+		return nil, fmt.Errorf("invalid plugin address %s: expected %s<host>:<port> or %s<path>", address, TCPScheme, UnixScheme)
+	}
+}
+
+// NewServer returns a gRPC server for a plugin, sending messages as large as the clients created by Dial receive
+func NewServer(opts ...grpc.ServerOption) *grpc.Server {
+	return grpc.NewServer(append([]grpc.ServerOption{grpc.MaxRecvMsgSize(math.MaxInt32)}, opts...)...)
+}
+
+// ToStruct converts a JSON serializable value to the configuration of a request
+func ToStruct(v interface{}) (*structpb.Struct, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(content, &values); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(values)
+}
+
+// FromStruct converts the configuration of a request to v
+func FromStruct(s *structpb.Struct, v interface{}) error {
+	if s == nil {
+		return nil
+	}
+	content, err := s.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+// ExtensionsKey is the key of the JSON serialized extensions of a result in the extension data of a verifier response
+const ExtensionsKey = "extensions"
+
+// ToExtensionData serializes the extensions of a verifier result to the values of the extension data of a response
+func ToExtensionData(extensions interface{}) (map[string]string, error) {
+	if extensions == nil {
+		return nil, nil
+	}
+	content, err := json.Marshal(extensions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize extensions: %w", err)
+	}
+	return map[string]string{ExtensionsKey: string(content)}, nil
+}
+
+// FromExtensionData returns the extensions of a verifier result serialized by ToExtensionData
+func FromExtensionData(values map[string]string) (interface{}, error) {
+	content, ok := values[ExtensionsKey]
+	if !ok {
+		return nil, nil
+	}
+	var extensions interface{}
+	if err := json.Unmarshal([]byte(content), &extensions); err != nil {
+		return nil, fmt.Errorf("failed to parse extensions: %w", err)
+	}
+	return extensions, nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcplugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/deislabs/ratify/pkg/common/plugin"
+)
+
+const (
+	// VersionServiceName is the service plugins built with the skeletons report their PluginInfo with, the gRPC
+	// counterpart of the VERSION command
+	VersionServiceName = "ratify.plugin.v1.PluginVersion"
+	// VersionMetadataKey is the metadata key of the version a request is sent in
+	VersionMetadataKey = "ratify-version"
+
+	getVersionMethod = "/" + VersionServiceName + "/GetVersion"
+	// versionQueryTimeout bounds the version query sent when a plugin is configured
+	versionQueryTimeout = 10 * time.Second
+)
+
+// versionServiceDesc describes the version service, whose messages are the well-known empty and struct messages so
+// it does not need generated code
+var versionServiceDesc = grpc.ServiceDesc{
+	ServiceName: VersionServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetVersion",
+			Handler:    getVersionHandler,
+		},
+	},
+}
+
+type versionServer struct {
+	info *structpb.Struct
+}
+
+func getVersionHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := &emptypb.Empty{}
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*versionServer).info, nil
+	}
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+	return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: getVersionMethod}, handler)
+}
+
+// NewPluginServer returns a gRPC server for the plugin described by info. The server answers the version service
+// with info and rejects requests sent in a version the plugin does not support.
+func NewPluginServer(info plugin.PluginInfo) (*grpc.Server, error) {
+	infoStruct, err := ToStruct(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the info of plugin %s: %w", info.Name, err)
+	}
+	server := NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, serverInfo *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if serverInfo.FullMethod != getVersionMethod {
+				if err := checkVersion(ctx, info.SupportedVersions); err != nil {
+					return nil, err
+				}
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, serverInfo *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := checkVersion(ss.Context(), info.SupportedVersions); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}))
+	server.RegisterService(&versionServiceDesc, &versionServer{info: infoStruct})
+	return server, nil
+}
+
+// checkVersion fails a request sent in a version that is not supported, requests without a version are accepted
+// since the protos do not require it
+func checkVersion(ctx context.Context, supportedVersions []string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	versions := md.Get(VersionMetadataKey)
+	if len(versions) == 0 || plugin.IsVersionSupported(versions[0], supportedVersions) {
+		return nil
+	}
+	return StatusError(codes.FailedPrecondition, plugin.NewError(errVersionNotSupported, fmt.Sprintf("plugin does not support version %s, supported versions are [%s]", versions[0], strings.Join(supportedVersions, ", ")), ""))
+}
+
+// WithVersion returns a context sending requests in version
+func WithVersion(ctx context.Context, version string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, VersionMetadataKey, version)
+}
+
+// NegotiateVersion queries the version service of the plugin served at address on conn, and returns the highest
+// version supported by the plugin that is compatible with the requested version along with the info of the plugin.
+// Requests keep the requested version if the plugin does not serve the version service or cannot be queried, the
+// returned info is nil then.
+func NegotiateVersion(ctx context.Context, conn grpc.ClientConnInterface, address string, requested string) (string, *plugin.PluginInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionQueryTimeout)
+	defer cancel()
+
+	response := &structpb.Struct{}
+	if err := conn.Invoke(ctx, getVersionMethod, &emptypb.Empty{}, response); err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			logrus.Warnf("plugin at %s does not report its supported versions, requests use version %s", address, requested)
+		} else {
+			logrus.Warnf("failed to query the versions of plugin at %s, requests use version %s: %v", address, requested, err)
+		}
+		return requested, nil, nil
+	}
+
+	info := &plugin.PluginInfo{}
+	if err := FromStruct(response, info); err != nil {
+		return "", nil, fmt.Errorf("invalid info of plugin at %s: %w", address, err)
+	}
+	version, err := plugin.HighestCompatibleVersion(requested, info.SupportedVersions)
+	if err != nil {
+		return "", nil, fmt.Errorf("plugin at %s is not compatible: %w", address, err)
+	}
+	if version != requested {
+		logrus.Infof("plugin at %s does not support version %s, requests use version %s", address, requested, version)
+	}
+	return version, info, nil
+}
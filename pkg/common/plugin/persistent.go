@@ -0,0 +1,184 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PersistentEnvKey is set when Ratify starts a plugin as a persistent process serving many requests
+	PersistentEnvKey = "RATIFY_PLUGIN_PERSISTENT"
+	// PersistentProtocol is the protocol a persistent plugin announces in its handshake
+	PersistentProtocol = "ratify-persistent-v1"
+
+	// frames carry blobs bounded by the maximum size of the store
+	maxFrameSize = math.MaxInt32
+	// the handshake is small, a larger frame is the output of a plugin that does not support the persistent mode
+	maxHandshakeSize = 4096
+)
+
+// PersistentHandshake is the first frame a persistent plugin writes to its stdout
+type PersistentHandshake struct {
+	Protocol string `json:"protocol"`
+}
+
+// PersistentRequest is the invocation of a plugin sent to a persistent plugin process
+type PersistentRequest struct {
+	// Environ holds the environment variables an executed plugin is invoked with
+	Environ []string `json:"environ"`
+	// Stdin is the data an executed plugin reads from stdin
+	Stdin []byte `json:"stdin"`
+}
+
+// Getenv returns the value of an environment variable of the request
+func (r PersistentRequest) Getenv(key string) string {
+	for i := len(r.Environ) - 1; i >= 0; i-- {
+		if strings.HasPrefix(r.Environ[i], key+"=") {
+			return strings.TrimPrefix(r.Environ[i], key+"=")
+		}
+	}
+	return ""
+}
+
+// PersistentResponse is the result of a request to a persistent plugin process
+type PersistentResponse struct {
+	// Stdout is the output an executed plugin writes to stdout
+	Stdout []byte `json:"stdout,omitempty"`
+	// Error is the error an executed plugin writes to stdout when it exits with a failure
+	Error *Error `json:"error,omitempty"`
+	// Stderr is the tail of what the plugin wrote to stderr while serving a failed request, at most MaxStderrTail bytes
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// MainOptions are the options of the main function of a plugin skeleton
+type MainOptions struct {
+	// Persistent is true if the plugin can run as a persistent process serving many requests
+	Persistent bool
+	// ArtifactTypes, MediaTypes and Features are reported in the PluginInfo of the plugin
+	ArtifactTypes []string
+	MediaTypes    []string
+	Features      []string
+}
+
+// MainOption sets an option of the main function of a plugin skeleton
+type MainOption func(*MainOptions)
+
+// WithPersistentMode advertises that the plugin can run as a persistent process. Plugins opting in must not keep
+// state between requests that changes their results.
+func WithPersistentMode() MainOption {
+	return func(options *MainOptions) {
+		options.Persistent = true
+	}
+}
+
+// NewMainOptions applies opts to the default options
+func NewMainOptions(opts ...MainOption) MainOptions {
+	options := MainOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// ServePersistent announces the persistent protocol on w and serves the requests read from r until r is closed.
+// Each request is handled with a writer of stderr keeping the tail of what is written to it, which is returned with
+// a failed response. The standard and logrus loggers write to it while the request is served.
+func ServePersistent(r io.Reader, w io.Writer, stderr io.Writer, handle func(request PersistentRequest, stderr io.Writer) PersistentResponse) error {
+	if err := WriteFrame(w, PersistentHandshake{Protocol: PersistentProtocol}); err != nil {
+		return err
+	}
+	defer func() {
+		log.SetOutput(stderr)
+		logrus.SetOutput(stderr)
+	}()
+	for {
+		var request PersistentRequest
+		if err := ReadFrame(r, &request, maxFrameSize); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		// requests are served one at a time, so the loggers only write what is logged for this request
+		stderrTail := &tailWriter{size: MaxStderrTail}
+		requestStderr := io.MultiWriter(stderr, stderrTail)
+		log.SetOutput(requestStderr)
+		logrus.SetOutput(requestStderr)
+		response := handle(request, requestStderr)
+		if response.Error != nil {
+			response.Stderr = stderrTail.String()
+		}
+		if err := WriteFrame(w, response); err != nil {
+			return err
+		}
+	}
+}
+
+// frameSizeError is returned when a frame is larger than the maximum size of the reader
+type frameSizeError struct {
+	size  uint64
+	limit int
+}
+
+func (e *frameSizeError) Error() string {
+	return fmt.Sprintf("frame of %d bytes is larger than %d bytes", e.size, e.limit)
+}
+
+// WriteFrame writes v as JSON prefixed with its length as a 4 byte big endian integer
+func WriteFrame(w io.Writer, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(content) > maxFrameSize {
+		return fmt.Errorf("frame of %d bytes is larger than %d bytes", len(content), maxFrameSize)
+	}
+	frame := make([]byte, 4+len(content))
+	binary.BigEndian.PutUint32(frame, uint32(len(content)))
+	copy(frame[4:], content)
+	_, err = w.Write(frame)
+	return err
+}
+
+// ReadFrame reads a frame written by WriteFrame into v, io.EOF is returned if r is closed before the frame starts
+func ReadFrame(r io.Reader, v interface{}, maxSize int) error {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if uint64(size) > uint64(maxSize) {
+		return &frameSizeError{size: uint64(size), limit: maxSize}
+	}
+	content := make([]byte, size)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return fmt.Errorf("failed to read frame: %w", err)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("invalid frame: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,375 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxProcesses is the number of persistent processes kept for a plugin if not configured
+	DefaultMaxProcesses = 4
+	// handshakeTimeout bounds the wait for a plugin to announce the persistent protocol
+	handshakeTimeout = 5 * time.Second
+)
+
+// errPersistentUnsupported is returned when a plugin does not announce the persistent protocol
+var errPersistentUnsupported = errors.New("plugin does not support the persistent mode")
+
+// the pools are shared by all executors so that recreating a verifier or store keeps its warm processes
+var (
+	poolsMutex sync.Mutex
+	pools      = map[string]*processPool{}
+)
+
+// PersistentExecutor runs plugins in a bounded pool of long-lived processes, exchanging requests over stdin and stdout
+// instead of executing the plugin for each request. Plugins that do not advertise the persistent mode are executed.
+type PersistentExecutor struct {
+	DefaultExecutor
+	// MaxProcesses is the maximum number of processes running a plugin
+	MaxProcesses int
+}
+
+// NewExecutor returns the executor of a plugin from the persistent and maxProcesses options of its config,
+// plugins are executed for each request unless persistent is true. The plugin runs under policy if it is not nil.
+func NewExecutor(persistent interface{}, maxProcesses interface{}, policy *ExecutionPolicy) (Executor, error) {
+	enabled, err := parseBool(persistent)
+	if err != nil {
+		return nil, fmt.Errorf("invalid persistent option: %w", err)
+	}
+	if !enabled {
+		return &DefaultExecutor{Stderr: os.Stderr, Policy: policy}, nil
+	}
+	size, err := parseInt(maxProcesses)
+	// an unset maxProcesses is the default number of processes
+	if err != nil || (maxProcesses != nil && size <= 0) {
+		return nil, fmt.Errorf("invalid maxProcesses option %v: must be a positive number", maxProcesses)
+	}
+	return &PersistentExecutor{DefaultExecutor: DefaultExecutor{Stderr: os.Stderr, Policy: policy}, MaxProcesses: size}, nil
+}
+
+func parseBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	default:
+		return false, fmt.Errorf("unexpected value %v", value)
+	}
+}
+
+func parseInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		return int(v), nil
+	case int:
+		return v, nil
+	case string:
+		return strconv.Atoi(v)
+	default:
+		return 0, fmt.Errorf("unexpected value %v", value)
+	}
+}
+
+func (e *PersistentExecutor) ExecutePlugin(ctx context.Context, pluginPath string, cmdArgs []string, stdinData []byte, environ []string) ([]byte, error) {
+	// variables of the request are filtered like those of an executed plugin, they are passed to the same process
+	environ = e.Policy.filterEnviron(environ)
+	pool := e.getPool(pluginPath)
+	if len(cmdArgs) > 0 || pool.unsupported.Load() {
+		return e.DefaultExecutor.ExecutePlugin(ctx, pluginPath, cmdArgs, stdinData, environ)
+	}
+
+	stdout, err := pool.execute(ctx, PersistentRequest{Environ: environ, Stdin: stdinData})
+	if errors.Is(err, errPersistentUnsupported) {
+		logrus.Warnf("plugin %s does not support the persistent mode, it is executed for each request", pluginPath)
+		pool.unsupported.Store(true)
+		return e.DefaultExecutor.ExecutePlugin(ctx, pluginPath, cmdArgs, stdinData, environ)
+	}
+	return stdout, err
+}
+
+func (e *PersistentExecutor) getPool(pluginPath string) *processPool {
+	maxProcesses := e.MaxProcesses
+	if maxProcesses <= 0 {
+		maxProcesses = DefaultMaxProcesses
+	}
+	stderr := e.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	policy, _ := json.Marshal(e.Policy)
+	key := fmt.Sprintf("%s:%d:%s", pluginPath, maxProcesses, policy)
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
+	pool, ok := pools[key]
+	if !ok {
+		pool = &processPool{
+			path:   pluginPath,
+			stderr: stderr,
+			policy: e.Policy,
+			slots:  make(chan struct{}, maxProcesses),
+			idle:   make(chan *pluginProcess, maxProcesses),
+		}
+		pools[key] = pool
+	}
+	return pool
+}
+
+// StopPersistentPlugins stops the idle processes of all persistent plugins
+func StopPersistentPlugins() {
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
+	for key, pool := range pools {
+		pool.stopIdle()
+		delete(pools, key)
+	}
+}
+
+type processPool struct {
+	path   string
+	stderr io.Writer
+	policy *ExecutionPolicy
+	// slots holds a token for each running process, bounding their number
+	slots chan struct{}
+	idle  chan *pluginProcess
+	// unsupported is set once the plugin failed the handshake
+	unsupported atomic.Bool
+}
+
+type pluginProcess struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	// removeWorkDir removes the working directory of the process once it is stopped
+	removeWorkDir func()
+	// served is the number of requests served, a process that served requests before failing is restarted
+	served   int
+	stopOnce sync.Once
+}
+
+func (p *processPool) execute(ctx context.Context, request PersistentRequest) ([]byte, error) {
+	limits, err := p.policy.limits()
+	if err != nil {
+		return nil, err
+	}
+	// the output is base64 encoded in the response, its size is only bounded by the frame size without a policy
+	maxResponseSize := limits.outputSize/3*4 + maxHandshakeSize
+	if limits.outputSize == 0 || maxResponseSize > maxFrameSize {
+		maxResponseSize = maxFrameSize
+	}
+	for {
+		process, err := p.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		response, err := process.roundTrip(ctx, request, int(maxResponseSize))
+		if err != nil {
+			var sizeErr *frameSizeError
+			if errors.As(err, &sizeErr) {
+				p.discard(process)
+				return nil, &OutputSizeError{Limit: limits.outputSize}
+			}
+			p.discard(process)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			// a process that crashed after serving requests is replaced, the request is retried on a new process
+			if process.served > 0 {
+				logrus.Warnf("persistent plugin %s failed, restarting it: %v", p.path, err)
+				continue
+			}
+			return nil, fmt.Errorf("persistent plugin %s failed: %w", p.path, err)
+		}
+		process.served++
+		p.idle <- process
+		if response.Error != nil {
+			response.Error.Stderr = response.Stderr
+			return nil, response.Error
+		}
+		if limits.outputSize > 0 && int64(len(response.Stdout)) > limits.outputSize {
+			return nil, &OutputSizeError{Limit: limits.outputSize}
+		}
+		return response.Stdout, nil
+	}
+}
+
+func (p *processPool) acquire(ctx context.Context) (*pluginProcess, error) {
+	select {
+	case process := <-p.idle:
+		return process, nil
+	default:
+	}
+	select {
+	case process := <-p.idle:
+		return process, nil
+	case p.slots <- struct{}{}:
+		process, err := p.start()
+		if err != nil {
+			<-p.slots
+			return nil, err
+		}
+		return process, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// discard stops a process that failed and frees its slot
+func (p *processPool) discard(process *pluginProcess) {
+	process.stop()
+	<-p.slots
+}
+
+func (p *processPool) stopIdle() {
+	for {
+		select {
+		case process := <-p.idle:
+			p.discard(process)
+		default:
+			return
+		}
+	}
+}
+
+func (p *processPool) start() (*pluginProcess, error) {
+	limits, err := p.policy.limits()
+	if err != nil {
+		return nil, err
+	}
+	pluginPath, err := p.policy.pluginPath(p.path)
+	if err != nil {
+		return nil, err
+	}
+	workDir, removeWorkDir, err := p.policy.createWorkDir()
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := sandboxedCommand(context.Background(), pluginPath, nil, p.policy.filterEnviron(append(os.Environ(), PersistentEnvKey+"=1")), limits)
+	if err != nil {
+		removeWorkDir()
+		return nil, err
+	}
+	cmd.Dir = workDir
+	cmd.Stderr = p.stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		removeWorkDir()
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		removeWorkDir()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		removeWorkDir()
+		return nil, fmt.Errorf("failed to start persistent plugin %s: %w", p.path, err)
+	}
+	// the limits apply to the lifetime of the process rather than to each request, the CPU time of all the requests
+	// it served counts towards its CPU time limit. A process exceeding the limits is restarted.
+	process := &pluginProcess{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout), removeWorkDir: removeWorkDir}
+
+	handshake := make(chan error, 1)
+	go func() {
+		var message PersistentHandshake
+		if err := ReadFrame(process.stdout, &message, maxHandshakeSize); err != nil {
+			handshake <- err
+			return
+		}
+		if message.Protocol != PersistentProtocol {
+			handshake <- fmt.Errorf("unknown protocol %s", message.Protocol)
+			return
+		}
+		handshake <- nil
+	}()
+
+	timer := time.NewTimer(handshakeTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-handshake:
+		if err != nil {
+			process.stop()
+			logrus.Debugf("persistent handshake of plugin %s failed: %v", p.path, err)
+			return nil, errPersistentUnsupported
+		}
+		return process, nil
+	case <-timer.C:
+		process.stop()
+		return nil, errPersistentUnsupported
+	}
+}
+
+// roundTrip sends a request to the process and reads its response, the process is stopped if ctx is done first
+func (process *pluginProcess) roundTrip(ctx context.Context, request PersistentRequest, maxResponseSize int) (PersistentResponse, error) {
+	type result struct {
+		response PersistentResponse
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		if err := WriteFrame(process.stdin, request); err != nil {
+			done <- result{err: err}
+			return
+		}
+		var response PersistentResponse
+		err := ReadFrame(process.stdout, &response, maxResponseSize)
+		done <- result{response: response, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.response, r.err
+	case <-ctx.Done():
+		// the process may be in the middle of a frame, it cannot serve other requests
+		process.stop()
+		return PersistentResponse{}, ctx.Err()
+	}
+}
+
+func (process *pluginProcess) stop() {
+	process.stopOnce.Do(func() {
+		_ = process.stdin.Close()
+		_ = process.cmd.Process.Kill()
+		// Wait closes stdout, so the process is only reaped once it is no longer read
+		go func() {
+			_ = process.cmd.Wait()
+			process.removeWorkDir()
+		}()
+	})
+}
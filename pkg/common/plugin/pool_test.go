@@ -0,0 +1,255 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPluginEnvKey makes the test binary act as a plugin, persistent, executed for each request or reporting its sandbox
+const testPluginEnvKey = "RATIFY_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(testPluginEnvKey) {
+	case "persistent":
+		if os.Getenv(PersistentEnvKey) == "" {
+			runTestPlugin(os.Stdin, os.Stdout)
+			os.Exit(0)
+		}
+		err := ServePersistent(os.Stdin, os.Stdout, os.Stderr, func(request PersistentRequest, stderr io.Writer) PersistentResponse {
+			if request.Getenv("RATIFY_TEST_COMMAND") == "crash" {
+				os.Exit(1)
+			}
+			if request.Getenv("RATIFY_TEST_COMMAND") == "fail" {
+				fmt.Fprintf(stderr, "failing request %s", request.Stdin)
+				return PersistentResponse{Error: NewError(8, "simulated error", "")}
+			}
+			if request.Getenv("RATIFY_TEST_COMMAND") == "env" {
+				// reports the variables of the request and of the process
+				return PersistentResponse{Stdout: []byte(fmt.Sprintf("%s,%s,%s:%d", request.Getenv("TEST_PLUGIN_SECRET"), os.Getenv("TEST_PLUGIN_SECRET"), request.Getenv("TEST_PLUGIN_ALLOWED"), os.Getpid()))}
+			}
+			if request.Getenv("RATIFY_TEST_COMMAND") == "sleep" {
+				time.Sleep(100 * time.Millisecond)
+			}
+			out := &bytes.Buffer{}
+			runTestPlugin(bytes.NewReader(request.Stdin), out)
+			return PersistentResponse{Stdout: out.Bytes()}
+		})
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "sandbox":
+		runSandboxTestPlugin()
+		os.Exit(0)
+	case "exec":
+		if os.Getenv(PersistentEnvKey) != "" {
+			// a plugin that does not support the persistent mode fails without the variables of a command
+			_, _ = os.Stdout.WriteString(`{"code":4,"msg":"missing env variables"}`)
+			os.Exit(1)
+		}
+		runTestPlugin(os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin writes its input and process id
+func runTestPlugin(stdin io.Reader, stdout io.Writer) {
+	input, _ := io.ReadAll(stdin)
+	fmt.Fprintf(stdout, "%s:%d", input, os.Getpid())
+}
+
+func executeTestPlugin(t *testing.T, executor Executor, command string, input string) (string, string, error) {
+	t.Helper()
+	environ := append(os.Environ(), "RATIFY_TEST_COMMAND="+command)
+	stdout, err := executor.ExecutePlugin(context.Background(), os.Args[0], nil, []byte(input), environ)
+	if err != nil {
+		return "", "", err
+	}
+	output, pid, _ := strings.Cut(string(stdout), ":")
+	return output, pid, nil
+}
+
+func newTestPersistentExecutor(t *testing.T, mode string, maxProcesses int) *PersistentExecutor {
+	t.Setenv(testPluginEnvKey, mode)
+	t.Cleanup(StopPersistentPlugins)
+	return &PersistentExecutor{DefaultExecutor: DefaultExecutor{Stderr: io.Discard}, MaxProcesses: maxProcesses}
+}
+
+func TestPersistentExecutor_FiltersEnviron(t *testing.T) {
+	executor := newTestPersistentExecutor(t, "persistent", 1)
+	executor.Policy = &ExecutionPolicy{AllowedEnv: []string{"TEST_PLUGIN_ALLOWED"}}
+	t.Setenv("TEST_PLUGIN_SECRET", "secret")
+	t.Setenv("TEST_PLUGIN_ALLOWED", "allowed")
+
+	output, _, err := executeTestPlugin(t, executor, "env", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if output != ",,allowed" {
+		t.Fatalf("expected only allowed variables to reach the plugin, got %q", output)
+	}
+}
+
+func TestPersistentExecutor_ReusesProcess(t *testing.T) {
+	executor := newTestPersistentExecutor(t, "persistent", 1)
+
+	var pids []string
+	for i := 0; i < 3; i++ {
+		output, pid, err := executeTestPlugin(t, executor, "verify", fmt.Sprintf("request%d", i))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if output != fmt.Sprintf("request%d", i) {
+			t.Fatalf("expected output of request %d, got %s", i, output)
+		}
+		if pid == fmt.Sprint(os.Getpid()) {
+			t.Fatalf("expected the plugin to run in its own process")
+		}
+		pids = append(pids, pid)
+	}
+	if pids[0] != pids[1] || pids[1] != pids[2] {
+		t.Fatalf("expected requests to be served by a single process, got %v", pids)
+	}
+
+	for _, input := range []string{"first", "second"} {
+		_, _, err := executeTestPlugin(t, executor, "fail", input)
+		var pluginErr *Error
+		if !errors.As(err, &pluginErr) || pluginErr.Msg != "simulated error" {
+			t.Fatalf("expected plugin error, got %v", err)
+		}
+		// the stderr of the error is only what the process wrote while serving the request
+		if pluginErr.Stderr != "failing request "+input {
+			t.Fatalf("expected the stderr of the request, got %q", pluginErr.Stderr)
+		}
+	}
+	if _, pid, err := executeTestPlugin(t, executor, "verify", "request"); err != nil || pid != pids[0] {
+		t.Fatalf("expected the process to be kept after a plugin error, got %s and error %v", pid, err)
+	}
+}
+
+func TestPersistentExecutor_RestartsCrashedProcess(t *testing.T) {
+	executor := newTestPersistentExecutor(t, "persistent", 1)
+
+	_, first, err := executeTestPlugin(t, executor, "verify", "request")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, _, err := executeTestPlugin(t, executor, "crash", "request"); err == nil {
+		t.Fatalf("expected error when the plugin crashes")
+	}
+	_, second, err := executeTestPlugin(t, executor, "verify", "request")
+	if err != nil {
+		t.Fatalf("expected a new process after the crash, got %v", err)
+	}
+	if first == second {
+		t.Fatalf("expected the crashed process %s to be replaced", first)
+	}
+}
+
+func TestPersistentExecutor_BoundsProcesses(t *testing.T) {
+	executor := newTestPersistentExecutor(t, "persistent", 2)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	pids := map[string]bool{}
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, pid, err := executeTestPlugin(t, executor, "sleep", "request")
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+				return
+			}
+			mutex.Lock()
+			pids[pid] = true
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(pids) == 0 || len(pids) > 2 {
+		t.Fatalf("expected at most 2 processes, got %v", pids)
+	}
+}
+
+func TestPersistentExecutor_FallsBackToExecution(t *testing.T) {
+	executor := newTestPersistentExecutor(t, "exec", 1)
+
+	var pids []string
+	for i := 0; i < 2; i++ {
+		output, pid, err := executeTestPlugin(t, executor, "verify", "request")
+		if err != nil || output != "request" {
+			t.Fatalf("expected the plugin to be executed, got %s and error %v", output, err)
+		}
+		pids = append(pids, pid)
+	}
+	if pids[0] == pids[1] {
+		t.Fatalf("expected a process for each request")
+	}
+	if !executor.getPool(os.Args[0]).unsupported.Load() {
+		t.Fatalf("expected the plugin to be marked as not supporting the persistent mode")
+	}
+}
+
+func TestNewExecutor(t *testing.T) {
+	testCases := []struct {
+		name               string
+		persistent         interface{}
+		maxProcesses       interface{}
+		expectPersistent   bool
+		expectMaxProcesses int
+		expectErr          bool
+	}{
+		{name: "not set", expectPersistent: false},
+		{name: "disabled", persistent: false, expectPersistent: false},
+		{name: "enabled", persistent: true, expectPersistent: true},
+		{name: "enabled with max processes", persistent: "true", maxProcesses: float64(8), expectPersistent: true, expectMaxProcesses: 8},
+		{name: "invalid persistent", persistent: "sometimes", expectErr: true},
+		{name: "invalid max processes", persistent: true, maxProcesses: 1.5, expectErr: true},
+		{name: "negative max processes", persistent: true, maxProcesses: "-1", expectErr: true},
+		{name: "zero max processes", persistent: true, maxProcesses: float64(0), expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			executor, err := NewExecutor(tc.persistent, tc.maxProcesses, nil)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			persistent, ok := executor.(*PersistentExecutor)
+			if ok != tc.expectPersistent {
+				t.Fatalf("expected persistent executor %v, got %T", tc.expectPersistent, executor)
+			}
+			if ok && persistent.MaxProcesses != tc.expectMaxProcesses {
+				t.Fatalf("expected max processes %d, got %d", tc.expectMaxProcesses, persistent.MaxProcesses)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// DefaultMaxOutputSize is the maximum size of the stdout of a plugin run under a policy that does not configure it
+const DefaultMaxOutputSize = "64Mi"
+
+// ExecutionPolicy restricts the environment and resources of an executed plugin
+type ExecutionPolicy struct {
+	// AllowedEnv lists the environment variables of Ratify passed to the plugin. Variables prefixed with RATIFY_
+	// carry the command and are always passed.
+	AllowedEnv []string `json:"allowedEnv,omitempty"`
+	// MaxMemory is the maximum virtual memory of the plugin as a quantity, e.g. 512Mi
+	MaxMemory string `json:"maxMemory,omitempty"`
+	// MaxCPUTime is the maximum CPU time of the plugin as a duration, e.g. 10s
+	MaxCPUTime string `json:"maxCPUTime,omitempty"`
+	// MaxFileSize is the maximum size of a file written by the plugin as a quantity
+	MaxFileSize string `json:"maxFileSize,omitempty"`
+	// MaxOutputSize is the maximum size of the stdout of the plugin as a quantity, defaults to DefaultMaxOutputSize
+	MaxOutputSize string `json:"maxOutputSize,omitempty"`
+	// Cgroup is the path of a cgroup v2 directory the plugin is placed in before it starts, the limits configured
+	// on the cgroup apply to all the plugin processes placed in it
+	Cgroup string `json:"cgroup,omitempty"`
+}
+
+// ParseExecutionPolicy returns the policy set in the config of a plugin, nil if the policy is not set
+func ParseExecutionPolicy(value interface{}) (*ExecutionPolicy, error) {
+	if value == nil {
+		return nil, nil
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	policy := &ExecutionPolicy{}
+	if err := json.Unmarshal(content, policy); err != nil {
+		return nil, fmt.Errorf("invalid execution policy: %w", err)
+	}
+	if _, err := policy.limits(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// OutputSizeError is returned when a plugin writes more than the maximum output size to stdout
+type OutputSizeError struct {
+	Limit int64
+}
+
+func (e *OutputSizeError) Error() string {
+	return fmt.Sprintf("plugin output is larger than the maximum output size of %d bytes", e.Limit)
+}
+
+// resourceLimits are the limits of a policy, zero for a resource that is not limited
+type resourceLimits struct {
+	memory     uint64
+	cpuSeconds uint64
+	fileSize   uint64
+	outputSize int64
+	cgroup     string
+}
+
+// sandboxed returns whether the limits are applied to the plugin process before it starts
+func (limits resourceLimits) sandboxed() bool {
+	return limits.memory != 0 || limits.cpuSeconds != 0 || limits.fileSize != 0 || limits.cgroup != ""
+}
+
+// limits returns the limits of the policy, a nil policy does not limit the plugin
+func (policy *ExecutionPolicy) limits() (resourceLimits, error) {
+	if policy == nil {
+		return resourceLimits{}, nil
+	}
+	limits := resourceLimits{cgroup: policy.Cgroup}
+	if limits.cgroup != "" && !filepath.IsAbs(limits.cgroup) {
+		return resourceLimits{}, fmt.Errorf("invalid cgroup %q: must be an absolute path", policy.Cgroup)
+	}
+	var err error
+	if limits.memory, err = parseQuantity("maxMemory", policy.MaxMemory); err != nil {
+		return resourceLimits{}, err
+	}
+	if limits.fileSize, err = parseQuantity("maxFileSize", policy.MaxFileSize); err != nil {
+		return resourceLimits{}, err
+	}
+	maxOutputSize := policy.MaxOutputSize
+	if maxOutputSize == "" {
+		maxOutputSize = DefaultMaxOutputSize
+	}
+	outputSize, err := parseQuantity("maxOutputSize", maxOutputSize)
+	if err != nil {
+		return resourceLimits{}, err
+	}
+	limits.outputSize = int64(outputSize)
+	if policy.MaxCPUTime != "" {
+		cpuTime, err := time.ParseDuration(policy.MaxCPUTime)
+		if err != nil || cpuTime <= 0 {
+			return resourceLimits{}, fmt.Errorf("invalid maxCPUTime %q: must be a positive duration", policy.MaxCPUTime)
+		}
+		// the CPU time limit is enforced in seconds
+		limits.cpuSeconds = uint64(math.Ceil(cpuTime.Seconds()))
+	}
+	return limits, nil
+}
+
+func parseQuantity(name string, value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	size, ok := quantity.AsInt64()
+	if !ok || size <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive number of bytes", name, value)
+	}
+	return uint64(size), nil
+}
+
+// filterEnviron returns the variables of environ passed to a plugin under the policy, all of them if policy is nil
+func (policy *ExecutionPolicy) filterEnviron(environ []string) []string {
+	if policy == nil {
+		return environ
+	}
+	allowed := map[string]bool{}
+	for _, name := range policy.AllowedEnv {
+		allowed[name] = true
+	}
+	filtered := []string{}
+	for _, env := range environ {
+		name := strings.SplitN(env, "=", 2)[0]
+		if allowed[name] || strings.HasPrefix(name, "RATIFY_") {
+			filtered = append(filtered, env)
+		}
+	}
+	return filtered
+}
+
+// pluginPath returns the path of the plugin to execute under the policy, which is absolute since the plugin does not
+// run in the working directory of Ratify
+func (policy *ExecutionPolicy) pluginPath(path string) (string, error) {
+	if policy == nil {
+		return path, nil
+	}
+	return filepath.Abs(path)
+}
+
+// createWorkDir creates an empty read-only working directory for a plugin under the policy, the returned
+// function removes it. No directory is created if policy is nil.
+func (policy *ExecutionPolicy) createWorkDir() (string, func(), error) {
+	if policy == nil {
+		return "", func() {}, nil
+	}
+	dir, err := os.MkdirTemp("", "ratify-plugin-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create plugin working directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0500); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("failed to create plugin working directory: %w", err)
+	}
+	return dir, func() {
+		_ = os.RemoveAll(dir)
+	}, nil
+}
+
+// limitedWriter buffers up to limit bytes, writes beyond the limit fail if strict and are dropped otherwise.
+// A limit of zero buffers everything written.
+type limitedWriter struct {
+	buf      []byte
+	limit    int64
+	strict   bool
+	exceeded bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.limit <= 0 {
+		w.buf = append(w.buf, p...)
+		return len(p), nil
+	}
+	remaining := w.limit - int64(len(w.buf))
+	if int64(len(p)) > remaining {
+		w.exceeded = true
+		if remaining > 0 {
+			w.buf = append(w.buf, p[:remaining]...)
+		}
+		if w.strict {
+			return 0, &OutputSizeError{Limit: w.limit}
+		}
+		return len(p), nil
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
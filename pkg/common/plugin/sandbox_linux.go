@@ -0,0 +1,115 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxEnvKey is set when Ratify re-executes itself to apply the limits of a plugin before executing it
+const sandboxEnvKey = "RATIFY_PLUGIN_SANDBOX"
+
+// sandboxSpec describes the plugin to execute and the limits applied to the process before it is executed
+type sandboxSpec struct {
+	Path       string `json:"path"`
+	Memory     uint64 `json:"memory,omitempty"`
+	CPUSeconds uint64 `json:"cpuSeconds,omitempty"`
+	FileSize   uint64 `json:"fileSize,omitempty"`
+	Cgroup     string `json:"cgroup,omitempty"`
+}
+
+func init() {
+	// the process is the sandbox of a plugin, it never returns to the caller of the binary
+	if spec, ok := os.LookupEnv(sandboxEnvKey); ok {
+		err := enterSandbox(spec)
+		fmt.Fprintf(os.Stderr, "failed to sandbox plugin: %v\n", err)
+		os.Exit(126)
+	}
+}
+
+// sandboxedCommand returns the command executing the plugin at path. If limits are set, the command re-executes
+// Ratify, which places itself in the cgroup and sets the rlimits of the limits before it executes the plugin, so
+// the plugin never runs without them.
+func sandboxedCommand(ctx context.Context, path string, args []string, environ []string, limits resourceLimits) (*exec.Cmd, error) {
+	if !limits.sandboxed() {
+		cmd := exec.CommandContext(ctx, path, args...)
+		cmd.Env = environ
+		return cmd, nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sandbox plugin: %w", err)
+	}
+	spec, err := json.Marshal(sandboxSpec{
+		Path:       path,
+		Memory:     limits.memory,
+		CPUSeconds: limits.cpuSeconds,
+		FileSize:   limits.fileSize,
+		Cgroup:     limits.cgroup,
+	})
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, self)
+	// the plugin is executed with the arguments of the command, the first one being its path
+	cmd.Args = append([]string{path}, args...)
+	cmd.Env = append(append([]string{}, environ...), sandboxEnvKey+"="+string(spec))
+	return cmd, nil
+}
+
+// enterSandbox applies the limits of spec to the current process and executes the plugin, it only returns on failure
+func enterSandbox(specJSON string) error {
+	if err := os.Unsetenv(sandboxEnvKey); err != nil {
+		return err
+	}
+	var spec sandboxSpec
+	if err := json.Unmarshal([]byte(specJSON), &spec); err != nil {
+		return err
+	}
+	if spec.Cgroup != "" {
+		// writing 0 moves the writing process to the cgroup
+		if err := os.WriteFile(filepath.Join(spec.Cgroup, "cgroup.procs"), []byte("0"), 0); err != nil {
+			return fmt.Errorf("failed to place plugin in cgroup %s: %w", spec.Cgroup, err)
+		}
+	}
+	for _, limit := range []struct {
+		name     string
+		resource int
+		value    uint64
+	}{
+		{name: "memory", resource: unix.RLIMIT_AS, value: spec.Memory},
+		{name: "CPU time", resource: unix.RLIMIT_CPU, value: spec.CPUSeconds},
+		{name: "file size", resource: unix.RLIMIT_FSIZE, value: spec.FileSize},
+	} {
+		if limit.value == 0 {
+			continue
+		}
+		rlimit := unix.Rlimit{Cur: limit.value, Max: limit.value}
+		if err := unix.Setrlimit(limit.resource, &rlimit); err != nil {
+			return fmt.Errorf("failed to limit %s of plugin: %w", limit.name, err)
+		}
+	}
+	// #nosec G204 -- the plugin path is set by Ratify
+	return syscall.Exec(spec.Path, os.Args, os.Environ())
+}
@@ -0,0 +1,81 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// limitsReport holds the rlimits a sandboxed test plugin started with
+type limitsReport struct {
+	Limits  map[int]unix.Rlimit `json:"limits"`
+	Sandbox string              `json:"sandbox"`
+}
+
+func init() {
+	// the test binary reports its rlimits when executed as a sandboxed plugin
+	if os.Getenv(testPluginEnvKey) != "limits" {
+		return
+	}
+	report := limitsReport{Limits: map[int]unix.Rlimit{}, Sandbox: os.Getenv(sandboxEnvKey)}
+	for _, resource := range []int{unix.RLIMIT_AS, unix.RLIMIT_CPU, unix.RLIMIT_FSIZE} {
+		var rlimit unix.Rlimit
+		_ = unix.Getrlimit(resource, &rlimit)
+		report.Limits[resource] = rlimit
+	}
+	_ = json.NewEncoder(os.Stdout).Encode(report)
+	os.Exit(0)
+}
+
+func TestDefaultExecutor_LimitsAppliedBeforeStart(t *testing.T) {
+	t.Setenv(testPluginEnvKey, "limits")
+	executor := &DefaultExecutor{Policy: &ExecutionPolicy{MaxMemory: "1Gi", MaxCPUTime: "10s", MaxFileSize: "1Mi"}}
+	stdout, err := executor.ExecutePlugin(context.Background(), os.Args[0], nil, nil, os.Environ())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	report := limitsReport{}
+	if err := json.Unmarshal(stdout, &report); err != nil {
+		t.Fatalf("failed to parse plugin output %s: %v", stdout, err)
+	}
+	for resource, expected := range map[int]uint64{unix.RLIMIT_AS: 1 << 30, unix.RLIMIT_CPU: 10, unix.RLIMIT_FSIZE: 1 << 20} {
+		if rlimit := report.Limits[resource]; rlimit.Cur != expected || rlimit.Max != expected {
+			t.Fatalf("expected limit %d of resource %d, got %+v", expected, resource, rlimit)
+		}
+	}
+	if report.Sandbox != "" {
+		t.Fatalf("expected the sandbox variable not to be passed to the plugin, got %s", report.Sandbox)
+	}
+}
+
+func TestDefaultExecutor_Cgroup(t *testing.T) {
+	t.Setenv(testPluginEnvKey, "limits")
+	missing := filepath.Join(t.TempDir(), "missing")
+	executor := &DefaultExecutor{Policy: &ExecutionPolicy{Cgroup: missing}}
+	_, err := executor.ExecutePlugin(context.Background(), os.Args[0], nil, nil, os.Environ())
+	var pluginErr *Error
+	if !errors.As(err, &pluginErr) || !strings.Contains(pluginErr.Stderr, "failed to place plugin in cgroup") {
+		t.Fatalf("expected the plugin not to run outside of its cgroup, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !linux
+// +build !linux
+
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// sandboxedCommand returns the command executing the plugin at path, it fails if limits are set since they are
+// only supported on Linux
+func sandboxedCommand(ctx context.Context, path string, args []string, environ []string, limits resourceLimits) (*exec.Cmd, error) {
+	if limits.sandboxed() {
+		return nil, fmt.Errorf("plugin resource limits and cgroups are only supported on Linux")
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = environ
+	return cmd, nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type sandboxReport struct {
+	Secret   string `json:"secret"`
+	Allowed  string `json:"allowed"`
+	Command  string `json:"command"`
+	WorkDir  string `json:"workDir"`
+	Writable bool   `json:"writable"`
+}
+
+// runSandboxTestPlugin reports the environment and working directory of the plugin, floods its stdout, or fails
+// after flooding its stderr
+func runSandboxTestPlugin() {
+	switch os.Getenv("RATIFY_TEST_COMMAND") {
+	case "flood":
+		_, _ = os.Stdout.Write([]byte(strings.Repeat("a", 1<<20)))
+		return
+	case "fail":
+		_, _ = os.Stderr.Write([]byte(strings.Repeat("a", 1<<16) + "last line"))
+		_ = json.NewEncoder(os.Stdout).Encode(NewError(8, "verify failed", "details"))
+		os.Exit(1)
+	}
+	workDir, _ := os.Getwd()
+	// only the sandboxed plugin probes its working directory, which is removed afterwards
+	writable := os.Getenv("RATIFY_TEST_COMMAND") == "write" && os.WriteFile(filepath.Join(workDir, "output"), []byte("output"), 0600) == nil
+	_ = json.NewEncoder(os.Stdout).Encode(sandboxReport{
+		Secret:   os.Getenv("TEST_PLUGIN_SECRET"),
+		Allowed:  os.Getenv("TEST_PLUGIN_ALLOWED"),
+		Command:  os.Getenv("RATIFY_TEST_COMMAND"),
+		WorkDir:  workDir,
+		Writable: writable,
+	})
+}
+
+func executeSandboxTestPlugin(t *testing.T, policy *ExecutionPolicy, command string) (sandboxReport, error) {
+	t.Helper()
+	t.Setenv(testPluginEnvKey, "sandbox")
+	t.Setenv("TEST_PLUGIN_SECRET", "secret")
+	t.Setenv("TEST_PLUGIN_ALLOWED", "allowed")
+	executor := &DefaultExecutor{Policy: policy}
+	stdout, err := executor.ExecutePlugin(context.Background(), os.Args[0], nil, nil, append(os.Environ(), "RATIFY_TEST_COMMAND="+command))
+	if err != nil || command == "flood" || command == "fail" {
+		return sandboxReport{}, err
+	}
+	report := sandboxReport{}
+	if err := json.Unmarshal(stdout, &report); err != nil {
+		t.Fatalf("failed to parse plugin output %s: %v", stdout, err)
+	}
+	return report, nil
+}
+
+func TestDefaultExecutor_NoPolicy(t *testing.T) {
+	report, err := executeSandboxTestPlugin(t, nil, "report")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	workDir, _ := os.Getwd()
+	if report.Secret != "secret" || report.Allowed != "allowed" || report.WorkDir != workDir {
+		t.Fatalf("expected the plugin to run with the environment of Ratify, got %+v", report)
+	}
+}
+
+func TestDefaultExecutor_ExecutionPolicy(t *testing.T) {
+	report, err := executeSandboxTestPlugin(t, &ExecutionPolicy{AllowedEnv: []string{"TEST_PLUGIN_ALLOWED"}}, "write")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Secret != "" || report.Allowed != "allowed" || report.Command != "write" {
+		t.Fatalf("expected only allowed and command variables to be passed, got %+v", report)
+	}
+	workDir, _ := os.Getwd()
+	if report.WorkDir == workDir || !strings.Contains(filepath.Base(report.WorkDir), "ratify-plugin-") {
+		t.Fatalf("expected the plugin to run in its own working directory, got %s", report.WorkDir)
+	}
+	// root can write to read-only directories
+	if report.Writable && os.Geteuid() != 0 {
+		t.Fatalf("expected the working directory to be read-only")
+	}
+	if _, err := os.Stat(report.WorkDir); !os.IsNotExist(err) {
+		t.Fatalf("expected the working directory to be removed, got %v", err)
+	}
+}
+
+func TestDefaultExecutor_MaxOutputSize(t *testing.T) {
+	_, err := executeSandboxTestPlugin(t, &ExecutionPolicy{MaxOutputSize: "1Ki"}, "flood")
+	var sizeErr *OutputSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Limit != 1024 {
+		t.Fatalf("expected output size error, got %v", err)
+	}
+
+	if _, err := executeSandboxTestPlugin(t, &ExecutionPolicy{MaxOutputSize: "2Mi"}, "flood"); err != nil {
+		t.Fatalf("expected output within the limit, got %v", err)
+	}
+}
+
+func TestDefaultExecutor_PluginError(t *testing.T) {
+	_, err := executeSandboxTestPlugin(t, nil, "fail")
+	var pluginErr *Error
+	if !errors.As(err, &pluginErr) {
+		t.Fatalf("expected plugin error, got %v", err)
+	}
+	if pluginErr.Code != 8 || pluginErr.Msg != "verify failed" || pluginErr.Details != "details" {
+		t.Fatalf("expected the error written by the plugin, got %+v", pluginErr)
+	}
+	if !strings.HasPrefix(pluginErr.Stderr, "...") || !strings.HasSuffix(pluginErr.Stderr, "last line") || len(pluginErr.Stderr) != MaxStderrTail+3 {
+		t.Fatalf("expected the tail of stderr, got %d bytes", len(pluginErr.Stderr))
+	}
+}
+
+func TestTailWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		expect string
+	}{
+		{
+			name:   "within size",
+			writes: []string{"ab", "cd"},
+			expect: "abcd",
+		},
+		{
+			name:   "truncated",
+			writes: []string{"abc", "def", "gh"},
+			expect: "...defgh",
+		},
+		{
+			name:   "truncated within a character",
+			writes: []string{"aéé", "bc"},
+			expect: "...ébc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &tailWriter{size: 5}
+			for _, write := range tt.writes {
+				_, _ = w.Write([]byte(write))
+			}
+			if tail := w.String(); tail != tt.expect {
+				t.Fatalf("expected %q, got %q", tt.expect, tail)
+			}
+		})
+	}
+}
+
+func TestParseExecutionPolicy(t *testing.T) {
+	testCases := []struct {
+		name      string
+		value     interface{}
+		expected  *ExecutionPolicy
+		expectErr bool
+	}{
+		{name: "not set", value: nil, expected: nil},
+		{
+			name:     "valid policy",
+			value:    map[string]interface{}{"allowedEnv": []interface{}{"HOME"}, "maxMemory": "512Mi", "maxCPUTime": "1500ms", "maxOutputSize": "1Mi"},
+			expected: &ExecutionPolicy{AllowedEnv: []string{"HOME"}, MaxMemory: "512Mi", MaxCPUTime: "1500ms", MaxOutputSize: "1Mi"},
+		},
+		{name: "invalid memory", value: map[string]interface{}{"maxMemory": "lots"}, expectErr: true},
+		{name: "invalid CPU time", value: map[string]interface{}{"maxCPUTime": "-1s"}, expectErr: true},
+		{name: "invalid output size", value: map[string]interface{}{"maxOutputSize": "0"}, expectErr: true},
+		{name: "relative cgroup", value: map[string]interface{}{"cgroup": "ratify/plugins"}, expectErr: true},
+		{name: "invalid type", value: "sandboxed", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := ParseExecutionPolicy(tc.value)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			expected, _ := json.Marshal(tc.expected)
+			actual, _ := json.Marshal(policy)
+			if string(expected) != string(actual) {
+				t.Fatalf("expected policy %s, got %s", expected, actual)
+			}
+		})
+	}
+
+	policy := &ExecutionPolicy{MaxCPUTime: "1500ms"}
+	if limits, _ := policy.limits(); limits.cpuSeconds != 2 || limits.outputSize != 64*1024*1024 {
+		t.Fatalf("unexpected limits %+v", limits)
+	}
+	if limits, _ := (*ExecutionPolicy)(nil).limits(); limits.sandboxed() || limits.outputSize != 0 {
+		t.Fatalf("expected no limits without a policy, got %+v", limits)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/config"
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+const (
+	// sourceStoreName is the store plugins verifying the signatures of a plugin artifact are configured with
+	sourceStoreName = "oras"
+	// maxSignatureBlobSize bounds the blobs of signatures read into memory
+	maxSignatureBlobSize = 4 * 1024 * 1024
+)
+
+// sourceStore is the referrer store of the repository of a plugin artifact, used to verify the signatures of the
+// artifact before the plugin is installed. Stores cannot be created here since their factories download plugins.
+type sourceStore struct {
+	repository *remote.Repository
+	source     PluginSource
+}
+
+var _ referrerstore.ReferrerStore = &sourceStore{}
+
+func (s *sourceStore) Name() string {
+	return sourceStoreName
+}
+
+// GetConfig returns the config of an ORAS store authenticating like the source, for verifiers executed as plugins
+func (s *sourceStore) GetConfig() *config.StoreConfig {
+	storeConfig := config.StorePluginConfig{"name": sourceStoreName}
+	if s.source.AuthProvider != nil {
+		storeConfig["authProvider"] = s.source.AuthProvider
+	}
+	return &config.StoreConfig{Version: "1.0.0", Store: storeConfig}
+}
+
+func (s *sourceStore) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	if subjectDesc == nil {
+		var err error
+		if subjectDesc, err = s.GetSubjectDescriptor(ctx, subjectReference); err != nil {
+			return referrerstore.ListReferrersResult{}, err
+		}
+	}
+	referrers, err := s.listReferrers(ctx, subjectDesc.Descriptor, artifactTypes)
+	if err != nil {
+		return referrerstore.ListReferrersResult{}, err
+	}
+	return referrerstore.ListReferrersResult{Referrers: referrers}, nil
+}
+
+// listReferrers lists the referrers of the subject and the cosign signature attached to it with a tag
+func (s *sourceStore) listReferrers(ctx context.Context, subjectDesc oci.Descriptor, artifactTypes []string) ([]ocispecs.ReferenceDescriptor, error) {
+	var referrers []ocispecs.ReferenceDescriptor
+	for _, artifactType := range referrerstore.ArtifactTypeFilters(artifactTypes) {
+		err := s.repository.Referrers(ctx, subjectDesc, artifactType, func(page []oci.Descriptor) error {
+			for _, referrer := range page {
+				referrers = append(referrers, ocispecs.ReferenceDescriptor{Descriptor: referrer, ArtifactType: referrer.ArtifactType})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if referrerstore.MatchesArtifactTypes(referrerstore.CosignSignatureArtifactType, artifactTypes) {
+		signatureTag := referrerstore.CosignAttachmentTag(subjectDesc.Digest, referrerstore.CosignSignatureTagSuffix)
+		desc, found, err := referrerstore.ResolveCosignAttachment(ctx, s.repository, fmt.Sprintf("%s/%s:%s", s.repository.Reference.Registry, s.repository.Reference.Repository, signatureTag))
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			referrers = append(referrers, ocispecs.ReferenceDescriptor{Descriptor: desc, ArtifactType: referrerstore.CosignSignatureArtifactType})
+		}
+	}
+	return referrers, nil
+}
+
+func (s *sourceStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+	desc, reader, err := s.fetchBlob(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	if desc.Size > maxSignatureBlobSize {
+		return nil, fmt.Errorf("blob %s of %d bytes is larger than %d bytes", digest, desc.Size, maxSignatureBlobSize)
+	}
+	return content.ReadAll(reader, desc)
+}
+
+func (s *sourceStore) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	desc, reader, err := s.fetchBlob(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+	if desc.Size > maxSignatureBlobSize {
+		reader.Close()
+		return nil, fmt.Errorf("blob %s of %d bytes is larger than %d bytes", digest, desc.Size, maxSignatureBlobSize)
+	}
+	return referrerstore.NewVerifyReader(reader, desc), nil
+}
+
+func (s *sourceStore) fetchBlob(ctx context.Context, digest digest.Digest) (oci.Descriptor, io.ReadCloser, error) {
+	return s.repository.Blobs().FetchReference(ctx, fmt.Sprintf("%s/%s@%s", s.repository.Reference.Registry, s.repository.Reference.Repository, digest))
+}
+
+func (s *sourceStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	if referenceDesc.Size > maxPluginManifestSize {
+		return ocispecs.ReferenceManifest{}, fmt.Errorf("manifest of %d bytes is larger than %d bytes", referenceDesc.Size, maxPluginManifestSize)
+	}
+	reader, err := s.repository.Fetch(ctx, referenceDesc.Descriptor)
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, err
+	}
+	defer reader.Close()
+	manifestBytes, err := content.ReadAll(reader, referenceDesc.Descriptor)
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, err
+	}
+	return parseReferenceManifest(referenceDesc.MediaType, manifestBytes)
+}
+
+func (s *sourceStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	desc, err := s.repository.Resolve(ctx, subjectReference.Original)
+	if err != nil {
+		return nil, err
+	}
+	return &ocispecs.SubjectDescriptor{Descriptor: desc}, nil
+}
@@ -26,6 +26,8 @@ type Error struct {
 	Code    uint   `json:"code"`
 	Msg     string `json:"msg"`
 	Details string `json:"details,omitempty"`
+	// Stderr is the tail of what the plugin wrote to stderr before failing, set by the executor and not by the plugin
+	Stderr string `json:"-"`
 }
 
 // NewError creates new Error
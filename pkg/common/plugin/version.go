@@ -0,0 +1,195 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// VersionCommand is answered by plugins built with the skeletons with their PluginInfo
+	VersionCommand = "VERSION"
+
+	// FeaturePersistent is reported by plugins that can run as persistent processes
+	FeaturePersistent = "persistent"
+	// FeatureGRPC is reported by plugins that can be served over gRPC
+	FeatureGRPC = "grpc"
+	// FeatureContentSocket is reported by verifier plugins that read content through the store of the host
+	FeatureContentSocket = "contentSocket"
+
+	// versionQueryTimeout bounds the VERSION command run when a plugin is configured
+	versionQueryTimeout = 10 * time.Second
+
+	// the errors of plugins that predate the VERSION command, which either reject it or miss its subject
+	errUnknownCommand              = 3
+	errMissingEnvironmentVariables = 4
+)
+
+// PluginInfo describes the versions and capabilities of a plugin
+type PluginInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// SupportedVersions are the spec versions the plugin accepts commands in
+	SupportedVersions []string `json:"supportedVersions"`
+	// ArtifactTypes are the artifact types a verifier plugin verifies, empty if not declared
+	ArtifactTypes []string `json:"artifactTypes,omitempty"`
+	// MediaTypes are the manifest media types the plugin handles, empty if not declared
+	MediaTypes []string `json:"mediaTypes,omitempty"`
+	// Features are the optional features of the plugin, e.g. FeaturePersistent
+	Features []string `json:"features,omitempty"`
+}
+
+// NewPluginInfo returns the info of a plugin built with a skeleton, which adds the features it implements
+func NewPluginInfo(name, version string, supportedVersions []string, options MainOptions, features ...string) PluginInfo {
+	if options.Persistent {
+		features = append(features, FeaturePersistent)
+	}
+	return PluginInfo{
+		Name:              name,
+		Version:           version,
+		SupportedVersions: supportedVersions,
+		ArtifactTypes:     options.ArtifactTypes,
+		MediaTypes:        options.MediaTypes,
+		Features:          append(features, options.Features...),
+	}
+}
+
+// Write writes the info as JSON to w
+func (info PluginInfo) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(info)
+}
+
+// HasFeature returns true if the plugin reports feature
+func (info PluginInfo) HasFeature(feature string) bool {
+	for _, f := range info.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// WithArtifactTypes declares the artifact types a verifier plugin verifies
+func WithArtifactTypes(artifactTypes ...string) MainOption {
+	return func(options *MainOptions) {
+		options.ArtifactTypes = append(options.ArtifactTypes, artifactTypes...)
+	}
+}
+
+// WithMediaTypes declares the manifest media types the plugin handles
+func WithMediaTypes(mediaTypes ...string) MainOption {
+	return func(options *MainOptions) {
+		options.MediaTypes = append(options.MediaTypes, mediaTypes...)
+	}
+}
+
+// WithFeatures declares optional features the plugin implements beyond the ones of the skeleton
+func WithFeatures(features ...string) MainOption {
+	return func(options *MainOptions) {
+		options.Features = append(options.Features, features...)
+	}
+}
+
+// NegotiateVersion runs the VERSION command of the plugin at pluginPath with environ, and returns the highest version
+// supported by the plugin that is compatible with the requested version along with the info of the plugin. Requests
+// keep the requested version if the plugin predates the command or cannot be queried, the returned info is nil then.
+func NegotiateVersion(ctx context.Context, executor Executor, pluginPath string, environ []string, requested string) (string, *PluginInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionQueryTimeout)
+	defer cancel()
+
+	stdout, err := executor.ExecutePlugin(ctx, pluginPath, nil, nil, environ)
+	if err != nil {
+		var pluginErr *Error
+		if errors.As(err, &pluginErr) && (pluginErr.Code == errUnknownCommand || pluginErr.Code == errMissingEnvironmentVariables) {
+			logrus.Warnf("plugin %s does not report its supported versions, requests use version %s", pluginPath, requested)
+		} else {
+			logrus.Warnf("failed to query the versions of plugin %s, requests use version %s: %v", pluginPath, requested, err)
+		}
+		return requested, nil, nil
+	}
+
+	info := &PluginInfo{}
+	if err := json.Unmarshal(stdout, info); err != nil {
+		return "", nil, fmt.Errorf("invalid output of the %s command of plugin %s: %w", VersionCommand, pluginPath, err)
+	}
+	version, err := HighestCompatibleVersion(requested, info.SupportedVersions)
+	if err != nil {
+		return "", nil, fmt.Errorf("plugin %s is not compatible: %w", pluginPath, err)
+	}
+	if version != requested {
+		logrus.Infof("plugin %s does not support version %s, requests use version %s", pluginPath, requested, version)
+	}
+	return version, info, nil
+}
+
+// HighestCompatibleVersion returns the highest of the supported versions with the major version of requested that is
+// not newer than requested
+func HighestCompatibleVersion(requested string, supported []string) (string, error) {
+	if err := ValidateVersion(requested); err != nil {
+		return "", err
+	}
+	highest := ""
+	for _, version := range supported {
+		if !semver.IsValid(canonicalVersion(version)) {
+			continue
+		}
+		if semver.Major(canonicalVersion(version)) != semver.Major(canonicalVersion(requested)) || semver.Compare(canonicalVersion(version), canonicalVersion(requested)) > 0 {
+			continue
+		}
+		if highest == "" || semver.Compare(canonicalVersion(version), canonicalVersion(highest)) > 0 {
+			highest = version
+		}
+	}
+	if highest == "" {
+		return "", fmt.Errorf("none of the supported versions [%s] is compatible with version %s", strings.Join(supported, ", "), requested)
+	}
+	return highest, nil
+}
+
+// ValidateVersion returns an error if version is not a semantic version
+func ValidateVersion(version string) error {
+	if !semver.IsValid(canonicalVersion(version)) {
+		return fmt.Errorf("invalid version %q: must be a semantic version", version)
+	}
+	return nil
+}
+
+// IsVersionSupported returns true if version equals one of the supported versions, comparing semantic versions
+// regardless of their precision, e.g. 1.0 and 1.0.0
+func IsVersionSupported(version string, supported []string) bool {
+	for _, v := range supported {
+		if v == version {
+			return true
+		}
+		if semver.IsValid(canonicalVersion(v)) && semver.IsValid(canonicalVersion(version)) && semver.Compare(canonicalVersion(v), canonicalVersion(version)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func canonicalVersion(version string) string {
+	return "v" + strings.TrimPrefix(version, "v")
+}
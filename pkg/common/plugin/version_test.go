@@ -0,0 +1,72 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+)
+
+func TestHighestCompatibleVersion(t *testing.T) {
+	testCases := []struct {
+		name      string
+		requested string
+		supported []string
+		expected  string
+		expectErr bool
+	}{
+		{name: "exact version", requested: "1.0.0", supported: []string{"1.0.0"}, expected: "1.0.0"},
+		{name: "highest common version", requested: "1.2.0", supported: []string{"0.1.0", "1.0.0", "1.1.0", "1.3.0"}, expected: "1.1.0"},
+		{name: "shorter version", requested: "1.0.0", supported: []string{"1.0"}, expected: "1.0"},
+		{name: "invalid supported versions ignored", requested: "1.0.0", supported: []string{"latest", "1.0.0"}, expected: "1.0.0"},
+		{name: "newer major version", requested: "1.0.0", supported: []string{"2.0.0"}, expectErr: true},
+		{name: "older major version", requested: "1.0.0", supported: []string{"0.1.0"}, expectErr: true},
+		{name: "no supported versions", requested: "1.0.0", expectErr: true},
+		{name: "invalid requested version", requested: "latest", supported: []string{"1.0.0"}, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			version, err := HighestCompatibleVersion(tc.requested, tc.supported)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if version != tc.expected {
+				t.Fatalf("expected version %s, got %s", tc.expected, version)
+			}
+		})
+	}
+}
+
+func TestIsVersionSupported(t *testing.T) {
+	testCases := []struct {
+		version   string
+		supported []string
+		expected  bool
+	}{
+		{version: "1.0.0", supported: []string{"1.0.0"}, expected: true},
+		{version: "1.0", supported: []string{"0.1.0", "1.0.0"}, expected: true},
+		{version: "v1.0.0", supported: []string{"1.0.0"}, expected: true},
+		{version: "1.1.0", supported: []string{"1.0.0"}, expected: false},
+		{version: "custom", supported: []string{"custom"}, expected: true},
+		{version: "custom", supported: []string{"other"}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		if actual := IsVersionSupported(tc.version, tc.supported); actual != tc.expected {
+			t.Fatalf("expected version %s supported by %v to be %v", tc.version, tc.supported, tc.expected)
+		}
+	}
+}
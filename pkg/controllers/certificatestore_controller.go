@@ -21,10 +21,14 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	configv1beta1 "github.com/deislabs/ratify/api/v1beta1"
 	"github.com/deislabs/ratify/pkg/certificateprovider"
 	_ "github.com/deislabs/ratify/pkg/certificateprovider/azurekeyvault"
+	_ "github.com/deislabs/ratify/pkg/certificateprovider/filesystem"
+	_ "github.com/deislabs/ratify/pkg/certificateprovider/hashicorpvault"
 	_ "github.com/deislabs/ratify/pkg/certificateprovider/inline"
 	"github.com/deislabs/ratify/pkg/utils"
 
@@ -34,18 +38,38 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // CertificateStoreReconciler reconciles a CertificateStore object
 type CertificateStoreReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// certStoreEvents triggers a reconcile when a watchable certificate provider reports a change
+	certStoreEvents chan event.GenericEvent
 }
 
+// CertificateStoreSubscriber is invoked whenever the certificates of a CertificateStore are updated.
+// A nil certificates slice indicates that the CertificateStore has been removed.
+type CertificateStoreSubscriber func(storeName string, certificates []*x509.Certificate)
+
 var (
 	// a map between CertificateStore name to array of x509 certificates
 	certificatesMap = map[string][]*x509.Certificate{}
+	// subscribers notified on certificate store updates
+	certStoreSubscribers []CertificateStoreSubscriber
+	certificatesMapLock  sync.RWMutex
+	// serializes the notifications of subscribers, which are made without holding certificatesMapLock so that
+	// subscribers can read the certificates map
+	certStoreNotifyLock sync.Mutex
+
+	// a map between CertificateStore name and the cancel function of its provider watcher
+	certStoreWatchers     = map[string]context.CancelFunc{}
+	certStoreWatchersLock sync.Mutex
 )
 
 //+kubebuilder:rbac:groups=config.ratify.deislabs.io,resources=certificatestores,verbs=get;list;watch;create;update;patch;delete
@@ -71,7 +95,8 @@ func (r *CertificateStoreReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	if err := r.Get(ctx, req.NamespacedName, &certStore); err != nil {
 		if apierrors.IsNotFound(err) {
 			logger.Infof("deletion detected, removing certificate store %v", req.Name)
-			delete(certificatesMap, resource)
+			stopWatchingCertificates(resource)
+			setCertificates(resource, nil)
 		} else {
 			logger.Error(err, "unable to fetch certificate store")
 		}
@@ -85,36 +110,99 @@ func (r *CertificateStoreReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	isFetchSuccessful := false
 
 	if err != nil {
-		writeCertStoreStatus(r, ctx, certStore, logger, isFetchSuccessful, err.Error(), lastFetchedTime, nil)
+		writeCertStoreStatus(r, ctx, certStore, logger, isFetchSuccessful, err.Error(), lastFetchedTime, nil, 0)
+		return ctrl.Result{}, err
+	}
+
+	refreshInterval, err := getRefreshInterval(certStore.Spec)
+	if err != nil {
+		writeCertStoreStatus(r, ctx, certStore, logger, isFetchSuccessful, err.Error(), lastFetchedTime, nil, 0)
 		return ctrl.Result{}, err
 	}
 
 	provider, err := getCertificateProvider(certificateprovider.GetCertificateProviders(), certStore.Spec.Provider)
 	if err != nil {
-		writeCertStoreStatus(r, ctx, certStore, logger, isFetchSuccessful, err.Error(), lastFetchedTime, nil)
+		writeCertStoreStatus(r, ctx, certStore, logger, isFetchSuccessful, err.Error(), lastFetchedTime, nil, 0)
 		return ctrl.Result{}, err
 	}
 
+	// on failure the last successfully fetched certificates keep being served, the error is returned so the fetch is retried with backoff
 	certificates, certAttributes, err := provider.GetCertificates(ctx, attributes)
 	if err != nil {
-		writeCertStoreStatus(r, ctx, certStore, logger, isFetchSuccessful, err.Error(), lastFetchedTime, nil)
+		writeCertStoreStatus(r, ctx, certStore, logger, isFetchSuccessful, err.Error(), lastFetchedTime, nil, 0)
 		return ctrl.Result{}, fmt.Errorf("Error fetching certificates in store %v with %v provider, error: %w", resource, certStore.Spec.Provider, err)
 	}
 
-	certificatesMap[resource] = certificates
+	setCertificates(resource, certificates)
+	updateExpiryTime(&certStore, certificates)
 	isFetchSuccessful = true
 	emptyErrorString := ""
-	writeCertStoreStatus(r, ctx, certStore, logger, isFetchSuccessful, emptyErrorString, lastFetchedTime, certAttributes)
+	writeCertStoreStatus(r, ctx, certStore, logger, isFetchSuccessful, emptyErrorString, lastFetchedTime, certAttributes, refreshInterval)
 
 	logger.Infof("%v certificates fetched for certificate store %v", len(certificates), resource)
 
+	// the provider watcher is replaced with one using the latest parameters only once they fetched certificates, a
+	// failed reconcile keeps the previous watcher
+	r.watchCertificates(certStore, provider, attributes)
+
+	if refreshInterval > 0 {
+		// certificates are fetched again after the interval to pick up rotated versions
+		return ctrl.Result{RequeueAfter: refreshInterval}, nil
+	}
+
 	// returning empty result and no error to indicate we’ve successfully reconciled this object
 	return ctrl.Result{}, nil
 }
 
-// returns the internal certificate map
+// returns a snapshot of the internal certificate map
 func GetCertificatesMap() map[string][]*x509.Certificate {
-	return certificatesMap
+	certificatesMapLock.RLock()
+	defer certificatesMapLock.RUnlock()
+
+	snapshot := make(map[string][]*x509.Certificate, len(certificatesMap))
+	for name, certs := range certificatesMap {
+		snapshot[name] = certs
+	}
+	return snapshot
+}
+
+// SubscribeCertificateStoreUpdates registers a subscriber that is notified on every certificate store update.
+// The subscriber is invoked immediately for every certificate store already loaded.
+func SubscribeCertificateStoreUpdates(subscriber CertificateStoreSubscriber) {
+	certStoreNotifyLock.Lock()
+	defer certStoreNotifyLock.Unlock()
+
+	certificatesMapLock.Lock()
+	certStoreSubscribers = append(certStoreSubscribers, subscriber)
+	snapshot := make(map[string][]*x509.Certificate, len(certificatesMap))
+	for name, certs := range certificatesMap {
+		snapshot[name] = certs
+	}
+	certificatesMapLock.Unlock()
+
+	for name, certs := range snapshot {
+		subscriber(name, certs)
+	}
+}
+
+// setCertificates updates the certificates of a store and notifies subscribers, nil certificates removes the store
+func setCertificates(storeName string, certificates []*x509.Certificate) {
+	certStoreNotifyLock.Lock()
+	defer certStoreNotifyLock.Unlock()
+
+	certificatesMapLock.Lock()
+	if certificates == nil {
+		delete(certificatesMap, storeName)
+	} else {
+		certificatesMap[storeName] = certificates
+	}
+	subscribers := make([]CertificateStoreSubscriber, len(certStoreSubscribers))
+	copy(subscribers, certStoreSubscribers)
+	certificatesMapLock.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber(storeName, certificates)
+	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -124,11 +212,52 @@ func (r *CertificateStoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// status updates will trigger a reconcile event
 	// if there are no changes to spec of CRD, this event should be filtered out by using the predicate
 	// see more discussions at https://github.com/kubernetes-sigs/kubebuilder/issues/618
+	r.certStoreEvents = make(chan event.GenericEvent)
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1beta1.CertificateStore{}).WithEventFilter(pred).
+		Watches(&source.Channel{Source: r.certStoreEvents}, &handler.EnqueueRequestForObject{}).
 		Complete(r)
 }
 
+// watchCertificates requeues the certificate store whenever a watchable provider reports a change of its certificates
+func (r *CertificateStoreReconciler) watchCertificates(certStore configv1beta1.CertificateStore, provider certificateprovider.CertificateProvider, attributes map[string]string) {
+	watchableProvider, ok := provider.(certificateprovider.WatchableCertificateProvider)
+	if !ok || r.certStoreEvents == nil {
+		stopWatchingCertificates(certStore.Name)
+		return
+	}
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	notify := func() {
+		select {
+		case r.certStoreEvents <- event.GenericEvent{Object: certStore.DeepCopy()}:
+		case <-watchCtx.Done():
+		}
+	}
+	if err := watchableProvider.Watch(watchCtx, attributes, notify); err != nil {
+		cancel()
+		logrus.Warnf("unable to watch certificates of certificate store %v, changes will only be picked up on update, error: %v", certStore.Name, err)
+		return
+	}
+
+	certStoreWatchersLock.Lock()
+	defer certStoreWatchersLock.Unlock()
+	if previousCancel, ok := certStoreWatchers[certStore.Name]; ok {
+		previousCancel()
+	}
+	certStoreWatchers[certStore.Name] = cancel
+}
+
+// stopWatchingCertificates stops the provider watcher of the certificate store if any
+func stopWatchingCertificates(storeName string) {
+	certStoreWatchersLock.Lock()
+	defer certStoreWatchersLock.Unlock()
+	if cancel, ok := certStoreWatchers[storeName]; ok {
+		cancel()
+		delete(certStoreWatchers, storeName)
+	}
+}
+
 func getCertStoreConfig(spec configv1beta1.CertificateStoreSpec) (map[string]string, error) {
 	attributes := map[string]string{}
 
@@ -144,9 +273,26 @@ func getCertStoreConfig(spec configv1beta1.CertificateStoreSpec) (map[string]str
 	return attributes, nil
 }
 
-func writeCertStoreStatus(r *CertificateStoreReconciler, ctx context.Context, certStore configv1beta1.CertificateStore, logger *logrus.Entry, isSuccess bool, errorString string, operationTime metav1.Time, certStatus certificateprovider.CertificatesStatus) {
+// getRefreshInterval returns the refresh interval of the certificate store, zero if certificates are not refreshed periodically
+func getRefreshInterval(spec configv1beta1.CertificateStoreSpec) (time.Duration, error) {
+	if spec.RefreshInterval == "" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(spec.RefreshInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid refreshInterval '%v', error: %w", spec.RefreshInterval, err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("refreshInterval '%v' must be a positive duration", spec.RefreshInterval)
+	}
+	return interval, nil
+}
+
+func writeCertStoreStatus(r *CertificateStoreReconciler, ctx context.Context, certStore configv1beta1.CertificateStore, logger *logrus.Entry, isSuccess bool, errorString string, operationTime metav1.Time, certStatus certificateprovider.CertificatesStatus, refreshInterval time.Duration) {
 	if isSuccess {
 		updateSuccessStatus(&certStore, &operationTime, certStatus)
+		updateNextRefreshTime(&certStore, operationTime, refreshInterval)
 	} else {
 		updateErrorStatus(&certStore, errorString, &operationTime)
 	}
@@ -159,6 +305,7 @@ func updateErrorStatus(certStore *configv1beta1.CertificateStore, errorString st
 	certStore.Status.IsSuccess = false
 	certStore.Status.Error = errorString
 	certStore.Status.LastFetchedTime = operationTime
+	certStore.Status.NextRefreshTime = nil
 }
 
 func updateSuccessStatus(certStore *configv1beta1.CertificateStore, lastOperationTime *metav1.Time, certStatus certificateprovider.CertificatesStatus) {
@@ -176,6 +323,28 @@ func updateSuccessStatus(certStore *configv1beta1.CertificateStore, lastOperatio
 	}
 }
 
+// updateExpiryTime records the expiry of the certificate that expires first
+func updateExpiryTime(certStore *configv1beta1.CertificateStore, certificates []*x509.Certificate) {
+	var expiryTime *metav1.Time
+	for _, cert := range certificates {
+		if expiryTime == nil || cert.NotAfter.Before(expiryTime.Time) {
+			notAfter := metav1.NewTime(cert.NotAfter)
+			expiryTime = &notAfter
+		}
+	}
+	certStore.Status.ExpiryTime = expiryTime
+}
+
+// updateNextRefreshTime records when certificates will be fetched again, if the store is refreshed periodically
+func updateNextRefreshTime(certStore *configv1beta1.CertificateStore, lastOperationTime metav1.Time, refreshInterval time.Duration) {
+	if refreshInterval <= 0 {
+		certStore.Status.NextRefreshTime = nil
+		return
+	}
+	nextRefreshTime := metav1.NewTime(lastOperationTime.Add(refreshInterval))
+	certStore.Status.NextRefreshTime = &nextRefreshTime
+}
+
 // given the name of the target provider, returns the provider from the providers map
 func getCertificateProvider(providers map[string]certificateprovider.CertificateProvider, providerName string) (certificateprovider.CertificateProvider, error) {
 	providerName = utils.TrimSpaceAndToLower(providerName)
@@ -16,7 +16,11 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
+	"crypto/x509"
+	"errors"
 	"testing"
+	"time"
 
 	configv1beta1 "github.com/deislabs/ratify/api/v1beta1"
 	"github.com/deislabs/ratify/pkg/certificateprovider"
@@ -24,6 +28,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 func TestGetCertStoreConfig_ValidConfig(t *testing.T) {
@@ -164,6 +169,89 @@ func TestUpdateSuccessStatus_emptyProperties(t *testing.T) {
 	}
 }
 
+func TestGetRefreshInterval(t *testing.T) {
+	testCases := []struct {
+		name             string
+		refreshInterval  string
+		expectedInterval time.Duration
+		expectedErr      bool
+	}{
+		{
+			name:             "not set",
+			refreshInterval:  "",
+			expectedInterval: 0,
+		},
+		{
+			name:             "valid interval",
+			refreshInterval:  "1h30m",
+			expectedInterval: 90 * time.Minute,
+		},
+		{
+			name:            "invalid interval",
+			refreshInterval: "hourly",
+			expectedErr:     true,
+		},
+		{
+			name:            "negative interval",
+			refreshInterval: "-1h",
+			expectedErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			interval, err := getRefreshInterval(configv1beta1.CertificateStoreSpec{RefreshInterval: tc.refreshInterval})
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if interval != tc.expectedInterval {
+				t.Fatalf("expected interval %v, got %v", tc.expectedInterval, interval)
+			}
+		})
+	}
+}
+
+func TestUpdateNextRefreshTime(t *testing.T) {
+	lastFetchedTime := metav1.Now()
+	certStore := configv1beta1.CertificateStore{}
+
+	updateNextRefreshTime(&certStore, lastFetchedTime, time.Hour)
+	if certStore.Status.NextRefreshTime == nil || !certStore.Status.NextRefreshTime.Time.Equal(lastFetchedTime.Add(time.Hour)) {
+		t.Fatalf("expected next refresh time %v, got %v", lastFetchedTime.Add(time.Hour), certStore.Status.NextRefreshTime)
+	}
+
+	// a failed refresh clears the next refresh time since the fetch is retried with backoff
+	updateErrorStatus(&certStore, "error from unit test", &lastFetchedTime)
+	if certStore.Status.NextRefreshTime != nil {
+		t.Fatalf("expected next refresh time to be cleared, got %v", certStore.Status.NextRefreshTime)
+	}
+
+	updateNextRefreshTime(&certStore, lastFetchedTime, 0)
+	if certStore.Status.NextRefreshTime != nil {
+		t.Fatalf("expected no next refresh time, got %v", certStore.Status.NextRefreshTime)
+	}
+}
+
+func TestUpdateExpiryTime(t *testing.T) {
+	now := time.Now()
+	certificates := []*x509.Certificate{
+		{NotAfter: now.Add(48 * time.Hour)},
+		{NotAfter: now.Add(time.Hour)},
+		{NotAfter: now.Add(24 * time.Hour)},
+	}
+	certStore := configv1beta1.CertificateStore{}
+
+	updateExpiryTime(&certStore, certificates)
+	if certStore.Status.ExpiryTime == nil || !certStore.Status.ExpiryTime.Time.Equal(now.Add(time.Hour)) {
+		t.Fatalf("expected expiry time %v, got %v", now.Add(time.Hour), certStore.Status.ExpiryTime)
+	}
+
+	updateExpiryTime(&certStore, nil)
+	if certStore.Status.ExpiryTime != nil {
+		t.Fatalf("expected no expiry time, got %v", certStore.Status.ExpiryTime)
+	}
+}
+
 func TestGetCertificateProvider(t *testing.T) {
 	providers := map[string]certificateprovider.CertificateProvider{}
 	providers["inline"] = inline.Create()
@@ -178,3 +266,84 @@ func TestGetCertificateProvider(t *testing.T) {
 		t.Fatalf("Getting unregistered provider should returns an error")
 	}
 }
+
+func TestSubscribeCertificateStoreUpdates(t *testing.T) {
+	setCertificates("existingStore", []*x509.Certificate{{}})
+	defer setCertificates("existingStore", nil)
+
+	received := map[string][]*x509.Certificate{}
+	consistent := true
+	SubscribeCertificateStoreUpdates(func(storeName string, certificates []*x509.Certificate) {
+		// subscribers are notified without holding the lock of the certificates map
+		if _, ok := GetCertificatesMap()[storeName]; ok != (certificates != nil) {
+			consistent = false
+		}
+		received[storeName] = certificates
+	})
+
+	if len(received["existingStore"]) != 1 {
+		t.Fatalf("Expected subscriber to be invoked for existing certificate stores")
+	}
+
+	setCertificates("newStore", []*x509.Certificate{{}, {}})
+	if len(received["newStore"]) != 2 {
+		t.Fatalf("Expected subscriber to receive 2 certificates, actual %+v", len(received["newStore"]))
+	}
+
+	setCertificates("newStore", nil)
+	if certs, ok := received["newStore"]; !ok || certs != nil {
+		t.Fatalf("Expected subscriber to be notified of certificate store removal")
+	}
+
+	if _, ok := GetCertificatesMap()["newStore"]; ok {
+		t.Fatalf("Expected newStore to be removed from certificates map")
+	}
+
+	if !consistent {
+		t.Fatalf("Expected the certificates map to be updated before subscribers are notified")
+	}
+}
+
+type testWatchableProvider struct {
+	watchErr error
+	ctx      context.Context
+}
+
+func (p *testWatchableProvider) GetCertificates(ctx context.Context, attrib map[string]string) ([]*x509.Certificate, certificateprovider.CertificatesStatus, error) {
+	return nil, nil, nil
+}
+
+func (p *testWatchableProvider) Watch(ctx context.Context, attrib map[string]string, notify func()) error {
+	p.ctx = ctx
+	return p.watchErr
+}
+
+func TestWatchCertificates_ReplacedOnlyOnSuccess(t *testing.T) {
+	r := &CertificateStoreReconciler{certStoreEvents: make(chan event.GenericEvent)}
+	certStore := configv1beta1.CertificateStore{ObjectMeta: metav1.ObjectMeta{Name: "watchedStore"}}
+	defer stopWatchingCertificates(certStore.Name)
+
+	first := &testWatchableProvider{}
+	r.watchCertificates(certStore, first, nil)
+	if first.ctx == nil || first.ctx.Err() != nil {
+		t.Fatalf("Expected the certificates to be watched")
+	}
+
+	// a failed watch keeps the previous watcher
+	r.watchCertificates(certStore, &testWatchableProvider{watchErr: errors.New("watch failed")}, nil)
+	if first.ctx.Err() != nil {
+		t.Fatalf("Expected the previous watcher to keep running")
+	}
+
+	second := &testWatchableProvider{}
+	r.watchCertificates(certStore, second, nil)
+	if first.ctx.Err() == nil || second.ctx.Err() != nil {
+		t.Fatalf("Expected the previous watcher to be replaced")
+	}
+
+	// a provider that cannot be watched stops the previous watcher
+	r.watchCertificates(certStore, inline.Create(), nil)
+	if second.ctx.Err() == nil {
+		t.Fatalf("Expected the previous watcher to be stopped")
+	}
+}
@@ -19,17 +19,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	configv1beta1 "github.com/deislabs/ratify/api/v1beta1"
 	"github.com/deislabs/ratify/config"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
 	"github.com/deislabs/ratify/pkg/referrerstore"
 	rc "github.com/deislabs/ratify/pkg/referrerstore/config"
 	sf "github.com/deislabs/ratify/pkg/referrerstore/factory"
+	"github.com/deislabs/ratify/pkg/referrerstore/orchestrator"
 	"github.com/deislabs/ratify/pkg/referrerstore/types"
 	"github.com/sirupsen/logrus"
 )
@@ -43,6 +47,10 @@ type StoreReconciler struct {
 var (
 	// a map to track active stores
 	StoreMap = map[string]referrerstore.ReferrerStore{}
+
+	// status updates will trigger a reconcile event, the store and its plugin are only created again on changes to
+	// the spec of CRD
+	storeEventFilter predicate.Predicate = predicate.GenerationChangedPredicate{}
 )
 
 //+kubebuilder:rbac:groups=config.ratify.deislabs.io,resources=stores,verbs=get;list;watch;create;update;patch;delete
@@ -74,9 +82,12 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 
 	if err := storeAddOrReplace(store.Spec, resource); err != nil {
 		storeLogger.Error(err, "unable to create store from store crd")
+		writeStoreStatus(ctx, r, store, storeLogger, false, err.Error())
 		return ctrl.Result{}, err
 	}
 
+	writeStoreStatus(ctx, r, store, storeLogger, true, "")
+
 	// returning empty result and no error to indicate we’ve successfully reconciled this object
 	return ctrl.Result{}, nil
 }
@@ -84,7 +95,7 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 // SetupWithManager sets up the controller with the Manager.
 func (r *StoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&configv1beta1.Store{}).
+		For(&configv1beta1.Store{}).WithEventFilter(storeEventFilter).
 		Complete(r)
 }
 
@@ -100,6 +111,9 @@ func storeAddOrReplace(spec configv1beta1.StoreSpec, fullname string) error {
 	if spec.Address == "" {
 		spec.Address = config.GetDefaultPluginPath()
 		logrus.Infof("Address was empty, setting to default path %v", spec.Address)
+	} else if grpcplugin.IsAddress(spec.Address) {
+		// the plugin is served at the address set in the config, plugin binaries are still looked up in the default path
+		spec.Address = config.GetDefaultPluginPath()
 	}
 	storeReference, err := sf.CreateStoreFromConfig(storeConfig, storeConfigVersion, []string{spec.Address})
 
@@ -108,14 +122,46 @@ func storeAddOrReplace(spec configv1beta1.StoreSpec, fullname string) error {
 		return fmt.Errorf("store factory failed to create store from store config, err: %w", err)
 	}
 
+	storeRemove(fullname)
 	StoreMap[fullname] = storeReference
 	logrus.Infof("store '%v' added to store map", storeReference.Name())
 
 	return nil
 }
 
+// writeStoreStatus reports whether the store, and the plugin downloaded from its source, could be created
+func writeStoreStatus(ctx context.Context, r client.StatusClient, store configv1beta1.Store, logger *logrus.Entry, isSuccess bool, errorString string) {
+	if isSuccess {
+		updateStoreSuccessStatus(&store)
+	} else {
+		updateStoreErrorStatus(&store, errorString)
+	}
+	if statusErr := r.Status().Update(ctx, &store); statusErr != nil {
+		logger.Error(statusErr, ", unable to update store status")
+	}
+}
+
+func updateStoreErrorStatus(store *configv1beta1.Store, errorString string) {
+	store.Status.IsSuccess = false
+	store.Status.Error = errorString
+}
+
+func updateStoreSuccessStatus(store *configv1beta1.Store) {
+	store.Status.IsSuccess = true
+	store.Status.Error = ""
+}
+
 // Remove store from map
 func storeRemove(resourceName string) {
+	if store, ok := StoreMap[resourceName]; ok {
+		orchestrator.StopShared(store)
+	}
+	// stores served over gRPC hold a connection to the plugin
+	if closer, ok := StoreMap[resourceName].(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logrus.Warnf("failed to close store %s: %v", resourceName, err)
+		}
+	}
 	delete(StoreMap, resourceName)
 }
 
@@ -133,6 +179,9 @@ func specToStoreConfig(storeSpec configv1beta1.StoreSpec) (rc.StorePluginConfig,
 	if storeSpec.Source != nil {
 		storeConfig[types.Source] = storeSpec.Source
 	}
+	if grpcplugin.IsAddress(storeSpec.Address) {
+		storeConfig[types.Address] = storeSpec.Address
+	}
 
 	return storeConfig, nil
 }
@@ -16,11 +16,20 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	configv1beta1 "github.com/deislabs/ratify/api/v1beta1"
+	"github.com/deislabs/ratify/pkg/featureflag"
 	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/plugin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 func TestStoreAdd_EmptyParameter(t *testing.T) {
@@ -53,6 +62,26 @@ func TestStoreAdd_WithParameters(t *testing.T) {
 	}
 }
 
+func TestStoreAdd_GRPCAddress(t *testing.T) {
+	resetStoreMap()
+	var testStoreSpec = configv1beta1.StoreSpec{
+		Name:    "custom-store",
+		Address: "grpc://localhost:9000",
+	}
+
+	if err := storeAddOrReplace(testStoreSpec, "custom-store"); err != nil {
+		t.Fatalf("storeAddOrReplace() expected no error, actual %v", err)
+	}
+	if _, ok := StoreMap["custom-store"].(*plugin.GRPCStore); !ok {
+		t.Fatalf("expected store served over gRPC, actual %T", StoreMap["custom-store"])
+	}
+
+	storeRemove("custom-store")
+	if len(StoreMap) != 0 {
+		t.Fatalf("Store map should be 0 after deletion, actual %v", len(StoreMap))
+	}
+}
+
 func TestStore_UpdateAndDelete(t *testing.T) {
 	resetStoreMap()
 	// add a Store
@@ -89,6 +118,117 @@ func TestStore_UpdateAndDelete(t *testing.T) {
 	}
 }
 
+func TestStoreReconcile_Status(t *testing.T) {
+	dynamicPlugins := featureflag.DynamicPlugins.Enabled
+	featureflag.DynamicPlugins.Enabled = true
+	defer func() {
+		featureflag.DynamicPlugins.Enabled = dynamicPlugins
+	}()
+
+	tests := []struct {
+		name          string
+		spec          configv1beta1.StoreSpec
+		expectSuccess bool
+		expectError   string
+	}{
+		{
+			name:          "store created",
+			spec:          configv1beta1.StoreSpec{Name: "oras"},
+			expectSuccess: true,
+		},
+		{
+			name: "plugin source rejected",
+			spec: configv1beta1.StoreSpec{
+				Name: "custom-store",
+				Source: &configv1beta1.PluginSource{
+					Artifact: "localhost:5000/custom-store:v1",
+					Digest:   "invalid",
+				},
+			},
+			expectError: "invalid digest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetStoreMap()
+			scheme := runtime.NewScheme()
+			if err := configv1beta1.AddToScheme(scheme); err != nil {
+				t.Fatalf("failed to build scheme: %v", err)
+			}
+			store := &configv1beta1.Store{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-store"},
+				Spec:       tt.spec,
+				// a previous failure is cleared once the store is created
+				Status: configv1beta1.StoreStatus{Error: "previous error"},
+			}
+			r := &StoreReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(store).Build(),
+				Scheme: scheme,
+			}
+
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-store"}})
+			if (err == nil) != tt.expectSuccess {
+				t.Fatalf("expected success %v, got error %v", tt.expectSuccess, err)
+			}
+
+			var reconciled configv1beta1.Store
+			if err := r.Get(context.Background(), types.NamespacedName{Name: "test-store"}, &reconciled); err != nil {
+				t.Fatalf("failed to get store: %v", err)
+			}
+			if reconciled.Status.IsSuccess != tt.expectSuccess {
+				t.Fatalf("expected isSuccess %v, got %+v", tt.expectSuccess, reconciled.Status)
+			}
+			if tt.expectSuccess && reconciled.Status.Error != "" || !strings.Contains(reconciled.Status.Error, tt.expectError) {
+				t.Fatalf("expected error %q in the status, got %+v", tt.expectError, reconciled.Status)
+			}
+		})
+	}
+}
+
+func TestStoreReconcile_StatusUpdateFiltered(t *testing.T) {
+	resetStoreMap()
+	scheme := runtime.NewScheme()
+	if err := configv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	store := &configv1beta1.Store{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-store", Generation: 1},
+		Spec:       configv1beta1.StoreSpec{Name: "oras"},
+	}
+	r := &StoreReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(store).Build(),
+		Scheme: scheme,
+	}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-store"}}
+
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	created := StoreMap["test-store"]
+
+	// the status written by the reconcile triggers an update event of the store
+	var updated configv1beta1.Store
+	if err := r.Get(context.Background(), request.NamespacedName, &updated); err != nil {
+		t.Fatalf("failed to get store: %v", err)
+	}
+	if storeEventFilter.Update(event.UpdateEvent{ObjectOld: store, ObjectNew: &updated}) {
+		if _, err := r.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if StoreMap["test-store"] != created {
+		t.Fatalf("expected a status update not to create the store again")
+	}
+
+	// a spec update creates the store again
+	specUpdated := updated.DeepCopy()
+	specUpdated.Generation++
+	if !storeEventFilter.Update(event.UpdateEvent{ObjectOld: &updated, ObjectNew: specUpdated}) {
+		t.Fatalf("expected a spec update to be reconciled")
+	}
+}
+
 func resetStoreMap() {
 	StoreMap = map[string]referrerstore.ReferrerStore{}
 }
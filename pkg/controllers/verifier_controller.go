@@ -19,9 +19,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 
 	configv1beta1 "github.com/deislabs/ratify/api/v1beta1"
 	"github.com/deislabs/ratify/config"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
 	vr "github.com/deislabs/ratify/pkg/verifier"
 	vc "github.com/deislabs/ratify/pkg/verifier/config"
 	vf "github.com/deislabs/ratify/pkg/verifier/factory"
@@ -99,6 +101,9 @@ func verifierAddOrReplace(spec configv1beta1.VerifierSpec, objectName string) er
 	if spec.Address == "" {
 		spec.Address = config.GetDefaultPluginPath()
 		logrus.Infof("Address was empty, setting to default path: %v", spec.Address)
+	} else if grpcplugin.IsAddress(spec.Address) {
+		// the plugin is served at the address set in the config, plugin binaries are still looked up in the default path
+		spec.Address = config.GetDefaultPluginPath()
 	}
 	verifierReference, err := vf.CreateVerifierFromConfig(verifierConfig, verifierConfigVersion, []string{spec.Address})
 
@@ -106,6 +111,7 @@ func verifierAddOrReplace(spec configv1beta1.VerifierSpec, objectName string) er
 		logrus.Error(err, "unable to create verifier from verifier config")
 		return err
 	}
+	verifierRemove(objectName)
 	VerifierMap[objectName] = verifierReference
 	logrus.Infof("verifier '%v' added to verifier map", verifierReference.Name())
 
@@ -114,6 +120,12 @@ func verifierAddOrReplace(spec configv1beta1.VerifierSpec, objectName string) er
 
 // remove verifier from map
 func verifierRemove(objectName string) {
+	// verifiers served over gRPC hold a connection to the plugin
+	if closer, ok := VerifierMap[objectName].(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logrus.Warnf("failed to close verifier %s: %v", objectName, err)
+		}
+	}
 	delete(VerifierMap, objectName)
 }
 
@@ -133,6 +145,9 @@ func specToVerifierConfig(verifierSpec configv1beta1.VerifierSpec) (vc.VerifierC
 	if verifierSpec.Source != nil {
 		verifierConfig[types.Source] = verifierSpec.Source
 	}
+	if grpcplugin.IsAddress(verifierSpec.Address) {
+		verifierConfig[types.Address] = verifierSpec.Address
+	}
 
 	return verifierConfig, nil
 }
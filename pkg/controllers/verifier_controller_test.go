@@ -21,6 +21,7 @@ import (
 
 	configv1beta1 "github.com/deislabs/ratify/api/v1beta1"
 	vr "github.com/deislabs/ratify/pkg/verifier"
+	"github.com/deislabs/ratify/pkg/verifier/plugin"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -96,6 +97,24 @@ func TestVerifier_UpdateAndDelete(t *testing.T) {
 	}
 }
 
+func TestVerifierAdd_GRPCAddress(t *testing.T) {
+	resetVerifierMap()
+	var testVerifierSpec = getDefaultLicenseCheckerSpec()
+	testVerifierSpec.Address = "unix:///var/run/ratify/licensechecker.sock"
+
+	if err := verifierAddOrReplace(testVerifierSpec, "licensechecker"); err != nil {
+		t.Fatalf("verifierAddOrReplace() expected no error, actual %v", err)
+	}
+	if _, ok := VerifierMap["licensechecker"].(*plugin.GRPCVerifier); !ok {
+		t.Fatalf("expected verifier served over gRPC, actual %T", VerifierMap["licensechecker"])
+	}
+
+	verifierRemove("licensechecker")
+	if len(VerifierMap) != 0 {
+		t.Fatalf("Verifier map should be 0 after deletion, actual %v", len(VerifierMap))
+	}
+}
+
 func resetVerifierMap() {
 	VerifierMap = map[string]vr.ReferenceVerifier{}
 }
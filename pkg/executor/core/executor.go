@@ -155,7 +155,8 @@ func (ex Executor) verifyReference(ctx context.Context, subjectRef common.Refere
 				verifyResult = vr.VerifierResult{
 					IsSuccess: false,
 					Name:      verifier.Name(),
-					Message:   fmt.Sprintf("an error thrown by the verifier: %v", err)}
+					Message:   fmt.Sprintf("an error thrown by the verifier: %v", err),
+					Error:     verifyResult.Error}
 			}
 
 			if len(verifier.GetNestedReferences()) > 0 {
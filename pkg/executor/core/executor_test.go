@@ -18,6 +18,7 @@ package core
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -207,6 +208,49 @@ func TestVerifySubject_VerifyFailures_ExpectedResults(t *testing.T) {
 	}
 }
 
+func TestVerifySubject_PluginError_ExpectedResults(t *testing.T) {
+	testDigest := digest.FromString("test")
+	store := &mocks.TestStore{References: []ocispecs.ReferenceDescriptor{
+		{
+			ArtifactType: "test-type1",
+		}},
+		ResolveMap: map[string]digest.Digest{
+			"v1": testDigest,
+		},
+	}
+	pluginError := &verifier.PluginError{Code: 8, Message: "plugin command VERIFY failed", Stderr: "panic: oops"}
+	ver := &TestVerifier{
+		CanVerifyFunc: func(at string) bool {
+			return true
+		},
+		pluginError: pluginError,
+	}
+
+	ex := &Executor{
+		PolicyEnforcer: config.PolicyEnforcer{},
+		ReferrerStores: []referrerstore.ReferrerStore{store},
+		Verifiers:      []verifier.ReferenceVerifier{ver},
+		Config: &exConfig.ExecutorConfig{
+			VerificationRequestTimeout: nil,
+			MutationRequestTimeout:     nil,
+		},
+	}
+
+	verifyParameters := e.VerifyParameters{
+		Subject: "localhost:5000/net-monitor:v1",
+	}
+
+	result, err := ex.verifySubjectInternal(context.Background(), verifyParameters)
+	if err != nil {
+		t.Fatalf("verification failed with err %v", err)
+	}
+
+	report := result.VerifierReports[0].(verifier.VerifierResult)
+	if report.IsSuccess || report.Error != pluginError || !strings.Contains(report.Message, "plugin command VERIFY failed") {
+		t.Fatalf("expected the plugin error in the verifier result, got %+v", report)
+	}
+}
+
 func TestVerifySubject_VerifySuccess_ExpectedResults(t *testing.T) {
 	testDigest := digest.FromString("test")
 	configPolicy := config.PolicyEnforcer{
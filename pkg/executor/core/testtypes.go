@@ -17,6 +17,7 @@ package core
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/deislabs/ratify/pkg/common"
 	"github.com/deislabs/ratify/pkg/ocispecs"
@@ -28,6 +29,8 @@ type TestVerifier struct {
 	CanVerifyFunc    func(artifactType string) bool
 	VerifyResult     func(artifactType string) bool
 	nestedReferences []string
+	// pluginError fails the verification with the error of a plugin when set
+	pluginError *verifier.PluginError
 }
 
 func (s *TestVerifier) Name() string {
@@ -42,6 +45,9 @@ func (s *TestVerifier) Verify(ctx context.Context,
 	subjectReference common.Reference,
 	referenceDescriptor ocispecs.ReferenceDescriptor,
 	referrerStore referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	if s.pluginError != nil {
+		return verifier.VerifierResult{IsSuccess: false, Error: s.pluginError}, fmt.Errorf("plugin failed: %s", s.pluginError.Message)
+	}
 	return verifier.VerifierResult{
 		IsSuccess: s.VerifyResult(referenceDescriptor.ArtifactType),
 	}, nil
@@ -17,6 +17,9 @@ package metrics
 
 import (
 	"context"
+	"crypto/x509"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/attribute"
@@ -33,7 +36,25 @@ var (
 	verifierDuration     instrument.Int64Histogram
 	systemErrorCount     instrument.Int64Counter
 	registryRequestCount instrument.Int64Counter
+	registryRetryCount   instrument.Int64Counter
+	circuitBreakerState  instrument.Int64ObservableGauge
 	cacheBlobCount       instrument.Int64Counter
+	blobCacheSize        instrument.Int64ObservableGauge
+	blobCacheEviction    instrument.Int64Counter
+	certificateExpiry    instrument.Float64ObservableGauge
+	pluginErrorCount     instrument.Int64Counter
+
+	// a map between a registry host and the state of its circuit breaker, observed by the circuit breaker state gauge
+	circuitBreakerStates     = map[string]int64{}
+	circuitBreakerStatesLock sync.RWMutex
+
+	// a map between the path of a blob cache and its size in bytes, observed by the blob cache size gauge
+	blobCacheSizes     = map[string]int64{}
+	blobCacheSizesLock sync.RWMutex
+
+	// a map between a certificate source and the certificates loaded from it, observed by the certificate expiry gauge
+	certificateSources     = map[string]certificateSource{}
+	certificateSourcesLock sync.RWMutex
 
 	// Azure Metrics
 	aadExchangeDuration    instrument.Int64Histogram
@@ -50,7 +71,13 @@ const (
 	metricNameVerifierDuration     = "ratify_verifier_duration"
 	metricNameSystemErrorCount     = "ratify_system_error_count"
 	metricNameRegistryRequestCount = "ratify_registry_request_count"
+	metricNameRegistryRetryCount   = "ratify_registry_retry_count"
+	metricNameCircuitBreakerState  = "ratify_registry_circuit_breaker_state"
 	metricNameBlobCacheCount       = "ratify_blob_cache_count"
+	metricNameBlobCacheSize        = "ratify_blob_cache_size"
+	metricNameBlobCacheEviction    = "ratify_blob_cache_eviction_count"
+	metricNameCertificateExpiry    = "ratify_certificate_expiry_days"
+	metricNamePluginErrorCount     = "ratify_plugin_error_count"
 
 	// Azure Metrics
 	metricNameAADExchangeDuration    = "ratify_aad_exchange_duration"
@@ -174,14 +201,51 @@ func initStatsReporter() error {
 		logrus.Error(err)
 		return err
 	}
+	registryRetryCount, err = meter.Int64Counter(metricNameRegistryRetryCount, instrument.WithDescription("registry request retry count"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	circuitBreakerState, err = meter.Int64ObservableGauge(metricNameCircuitBreakerState, instrument.WithDescription("registry circuit breaker state, 0 closed, 1 half-open, 2 open"), instrument.WithInt64Callback(observeCircuitBreakerState))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
 	cacheBlobCount, err = meter.Int64Counter(metricNameBlobCacheCount, instrument.WithDescription("blob cache hit/miss count"))
 	if err != nil {
 		logrus.Error(err)
 		return err
 	}
+	blobCacheSize, err = meter.Int64ObservableGauge(metricNameBlobCacheSize, instrument.WithUnit("byte"), instrument.WithDescription("size of the blobs in the blob cache in bytes"), instrument.WithInt64Callback(observeBlobCacheSize))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	blobCacheEviction, err = meter.Int64Counter(metricNameBlobCacheEviction, instrument.WithDescription("blob cache eviction count"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	certificateExpiry, err = meter.Float64ObservableGauge(metricNameCertificateExpiry, instrument.WithUnit("day"), instrument.WithDescription("days until certificate expiry"), instrument.WithFloat64Callback(observeCertificateExpiry))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	pluginErrorCount, err = meter.Int64Counter(metricNamePluginErrorCount, instrument.WithDescription("plugin error count"))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
 	return nil
 }
 
+// certificateSource holds the certificates loaded from a trust store path or a certificate store
+type certificateSource struct {
+	sourceType   string
+	name         string
+	certificates []*x509.Certificate
+}
+
 // ReportVerificationRequest reports the duration of a verification request
 func ReportVerificationRequest(ctx context.Context, duration int64) {
 	if verificationDuration != nil {
@@ -234,6 +298,16 @@ func ReportSystemError(ctx context.Context, errorString string) {
 	}
 }
 
+// ReportPluginError reports a plugin execution that failed
+// Attributes:
+// plugin: the name of the plugin
+// code: the error code returned by the plugin, 0 if the plugin failed without a structured error
+func ReportPluginError(ctx context.Context, plugin string, code uint) {
+	if pluginErrorCount != nil {
+		pluginErrorCount.Add(ctx, 1, attribute.KeyValue{Key: "plugin", Value: attribute.StringValue(plugin)}, attribute.KeyValue{Key: "code", Value: attribute.IntValue(int(code))})
+	}
+}
+
 // ReportRegistryRequestCount reports a registry request
 // Attributes:
 // statusCode: the status code of the request
@@ -244,6 +318,36 @@ func ReportRegistryRequestCount(ctx context.Context, statusCode int, registryHos
 	}
 }
 
+// ReportRegistryRetry reports a registry request that is retried
+// Attributes:
+// statusCode: the status code of the failed attempt, 0 if no response was received
+// registryHost: the host name of the registry
+func ReportRegistryRetry(ctx context.Context, statusCode int, registryHost string) {
+	if registryRetryCount != nil {
+		registryRetryCount.Add(ctx, 1, attribute.KeyValue{Key: "status_code", Value: attribute.IntValue(statusCode)}, attribute.KeyValue{Key: "registry_host", Value: attribute.StringValue(registryHost)})
+	}
+}
+
+// ReportCircuitBreakerState records the state of the circuit breaker of a registry, it is observed on every collection.
+// Attributes:
+// registryHost: the host name of the registry
+func ReportCircuitBreakerState(registryHost string, state int64) {
+	circuitBreakerStatesLock.Lock()
+	defer circuitBreakerStatesLock.Unlock()
+	circuitBreakerStates[registryHost] = state
+}
+
+// observeCircuitBreakerState observes the state of every reported circuit breaker
+func observeCircuitBreakerState(_ context.Context, observer instrument.Int64Observer) error {
+	circuitBreakerStatesLock.RLock()
+	defer circuitBreakerStatesLock.RUnlock()
+
+	for host, state := range circuitBreakerStates {
+		observer.Observe(state, attribute.KeyValue{Key: "registry_host", Value: attribute.StringValue(host)})
+	}
+	return nil
+}
+
 // ReportAADExchangeDuration reports the duration of an AAD exchange
 // Attributes:
 // resourceType: the scope of resource being exchanged (AKV or ACR)
@@ -279,3 +383,70 @@ func ReportBlobCacheCount(ctx context.Context, hit bool) {
 		cacheBlobCount.Add(ctx, 1, attribute.KeyValue{Key: "hit", Value: attribute.BoolValue(hit)})
 	}
 }
+
+// ReportBlobCacheSize records the size in bytes of the blobs in a blob cache, it is observed on every collection.
+// Attributes:
+// cache: the path of the blob cache
+func ReportBlobCacheSize(cache string, size int64) {
+	blobCacheSizesLock.Lock()
+	defer blobCacheSizesLock.Unlock()
+	blobCacheSizes[cache] = size
+}
+
+// observeBlobCacheSize observes the size of every reported blob cache
+func observeBlobCacheSize(_ context.Context, observer instrument.Int64Observer) error {
+	blobCacheSizesLock.RLock()
+	defer blobCacheSizesLock.RUnlock()
+
+	for cache, size := range blobCacheSizes {
+		observer.Observe(size, attribute.KeyValue{Key: "cache", Value: attribute.StringValue(cache)})
+	}
+	return nil
+}
+
+// ReportBlobCacheEviction reports a blob evicted from a blob cache
+// Attributes:
+// reason: why the blob was evicted (size, age or corrupt)
+func ReportBlobCacheEviction(ctx context.Context, reason string) {
+	if blobCacheEviction != nil {
+		blobCacheEviction.Add(ctx, 1, attribute.KeyValue{Key: "reason", Value: attribute.StringValue(reason)})
+	}
+}
+
+// ReportCertificateExpiry records the certificates of a source, their days until expiry are observed on every collection.
+// nil certificates removes the source.
+// Attributes:
+// sourceType: the type of the source (trustStorePath or certificateStore)
+// source: the name of the source
+// subject: the subject of the certificate
+// serial: the serial number of the certificate
+func ReportCertificateExpiry(sourceType string, source string, certificates []*x509.Certificate) {
+	key := sourceType + "/" + source
+
+	certificateSourcesLock.Lock()
+	defer certificateSourcesLock.Unlock()
+	if certificates == nil {
+		delete(certificateSources, key)
+		return
+	}
+	certificateSources[key] = certificateSource{sourceType: sourceType, name: source, certificates: certificates}
+}
+
+// observeCertificateExpiry observes the days until expiry of every reported certificate
+func observeCertificateExpiry(_ context.Context, observer instrument.Float64Observer) error {
+	certificateSourcesLock.RLock()
+	defer certificateSourcesLock.RUnlock()
+
+	now := time.Now()
+	for _, source := range certificateSources {
+		for _, cert := range source.certificates {
+			observer.Observe(cert.NotAfter.Sub(now).Hours()/24,
+				attribute.KeyValue{Key: "source_type", Value: attribute.StringValue(source.sourceType)},
+				attribute.KeyValue{Key: "source", Value: attribute.StringValue(source.name)},
+				attribute.KeyValue{Key: "subject", Value: attribute.StringValue(cert.Subject.String())},
+				attribute.KeyValue{Key: "serial", Value: attribute.StringValue(cert.SerialNumber.Text(16))},
+			)
+		}
+	}
+	return nil
+}
@@ -17,8 +17,12 @@ package metrics
 
 import (
 	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric/instrument"
@@ -54,6 +58,34 @@ func (m *MockInt64Counter) Add(ctx context.Context, incr int64, attrs ...attribu
 	}
 }
 
+type MockFloat64Observer struct {
+	Values     []float64
+	Attributes []map[string]string
+}
+
+func (m *MockFloat64Observer) Observe(value float64, attrs ...attribute.KeyValue) {
+	m.Values = append(m.Values, value)
+	attributes := map[string]string{}
+	for _, attr := range attrs {
+		attributes[string(attr.Key)] = attr.Value.AsString()
+	}
+	m.Attributes = append(m.Attributes, attributes)
+}
+
+type MockInt64Observer struct {
+	Values     []int64
+	Attributes []map[string]string
+}
+
+func (m *MockInt64Observer) Observe(value int64, attrs ...attribute.KeyValue) {
+	m.Values = append(m.Values, value)
+	attributes := map[string]string{}
+	for _, attr := range attrs {
+		attributes[string(attr.Key)] = attr.Value.AsString()
+	}
+	m.Attributes = append(m.Attributes, attributes)
+}
+
 type MockInt64Counter struct {
 	instrument.Int64Counter
 	Value      int64
@@ -227,3 +259,128 @@ func TestReportBlobCacheCount(t *testing.T) {
 		t.Fatalf("expected hit attribute to be true but got %s", mockCounter.Attributes["hit"])
 	}
 }
+
+func TestReportRegistryRetry(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	registryRetryCount = mockCounter
+	ReportRegistryRetry(context.Background(), 503, "test_registry_host")
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportRegistryRetry() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if mockCounter.Attributes["status_code"] != "503" {
+		t.Fatalf("expected status_code attribute to be 503 but got %s", mockCounter.Attributes["status_code"])
+	}
+	if mockCounter.Attributes["registry_host"] != "test_registry_host" {
+		t.Fatalf("expected registry_host attribute to be test_registry_host but got %s", mockCounter.Attributes["registry_host"])
+	}
+}
+
+func TestReportCircuitBreakerState(t *testing.T) {
+	ReportCircuitBreakerState("test_registry_host", 2)
+	defer func() {
+		circuitBreakerStatesLock.Lock()
+		delete(circuitBreakerStates, "test_registry_host")
+		circuitBreakerStatesLock.Unlock()
+	}()
+
+	observer := &MockInt64Observer{}
+	if err := observeCircuitBreakerState(context.Background(), observer); err != nil {
+		t.Fatalf("observeCircuitBreakerState() error = %v", err)
+	}
+	if len(observer.Values) != 1 || observer.Values[0] != 2 {
+		t.Fatalf("observeCircuitBreakerState() observer.Values = %v, expected [2]", observer.Values)
+	}
+	if observer.Attributes[0]["registry_host"] != "test_registry_host" {
+		t.Fatalf("expected registry_host attribute to be test_registry_host but got %s", observer.Attributes[0]["registry_host"])
+	}
+}
+
+func TestReportBlobCacheSize(t *testing.T) {
+	ReportBlobCacheSize("test_cache", 1024)
+	defer func() {
+		blobCacheSizesLock.Lock()
+		delete(blobCacheSizes, "test_cache")
+		blobCacheSizesLock.Unlock()
+	}()
+
+	observer := &MockInt64Observer{}
+	if err := observeBlobCacheSize(context.Background(), observer); err != nil {
+		t.Fatalf("observeBlobCacheSize() error = %v", err)
+	}
+	if len(observer.Values) != 1 || observer.Values[0] != 1024 {
+		t.Fatalf("observeBlobCacheSize() observer.Values = %v, expected [1024]", observer.Values)
+	}
+	if observer.Attributes[0]["cache"] != "test_cache" {
+		t.Fatalf("expected cache attribute to be test_cache but got %s", observer.Attributes[0]["cache"])
+	}
+}
+
+func TestReportBlobCacheEviction(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	blobCacheEviction = mockCounter
+	ReportBlobCacheEviction(context.Background(), "size")
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportBlobCacheEviction() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if mockCounter.Attributes["reason"] != "size" {
+		t.Fatalf("expected reason attribute to be size but got %s", mockCounter.Attributes["reason"])
+	}
+}
+
+func TestReportPluginError(t *testing.T) {
+	if err := initStatsReporter(); err != nil {
+		t.Fatalf("initStatsReporter() error = %v", err)
+	}
+
+	mockCounter := &MockInt64Counter{Attributes: make(map[string]string)}
+	pluginErrorCount = mockCounter
+	ReportPluginError(context.Background(), "sbom", 8)
+	if mockCounter.Value != 1 {
+		t.Fatalf("ReportPluginError() mockCounter.Value = %v, expected %v", mockCounter.Value, 1)
+	}
+	if mockCounter.Attributes["plugin"] != "sbom" || mockCounter.Attributes["code"] != "8" {
+		t.Fatalf("expected plugin sbom and code 8 attributes but got %v", mockCounter.Attributes)
+	}
+}
+
+func TestReportCertificateExpiry(t *testing.T) {
+	cert := &x509.Certificate{
+		Subject:      pkix.Name{CommonName: "ratify.test"},
+		SerialNumber: big.NewInt(255),
+		NotAfter:     time.Now().Add(10*24*time.Hour + time.Hour),
+	}
+	ReportCertificateExpiry("certificateStore", "certstore-test", []*x509.Certificate{cert})
+	defer ReportCertificateExpiry("certificateStore", "certstore-test", nil)
+
+	observer := &MockFloat64Observer{}
+	if err := observeCertificateExpiry(context.Background(), observer); err != nil {
+		t.Fatalf("observeCertificateExpiry() error = %v", err)
+	}
+	if len(observer.Values) != 1 {
+		t.Fatalf("observeCertificateExpiry() len(observer.Values) = %v, expected %v", len(observer.Values), 1)
+	}
+	if int(observer.Values[0]) != 10 {
+		t.Fatalf("observeCertificateExpiry() observer.Values[0] = %v, expected %v days", observer.Values[0], 10)
+	}
+	expectedAttributes := map[string]string{"source_type": "certificateStore", "source": "certstore-test", "subject": "CN=ratify.test", "serial": "ff"}
+	for key, value := range expectedAttributes {
+		if observer.Attributes[0][key] != value {
+			t.Fatalf("expected %s attribute to be %s but got %s", key, value, observer.Attributes[0][key])
+		}
+	}
+
+	ReportCertificateExpiry("certificateStore", "certstore-test", nil)
+	observer = &MockFloat64Observer{}
+	_ = observeCertificateExpiry(context.Background(), observer)
+	if len(observer.Values) != 0 {
+		t.Fatalf("expected removed source not to be observed, got %v observations", len(observer.Values))
+	}
+}
@@ -24,6 +24,8 @@ type ReferenceDescriptor struct {
 	oci.Descriptor
 
 	ArtifactType string `json:"artifactType,omitempty"`
+	// DiscoveredBy is the mechanism the referrer store found the referrer with, e.g. the Referrers API or the referrers tag schema
+	DiscoveredBy string `json:"discoveredBy,omitempty"`
 }
 
 // ReferenceManifest describes an artifact manifest
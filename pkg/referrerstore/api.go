@@ -17,6 +17,7 @@ package referrerstore
 
 import (
 	"context"
+	"io"
 
 	"github.com/deislabs/ratify/pkg/common"
 	"github.com/deislabs/ratify/pkg/ocispecs"
@@ -43,6 +44,10 @@ type ReferrerStore interface {
 	// WARNING: This API is intended to use for small objects like signatures, SBoMs
 	GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error)
 
+	// GetBlobReader returns a reader of the blob with the given digest, so that large blobs like
+	// vulnerability reports can be parsed incrementally. The caller must close the reader.
+	GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error)
+
 	// GetReferenceManifest returns the reference artifact manifest as given by the descriptor
 	GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error)
 
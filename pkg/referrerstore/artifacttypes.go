@@ -0,0 +1,52 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referrerstore
+
+import "strings"
+
+// WildcardArtifactType requests referrers of any artifact type
+const WildcardArtifactType = "*"
+
+// ArtifactTypeFilters returns the distinct artifact types to list, a single empty filter lists all referrers
+func ArtifactTypeFilters(artifactTypes []string) []string {
+	filters := []string{}
+	seen := map[string]bool{}
+	for _, artifactType := range artifactTypes {
+		artifactType = strings.TrimSpace(artifactType)
+		if artifactType == "" || artifactType == WildcardArtifactType {
+			// an empty or wildcard artifact type matches every referrer
+			return []string{""}
+		}
+		if !seen[artifactType] {
+			seen[artifactType] = true
+			filters = append(filters, artifactType)
+		}
+	}
+	if len(filters) == 0 {
+		return []string{""}
+	}
+	return filters
+}
+
+// MatchesArtifactTypes returns true if artifactType is one of artifactTypes, or no or the wildcard artifact type is requested
+func MatchesArtifactTypes(artifactType string, artifactTypes []string) bool {
+	for _, filter := range ArtifactTypeFilters(artifactTypes) {
+		if filter == "" || filter == artifactType {
+			return true
+		}
+	}
+	return false
+}
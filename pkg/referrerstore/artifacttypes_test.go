@@ -0,0 +1,63 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referrerstore
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testArtifactTypeSbom     = "application/spdx+json"
+	testArtifactTypeNotation = "application/vnd.cncf.notary.signature"
+)
+
+func TestArtifactTypeFilters(t *testing.T) {
+	testCases := []struct {
+		artifactTypes []string
+		expected      []string
+	}{
+		{artifactTypes: nil, expected: []string{""}},
+		{artifactTypes: []string{testArtifactTypeSbom, " " + testArtifactTypeSbom, testArtifactTypeNotation}, expected: []string{testArtifactTypeSbom, testArtifactTypeNotation}},
+		{artifactTypes: []string{testArtifactTypeSbom, ""}, expected: []string{""}},
+		{artifactTypes: []string{"*"}, expected: []string{""}},
+	}
+
+	for _, tc := range testCases {
+		actual := ArtifactTypeFilters(tc.artifactTypes)
+		if strings.Join(actual, ",") != strings.Join(tc.expected, ",") {
+			t.Fatalf("expected filters %v, got %v", tc.expected, actual)
+		}
+	}
+}
+
+func TestMatchesArtifactTypes(t *testing.T) {
+	testCases := []struct {
+		artifactTypes []string
+		expected      bool
+	}{
+		{artifactTypes: nil, expected: true},
+		{artifactTypes: []string{"*"}, expected: true},
+		{artifactTypes: []string{" " + testArtifactTypeSbom}, expected: true},
+		{artifactTypes: []string{testArtifactTypeNotation}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		if actual := MatchesArtifactTypes(testArtifactTypeSbom, tc.artifactTypes); actual != tc.expected {
+			t.Fatalf("expected %v for artifact types %v, got %v", tc.expected, tc.artifactTypes, actual)
+		}
+	}
+}
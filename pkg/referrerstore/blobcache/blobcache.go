@@ -0,0 +1,469 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobcache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+
+	"github.com/deislabs/ratify/pkg/metrics"
+)
+
+const (
+	// DefaultMaxSize is the default bound of the size of the blobs in the cache
+	DefaultMaxSize = "1Gi"
+	// DefaultMaxAge is the default time a blob is kept in the cache without being read
+	DefaultMaxAge = "168h"
+
+	blobsDir  = "blobs"
+	ingestDir = "ingest"
+	// ingest files older than staleIngestAge were left by writes interrupted by a restart,
+	// younger files may be written by another process sharing the cache
+	staleIngestAge = time.Hour
+
+	evictionReasonSize    = "size"
+	evictionReasonAge     = "age"
+	evictionReasonCorrupt = "corrupt"
+)
+
+// ErrBlobTooLarge is returned when pushing a blob larger than the size of the cache
+var ErrBlobTooLarge = errors.New("blob is larger than the blob cache")
+
+// ErrInvalidContent is returned when the content of a blob does not match its descriptor
+var ErrInvalidContent = errors.New("content does not match the blob descriptor")
+
+// maintenanceDisabled is set in processes that share the cache of another process
+var maintenanceDisabled atomic.Bool
+
+// DisableMaintenance stops the caches opened by the process from removing ingest files and evicting blobs.
+// Plugin processes share the cache of the ratify process, which owns its maintenance.
+func DisableMaintenance() {
+	maintenanceDisabled.Store(true)
+}
+
+// Config describes the bounds of a blob cache
+type Config struct {
+	// MaxSize is the maximum total size of the cached blobs as a quantity, e.g. 512Mi
+	MaxSize string `json:"maxSize,omitempty"`
+	// MaxAge is how long a blob is kept without being read, e.g. 24h. 0 keeps blobs until they are evicted by size
+	MaxAge string `json:"maxAge,omitempty"`
+}
+
+// Cache is a size and age bounded cache of content addressed blobs on disk, evicting the least recently used blobs.
+// Blobs are stored at blobs/<algorithm>/<encoded digest> under the root path, like an OCI image layout,
+// so the blobs of an existing layout are reused. Cache implements content.Storage.
+type Cache struct {
+	root    string
+	maxSize int64
+	maxAge  time.Duration
+	// maintained is false if the cache is shared with the process evicting it
+	maintained bool
+
+	mu sync.Mutex
+	// entries maps the digest of a blob to its element in lru
+	entries map[digest.Digest]*list.Element
+	// lru holds the cached blobs, the most recently used first
+	lru  *list.List
+	size int64
+	now  func() time.Time
+}
+
+type entry struct {
+	digest     digest.Digest
+	size       int64
+	lastAccess time.Time
+	// verified is false until the content of a blob loaded from disk is read and matches its digest
+	verified bool
+}
+
+// New opens the blob cache at path and evicts it down to its bounds. Blobs already in the cache are checked
+// against their digest when they are first read, blobs that do not match are removed.
+func New(path string, conf Config) (*Cache, error) {
+	maxSize, maxAge, err := parseConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	cache := &Cache{
+		root:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maintained: !maintenanceDisabled.Load(),
+		entries:    map[digest.Digest]*list.Element{},
+		lru:        list.New(),
+		now:        time.Now,
+	}
+	for _, dir := range []string{blobsDir, ingestDir} {
+		if err := os.MkdirAll(filepath.Join(path, dir), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create blob cache at path %s: %w", path, err)
+		}
+	}
+	if cache.maintained {
+		if err := cache.removeStaleIngests(); err != nil {
+			return nil, fmt.Errorf("failed to clean up blob cache ingest directory: %w", err)
+		}
+	}
+	if err := cache.load(); err != nil {
+		return nil, fmt.Errorf("failed to load blob cache at path %s: %w", path, err)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.evict(context.Background())
+	return cache, nil
+}
+
+func parseConfig(conf Config) (int64, time.Duration, error) {
+	if conf.MaxSize == "" {
+		conf.MaxSize = DefaultMaxSize
+	}
+	quantity, err := resource.ParseQuantity(conf.MaxSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid blob cache maxSize %q: %w", conf.MaxSize, err)
+	}
+	maxSize, ok := quantity.AsInt64()
+	if !ok || maxSize <= 0 {
+		return 0, 0, fmt.Errorf("invalid blob cache maxSize %q: must be a positive number of bytes", conf.MaxSize)
+	}
+
+	if conf.MaxAge == "" {
+		conf.MaxAge = DefaultMaxAge
+	}
+	maxAge, err := time.ParseDuration(conf.MaxAge)
+	if err != nil || maxAge < 0 {
+		return 0, 0, fmt.Errorf("invalid blob cache maxAge %q: must be a non-negative duration", conf.MaxAge)
+	}
+	return maxSize, maxAge, nil
+}
+
+// removeStaleIngests discards the writes interrupted by a restart
+func (c *Cache) removeStaleIngests() error {
+	entries, err := os.ReadDir(filepath.Join(c.root, ingestDir))
+	if err != nil {
+		return err
+	}
+	for _, ingest := range entries {
+		info, err := ingest.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		if c.now().Sub(info.ModTime()) < staleIngestAge {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(c.root, ingestDir, ingest.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load indexes the blobs in the cache directory, the time a blob was last read is its modification time
+func (c *Cache) load() error {
+	var loaded []entry
+	err := filepath.WalkDir(filepath.Join(c.root, blobsDir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Join(c.root, blobsDir), path)
+		if err != nil {
+			return err
+		}
+		algorithm, encoded := filepath.Split(rel)
+		dgst := digest.NewDigestFromEncoded(digest.Algorithm(filepath.Clean(algorithm)), encoded)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := dgst.Validate(); err != nil {
+			logrus.Warnf("ignoring file %s in the blob cache: %v", rel, err)
+			return nil
+		}
+		loaded = append(loaded, entry{digest: dgst, size: info.Size(), lastAccess: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the most recently used blobs are at the front
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].lastAccess.After(loaded[j].lastAccess)
+	})
+	for i := range loaded {
+		c.entries[loaded[i].digest] = c.lru.PushBack(&loaded[i])
+		c.size += loaded[i].size
+	}
+	return nil
+}
+
+// Fetch returns the content of a cached blob, errdef.ErrNotFound is returned if it is not cached
+func (c *Cache) Fetch(ctx context.Context, target oci.Descriptor) (io.ReadCloser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.lookup(ctx, target.Digest)
+	if !ok {
+		metrics.ReportBlobCacheCount(ctx, false)
+		return nil, fmt.Errorf("%s: %w", target.Digest, errdef.ErrNotFound)
+	}
+	file, err := os.Open(c.blobPath(target.Digest))
+	if err != nil {
+		// the blob was removed from disk by another process
+		c.remove(element)
+		metrics.ReportBlobCacheCount(ctx, false)
+		return nil, fmt.Errorf("%s: %w", target.Digest, errdef.ErrNotFound)
+	}
+	metrics.ReportBlobCacheCount(ctx, true)
+
+	e := element.Value.(*entry)
+	e.lastAccess = c.now()
+	c.lru.MoveToFront(element)
+	// the access time is persisted so the order of eviction survives restarts
+	if err := os.Chtimes(c.blobPath(e.digest), e.lastAccess, e.lastAccess); err != nil {
+		logrus.Debugf("failed to update access time of cached blob %s: %v", e.digest, err)
+	}
+	if e.verified {
+		return file, nil
+	}
+	return &verifyReader{file: file, cache: c, entry: e, verifier: content.NewVerifyReader(file, oci.Descriptor{Digest: e.digest, Size: e.size})}, nil
+}
+
+// verifyReader checks a blob loaded from disk against its digest once it is read to the end
+type verifyReader struct {
+	file     *os.File
+	cache    *Cache
+	entry    *entry
+	verifier *content.VerifyReader
+}
+
+func (r *verifyReader) Read(p []byte) (int, error) {
+	n, err := r.verifier.Read(p)
+	if err == io.EOF {
+		if verifyErr := r.verifier.Verify(); verifyErr != nil {
+			r.cache.removeCorrupt(r.entry, verifyErr)
+			return n, fmt.Errorf("cached blob %s: %w", r.entry.digest, verifyErr)
+		}
+		r.cache.mu.Lock()
+		r.entry.verified = true
+		r.cache.mu.Unlock()
+	}
+	return n, err
+}
+
+func (r *verifyReader) Close() error {
+	return r.file.Close()
+}
+
+// removeCorrupt removes a blob that does not match its digest, unless it was already replaced
+func (c *Cache) removeCorrupt(e *entry, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[e.digest]
+	if !ok || element.Value.(*entry) != e {
+		return
+	}
+	logrus.Warnf("removing blob %s from the blob cache: %v", e.digest, err)
+	c.remove(element)
+	metrics.ReportBlobCacheEviction(context.Background(), evictionReasonCorrupt)
+}
+
+// Exists returns whether the blob is cached
+func (c *Cache) Exists(ctx context.Context, target oci.Descriptor) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.lookup(ctx, target.Digest)
+	return ok, nil
+}
+
+// Push verifies and caches a blob, evicting the least recently used blobs to stay within the size of the cache
+func (c *Cache) Push(ctx context.Context, expected oci.Descriptor, content io.Reader) error {
+	writer, err := c.NewWriter(ctx, expected)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, content); err != nil {
+		writer.Abort()
+		return err
+	}
+	return writer.Commit(ctx)
+}
+
+// Writer adds a blob to the cache as it is written, so content streamed to a reader is cached at the same time
+type Writer struct {
+	cache    *Cache
+	expected oci.Descriptor
+	file     *os.File
+	verifier digest.Verifier
+	size     int64
+}
+
+// NewWriter returns a writer of a blob to the cache. ErrBlobTooLarge is returned if the expected size is larger than
+// the cache, errdef.ErrAlreadyExists if the blob is already cached.
+func (c *Cache) NewWriter(ctx context.Context, expected oci.Descriptor) (*Writer, error) {
+	if err := expected.Digest.Validate(); err != nil {
+		return nil, err
+	}
+	if expected.Size > c.maxSize {
+		return nil, fmt.Errorf("%s: %w", expected.Digest, ErrBlobTooLarge)
+	}
+	if exists, _ := c.Exists(ctx, expected); exists {
+		return nil, fmt.Errorf("%s: %s: %w", expected.Digest, expected.MediaType, errdef.ErrAlreadyExists)
+	}
+	file, err := os.CreateTemp(filepath.Join(c.root, ingestDir), expected.Digest.Encoded()+"-*")
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{cache: c, expected: expected, file: file, verifier: expected.Digest.Verifier()}, nil
+}
+
+// Write writes content of the blob, ErrBlobTooLarge is returned once more content than the size of the cache is written
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.size+int64(len(p)) > w.cache.maxSize {
+		return 0, fmt.Errorf("%s: %w", w.expected.Digest, ErrBlobTooLarge)
+	}
+	n, err := w.file.Write(p)
+	w.verifier.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+// Digest returns the digest of the blob written
+func (w *Writer) Digest() digest.Digest {
+	return w.expected.Digest
+}
+
+// Abort discards the content written
+func (w *Writer) Abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+// Commit verifies the content written matches the expected descriptor and adds the blob to the cache
+func (w *Writer) Commit(ctx context.Context) error {
+	defer os.Remove(w.file.Name())
+	err := w.file.Close()
+	if err == nil {
+		switch {
+		case w.expected.Size > 0 && w.size != w.expected.Size:
+			err = fmt.Errorf("%s: expected size %d, got %d: %w", w.expected.Digest, w.expected.Size, w.size, ErrInvalidContent)
+		case !w.verifier.Verified():
+			err = fmt.Errorf("%s: content does not match digest: %w", w.expected.Digest, ErrInvalidContent)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	c := w.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[w.expected.Digest]; ok {
+		return fmt.Errorf("%s: %s: %w", w.expected.Digest, w.expected.MediaType, errdef.ErrAlreadyExists)
+	}
+	blobPath := c.blobPath(w.expected.Digest)
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0700); err != nil {
+		return err
+	}
+	if err := os.Rename(w.file.Name(), blobPath); err != nil {
+		return err
+	}
+	// pushed content is verified while it is written
+	c.entries[w.expected.Digest] = c.lru.PushFront(&entry{digest: w.expected.Digest, size: w.size, lastAccess: c.now(), verified: true})
+	c.size += w.size
+	c.evict(ctx)
+	return nil
+}
+
+// Size returns the total size in bytes of the cached blobs
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// lookup returns the element of a cached blob, expired blobs are removed. It must be called with the lock held.
+func (c *Cache) lookup(ctx context.Context, dgst digest.Digest) (*list.Element, bool) {
+	element, ok := c.entries[dgst]
+	if !ok {
+		return nil, false
+	}
+	if c.maintained && c.expired(element.Value.(*entry)) {
+		c.remove(element)
+		metrics.ReportBlobCacheEviction(ctx, evictionReasonAge)
+		return nil, false
+	}
+	return element, true
+}
+
+// evict removes the blobs not read within the max age, then the least recently used blobs until the cache is within its size.
+// Blobs are not evicted if the cache is not maintained by the process. It must be called with the lock held.
+func (c *Cache) evict(ctx context.Context) {
+	if !c.maintained {
+		return
+	}
+	for element := c.lru.Back(); element != nil; {
+		previous := element.Prev()
+		if c.expired(element.Value.(*entry)) {
+			c.remove(element)
+			metrics.ReportBlobCacheEviction(ctx, evictionReasonAge)
+		}
+		element = previous
+	}
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+		metrics.ReportBlobCacheEviction(ctx, evictionReasonSize)
+	}
+	metrics.ReportBlobCacheSize(c.root, c.size)
+}
+
+func (c *Cache) expired(e *entry) bool {
+	return c.maxAge > 0 && c.now().Sub(e.lastAccess) > c.maxAge
+}
+
+// remove deletes a blob from the cache. It must be called with the lock held.
+func (c *Cache) remove(element *list.Element) {
+	e := c.lru.Remove(element).(*entry)
+	delete(c.entries, e.digest)
+	c.size -= e.size
+	if err := os.Remove(c.blobPath(e.digest)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logrus.Warnf("failed to remove blob %s from the blob cache: %v", e.digest, err)
+	}
+	metrics.ReportBlobCacheSize(c.root, c.size)
+}
+
+func (c *Cache) blobPath(dgst digest.Digest) string {
+	return filepath.Join(c.root, blobsDir, dgst.Algorithm().String(), dgst.Encoded())
+}
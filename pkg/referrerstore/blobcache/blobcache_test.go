@@ -0,0 +1,263 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blobcache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+func newTestCache(t *testing.T, path string, conf Config) *Cache {
+	cache, err := New(path, conf)
+	if err != nil {
+		t.Fatalf("failed to create blob cache: %v", err)
+	}
+	return cache
+}
+
+func pushTestBlob(t *testing.T, cache *Cache, content string) oci.Descriptor {
+	desc := oci.Descriptor{Digest: digest.FromString(content), Size: int64(len(content))}
+	if err := cache.Push(context.Background(), desc, bytes.NewReader([]byte(content))); err != nil {
+		t.Fatalf("failed to push blob: %v", err)
+	}
+	return desc
+}
+
+func fetchTestBlob(cache *Cache, desc oci.Descriptor) (string, error) {
+	rc, err := cache.Fetch(context.Background(), desc)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	return string(content), err
+}
+
+func TestCache_PushFetch(t *testing.T) {
+	cache := newTestCache(t, t.TempDir(), Config{})
+	desc := pushTestBlob(t, cache, "signature")
+
+	content, err := fetchTestBlob(cache, desc)
+	if err != nil || content != "signature" {
+		t.Fatalf("expected cached content, got %q and error %v", content, err)
+	}
+	if err := cache.Push(context.Background(), desc, bytes.NewReader([]byte("signature"))); !errors.Is(err, errdef.ErrAlreadyExists) {
+		t.Fatalf("expected already exists error, got %v", err)
+	}
+	if _, err := fetchTestBlob(cache, oci.Descriptor{Digest: digest.FromString("missing")}); !errors.Is(err, errdef.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestCache_PushInvalidContent(t *testing.T) {
+	cache := newTestCache(t, t.TempDir(), Config{MaxSize: "8"})
+	testCases := []struct {
+		name    string
+		desc    oci.Descriptor
+		content string
+	}{
+		{name: "digest mismatch", desc: oci.Descriptor{Digest: digest.FromString("sbom"), Size: 4}, content: "evil"},
+		{name: "size mismatch", desc: oci.Descriptor{Digest: digest.FromString("sbom"), Size: 3}, content: "sbom"},
+		{name: "larger than the cache", desc: oci.Descriptor{Digest: digest.FromString("large sbom")}, content: "large sbom"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := cache.Push(context.Background(), tc.desc, bytes.NewReader([]byte(tc.content))); err == nil {
+				t.Fatalf("expected error pushing invalid content")
+			}
+			if exists, _ := cache.Exists(context.Background(), tc.desc); exists || cache.Size() != 0 {
+				t.Fatalf("expected invalid content not to be cached")
+			}
+		})
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newTestCache(t, t.TempDir(), Config{MaxSize: "12"})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	first := pushTestBlob(t, cache, "blob1")
+	now = now.Add(time.Second)
+	second := pushTestBlob(t, cache, "blob2")
+	now = now.Add(time.Second)
+	// reading the first blob makes the second one the least recently used
+	if _, err := fetchTestBlob(cache, first); err != nil {
+		t.Fatalf("expected first blob to be cached, got %v", err)
+	}
+	now = now.Add(time.Second)
+	third := pushTestBlob(t, cache, "blob3")
+
+	for desc, expected := range map[digest.Digest]bool{first.Digest: true, second.Digest: false, third.Digest: true} {
+		if exists, _ := cache.Exists(context.Background(), oci.Descriptor{Digest: desc}); exists != expected {
+			t.Fatalf("expected blob %s cached %v", desc, expected)
+		}
+	}
+	if cache.Size() != 10 {
+		t.Fatalf("expected cache size 10, got %d", cache.Size())
+	}
+	if _, err := os.Stat(cache.blobPath(second.Digest)); !os.IsNotExist(err) {
+		t.Fatalf("expected evicted blob to be removed from disk, got %v", err)
+	}
+}
+
+func TestCache_EvictsExpiredBlobs(t *testing.T) {
+	cache := newTestCache(t, t.TempDir(), Config{MaxAge: "1h"})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	desc := pushTestBlob(t, cache, "signature")
+	now = now.Add(time.Hour + time.Second)
+	if _, err := fetchTestBlob(cache, desc); !errors.Is(err, errdef.ErrNotFound) {
+		t.Fatalf("expected expired blob not to be served, got %v", err)
+	}
+	if cache.Size() != 0 {
+		t.Fatalf("expected expired blob to be evicted, cache size %d", cache.Size())
+	}
+}
+
+func TestNew_LoadsExistingBlobs(t *testing.T) {
+	path := t.TempDir()
+	cache := newTestCache(t, path, Config{})
+	older := pushTestBlob(t, cache, "blob1")
+	newer := pushTestBlob(t, cache, "blob2")
+	now := time.Now()
+	for desc, accessed := range map[digest.Digest]time.Time{older.Digest: now.Add(-time.Minute), newer.Digest: now} {
+		if err := os.Chtimes(cache.blobPath(desc), accessed, accessed); err != nil {
+			t.Fatalf("failed to set access time: %v", err)
+		}
+	}
+	stale := filepath.Join(path, ingestDir, "stale")
+	inProgress := filepath.Join(path, ingestDir, "in-progress")
+	for _, ingest := range []string{stale, inProgress} {
+		if err := os.WriteFile(ingest, []byte("blob"), 0600); err != nil {
+			t.Fatalf("failed to write partial blob: %v", err)
+		}
+	}
+	staleTime := now.Add(-staleIngestAge - time.Minute)
+	if err := os.Chtimes(stale, staleTime, staleTime); err != nil {
+		t.Fatalf("failed to set modification time: %v", err)
+	}
+
+	// the blobs are reloaded in least recently used order, so the older blob is evicted first
+	reloaded := newTestCache(t, path, Config{MaxSize: "7"})
+	if reloaded.Size() != 5 {
+		t.Fatalf("expected a single blob to be kept, got size %d", reloaded.Size())
+	}
+	if content, err := fetchTestBlob(reloaded, newer); err != nil || content != "blob2" {
+		t.Fatalf("expected most recently used blob to be kept, got %q and error %v", content, err)
+	}
+	if exists, _ := reloaded.Exists(context.Background(), older); exists {
+		t.Fatalf("expected blob %s to be removed", older.Digest)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale partial blobs to be removed, got %v", err)
+	}
+	// another process sharing the cache may still be writing recent partial blobs
+	if _, err := os.Stat(inProgress); err != nil {
+		t.Fatalf("expected recent partial blobs to be kept, got %v", err)
+	}
+}
+
+func TestCache_FetchRemovesCorruptBlobs(t *testing.T) {
+	path := t.TempDir()
+	cache := newTestCache(t, path, Config{})
+	valid := pushTestBlob(t, cache, "blob1")
+	corrupt := pushTestBlob(t, cache, "blob2")
+	if err := os.WriteFile(cache.blobPath(corrupt.Digest), []byte("evil2"), 0600); err != nil {
+		t.Fatalf("failed to corrupt blob: %v", err)
+	}
+
+	// blobs loaded from disk are checked against their digest when they are read
+	reloaded := newTestCache(t, path, Config{})
+	if reloaded.Size() != 10 {
+		t.Fatalf("expected blobs to be loaded without reading them, got size %d", reloaded.Size())
+	}
+	if content, err := fetchTestBlob(reloaded, valid); err != nil || content != "blob1" {
+		t.Fatalf("expected valid blob to be served, got %q and error %v", content, err)
+	}
+	if _, err := fetchTestBlob(reloaded, corrupt); !errors.Is(err, content.ErrMismatchedDigest) {
+		t.Fatalf("expected corrupt blob to fail verification, got %v", err)
+	}
+	if exists, _ := reloaded.Exists(context.Background(), corrupt); exists || reloaded.Size() != 5 {
+		t.Fatalf("expected corrupt blob to be removed, cache size %d", reloaded.Size())
+	}
+	if _, err := os.Stat(reloaded.blobPath(corrupt.Digest)); !os.IsNotExist(err) {
+		t.Fatalf("expected corrupt blob to be removed from disk, got %v", err)
+	}
+}
+
+func TestCache_MaintenanceDisabled(t *testing.T) {
+	path := t.TempDir()
+	cache := newTestCache(t, path, Config{})
+	first := pushTestBlob(t, cache, "blob1")
+	stale := filepath.Join(path, ingestDir, "stale")
+	if err := os.WriteFile(stale, []byte("blob"), 0600); err != nil {
+		t.Fatalf("failed to write partial blob: %v", err)
+	}
+	staleTime := time.Now().Add(-staleIngestAge - time.Minute)
+	if err := os.Chtimes(stale, staleTime, staleTime); err != nil {
+		t.Fatalf("failed to set modification time: %v", err)
+	}
+
+	DisableMaintenance()
+	defer maintenanceDisabled.Store(false)
+	shared := newTestCache(t, path, Config{MaxSize: "7", MaxAge: "1h"})
+	second := pushTestBlob(t, shared, "blob2")
+	now := time.Now().Add(2 * time.Hour)
+	shared.now = func() time.Time { return now }
+
+	for _, desc := range []oci.Descriptor{first, second} {
+		if exists, _ := shared.Exists(context.Background(), desc); !exists {
+			t.Fatalf("expected blob %s not to be evicted by a process sharing the cache", desc.Digest)
+		}
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("expected partial blobs not to be removed by a process sharing the cache, got %v", err)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name string
+		conf Config
+	}{
+		{name: "invalid size", conf: Config{MaxSize: "large"}},
+		{name: "zero size", conf: Config{MaxSize: "0"}},
+		{name: "invalid age", conf: Config{MaxAge: "forever"}},
+		{name: "negative age", conf: Config{MaxAge: "-1h"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := New(t.TempDir(), tc.conf); err == nil {
+				t.Fatalf("expected error for invalid configuration")
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referrerstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+)
+
+const (
+	// CosignSignatureArtifactType is the artifact type cosign signatures attached with a tag are reported as
+	CosignSignatureArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	// CosignSignatureTagSuffix is the suffix of the tag cosign attaches signatures to a subject with
+	CosignSignatureTagSuffix = ".sig"
+)
+
+// Resolver resolves a reference to the descriptor of its manifest
+type Resolver interface {
+	Resolve(ctx context.Context, reference string) (oci.Descriptor, error)
+}
+
+// CosignAttachmentTag returns the tag cosign attaches content with tagSuffix to the subject with
+func CosignAttachmentTag(subjectDigest digest.Digest, tagSuffix string) string {
+	// sha256:d34db33f -> sha256-d34db33f.suffix
+	return strings.ReplaceAll(subjectDigest.String(), ":", "-") + tagSuffix
+}
+
+// ResolveCosignAttachment resolves the reference of a cosign attachment tag, false is returned if it is not found
+func ResolveCosignAttachment(ctx context.Context, resolver Resolver, reference string) (oci.Descriptor, bool, error) {
+	desc, err := resolver.Resolve(ctx, reference)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return oci.Descriptor{}, false, nil
+		}
+		return oci.Descriptor{}, false, err
+	}
+	return oci.Descriptor{MediaType: desc.MediaType, Digest: desc.Digest, Size: desc.Size}, true, nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referrerstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// testResolver resolves the references in descriptors and fails with err for the others
+type testResolver struct {
+	descriptors map[string]oci.Descriptor
+	err         error
+}
+
+func (r *testResolver) Resolve(_ context.Context, reference string) (oci.Descriptor, error) {
+	if desc, ok := r.descriptors[reference]; ok {
+		return desc, nil
+	}
+	return oci.Descriptor{}, r.err
+}
+
+func TestCosignAttachmentTag(t *testing.T) {
+	subjectDigest := digest.FromString("subject")
+	expected := "sha256-" + subjectDigest.Encoded() + ".sig"
+	if tag := CosignAttachmentTag(subjectDigest, CosignSignatureTagSuffix); tag != expected {
+		t.Fatalf("expected tag %s, got %s", expected, tag)
+	}
+}
+
+func TestResolveCosignAttachment(t *testing.T) {
+	signature := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("signature"), Size: 9, Annotations: map[string]string{"key": "value"}}
+	testCases := []struct {
+		name          string
+		reference     string
+		err           error
+		expectedFound bool
+		expectErr     bool
+	}{
+		{name: "found", reference: "localhost/test:signed", expectedFound: true},
+		{name: "not found", reference: "localhost/test:unsigned", err: errdef.ErrNotFound},
+		{name: "failure", reference: "localhost/test:unsigned", err: errors.New("registry unavailable"), expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := &testResolver{descriptors: map[string]oci.Descriptor{"localhost/test:signed": signature}, err: tc.err}
+			desc, found, err := ResolveCosignAttachment(context.Background(), resolver, tc.reference)
+			if tc.expectErr != (err != nil) || found != tc.expectedFound {
+				t.Fatalf("expected found %v and error %v, got %v and %v", tc.expectedFound, tc.expectErr, found, err)
+			}
+			if found && (desc.Digest != signature.Digest || desc.Size != signature.Size || desc.Annotations != nil) {
+				t.Fatalf("expected the descriptor of the signature manifest, got %+v", desc)
+			}
+		})
+	}
+}
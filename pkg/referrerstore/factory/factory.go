@@ -23,11 +23,13 @@ import (
 	"strings"
 
 	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
 	"github.com/deislabs/ratify/pkg/featureflag"
 	"github.com/deislabs/ratify/pkg/referrerstore"
 	"github.com/deislabs/ratify/pkg/referrerstore/config"
 	"github.com/deislabs/ratify/pkg/referrerstore/plugin"
 	"github.com/deislabs/ratify/pkg/referrerstore/types"
+	vf "github.com/deislabs/ratify/pkg/verifier/factory"
 	"github.com/sirupsen/logrus"
 )
 
@@ -69,8 +71,13 @@ func CreateStoreFromConfig(storeConfig config.StorePluginConfig, configVersion s
 				return nil, fmt.Errorf("failed to parse plugin source: %w", err)
 			}
 
+			signatureVerifier, err := vf.CreateSourceVerifier(source, configVersion, pluginBinDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create verifier of plugin source: %w", err)
+			}
+
 			targetPath := path.Join(pluginBinDir[0], storeNameStr)
-			err = pluginCommon.DownloadPlugin(source, targetPath)
+			err = pluginCommon.DownloadPlugin(source, targetPath, signatureVerifier)
 			if err != nil {
 				return nil, fmt.Errorf("failed to download plugin: %w", err)
 			}
@@ -80,6 +87,11 @@ func CreateStoreFromConfig(storeConfig config.StorePluginConfig, configVersion s
 		}
 	}
 
+	// a plugin served over gRPC runs as a long-lived server instead of being executed from the plugin directories
+	if address, ok := storeConfig[types.Address].(string); ok && grpcplugin.IsAddress(address) {
+		return plugin.NewGRPCStore(configVersion, storeConfig, address)
+	}
+
 	storeFactory, ok := builtInStores[storeNameStr]
 	if ok {
 		return storeFactory.Create(configVersion, storeConfig)
@@ -125,6 +137,6 @@ func CreateStoresFromConfig(storesConfig config.StoresConfig, defaultPluginPath
 
 func validateStoresConfig(storesConfig *config.StoresConfig) error {
 	// TODO check for existence of plugin dirs
-	// TODO check if version is supported
-	return nil
+	// the version is negotiated with each plugin when it is created
+	return pluginCommon.ValidateVersion(storesConfig.Version)
 }
@@ -98,7 +98,8 @@ func TestCreateStoresFromConfig_DynamicPluginStores_ReturnsExpected(t *testing.T
 	storeConfig := map[string]interface{}{
 		"name": "plugin-store",
 		"source": map[string]interface{}{
-			"artifact": "wabbitnetworks.azurecr.io/test/sample-verifier-plugin:v1",
+			"artifact":        "wabbitnetworks.azurecr.io/test/sample-verifier-plugin:v1",
+			"allowUnverified": true,
 		},
 	}
 
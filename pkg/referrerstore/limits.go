@@ -0,0 +1,110 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referrerstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// DefaultMaxBlobSize is the maximum size of a blob read from a store if not configured
+	DefaultMaxBlobSize = "64Mi"
+	// DefaultMaxManifestSize is the maximum size of a manifest read from a store if not configured
+	DefaultMaxManifestSize = "4Mi"
+)
+
+// ErrMaxSizeExceeded is returned when a blob or manifest is larger than the maximum size of the store
+var ErrMaxSizeExceeded = errors.New("content exceeds the maximum size allowed by the store")
+
+// SizeLimits is the configuration of the maximum size of the content read from a store
+type SizeLimits struct {
+	// MaxBlobSize is the maximum size of a blob as a quantity, e.g. 64Mi
+	MaxBlobSize string `json:"maxBlobSize,omitempty"`
+	// MaxManifestSize is the maximum size of a reference manifest as a quantity, e.g. 4Mi
+	MaxManifestSize string `json:"maxManifestSize,omitempty"`
+}
+
+// Parse returns the maximum blob and manifest sizes in bytes, defaulting unset limits
+func (limits SizeLimits) Parse() (int64, int64, error) {
+	maxBlobSize, err := parseSize("maxBlobSize", limits.MaxBlobSize, DefaultMaxBlobSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	maxManifestSize, err := parseSize("maxManifestSize", limits.MaxManifestSize, DefaultMaxManifestSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	return maxBlobSize, maxManifestSize, nil
+}
+
+func parseSize(name string, value string, defaultValue string) (int64, error) {
+	if value == "" {
+		value = defaultValue
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	size, ok := quantity.AsInt64()
+	if !ok || size <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive number of bytes", name, value)
+	}
+	return size, nil
+}
+
+// CheckSize returns ErrMaxSizeExceeded if the size of a descriptor is larger than limit
+func CheckSize(size int64, limit int64) error {
+	if size > limit {
+		return fmt.Errorf("size %d is larger than %d bytes: %w", size, limit, ErrMaxSizeExceeded)
+	}
+	return nil
+}
+
+// NewLimitedReader returns a reader of rc that fails with ErrMaxSizeExceeded once more than limit bytes are read.
+// Unlike io.LimitReader, content beyond the limit is an error rather than silently truncated.
+func NewLimitedReader(rc io.ReadCloser, limit int64) io.ReadCloser {
+	return &limitedReader{rc: rc, remaining: limit, limit: limit}
+}
+
+type limitedReader struct {
+	rc        io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, fmt.Errorf("content is larger than %d bytes: %w", r.limit, ErrMaxSizeExceeded)
+	}
+	// read a byte past the limit to tell content of exactly the maximum size from larger content
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.rc.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n + int(r.remaining), fmt.Errorf("content is larger than %d bytes: %w", r.limit, ErrMaxSizeExceeded)
+	}
+	return n, err
+}
+
+func (r *limitedReader) Close() error {
+	return r.rc.Close()
+}
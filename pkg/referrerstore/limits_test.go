@@ -0,0 +1,90 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referrerstore
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSizeLimits_Parse(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		limits                  SizeLimits
+		expectedMaxBlobSize     int64
+		expectedMaxManifestSize int64
+		expectErr               bool
+	}{
+		{name: "defaults", limits: SizeLimits{}, expectedMaxBlobSize: 64 << 20, expectedMaxManifestSize: 4 << 20},
+		{name: "configured", limits: SizeLimits{MaxBlobSize: "1Gi", MaxManifestSize: "512Ki"}, expectedMaxBlobSize: 1 << 30, expectedMaxManifestSize: 512 << 10},
+		{name: "invalid blob size", limits: SizeLimits{MaxBlobSize: "large"}, expectErr: true},
+		{name: "zero manifest size", limits: SizeLimits{MaxManifestSize: "0"}, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			maxBlobSize, maxManifestSize, err := tc.limits.Parse()
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if maxBlobSize != tc.expectedMaxBlobSize || maxManifestSize != tc.expectedMaxManifestSize {
+				t.Fatalf("expected limits %d and %d, got %d and %d", tc.expectedMaxBlobSize, tc.expectedMaxManifestSize, maxBlobSize, maxManifestSize)
+			}
+		})
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	testCases := []struct {
+		name      string
+		content   string
+		limit     int64
+		expectErr bool
+	}{
+		{name: "smaller than the limit", content: "sbom", limit: 8},
+		{name: "exactly the limit", content: "sbom", limit: 4},
+		{name: "larger than the limit", content: "large sbom", limit: 4, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content, err := io.ReadAll(NewLimitedReader(io.NopCloser(strings.NewReader(tc.content)), tc.limit))
+			if tc.expectErr {
+				if !errors.Is(err, ErrMaxSizeExceeded) {
+					t.Fatalf("expected max size exceeded error, got %v", err)
+				}
+				if int64(len(content)) != tc.limit {
+					t.Fatalf("expected content to be read up to the limit, got %q", content)
+				}
+				return
+			}
+			if err != nil || string(content) != tc.content {
+				t.Fatalf("expected content %q, got %q and error %v", tc.content, content, err)
+			}
+		})
+	}
+}
+
+func TestCheckSize(t *testing.T) {
+	if err := CheckSize(4, 4); err != nil {
+		t.Fatalf("expected size within the limit, got %v", err)
+	}
+	if err := CheckSize(5, 4); !errors.Is(err, ErrMaxSizeExceeded) {
+		t.Fatalf("expected max size exceeded error, got %v", err)
+	}
+}
@@ -14,8 +14,10 @@ limitations under the License.
 package mocks
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/deislabs/ratify/pkg/common"
 	"github.com/deislabs/ratify/pkg/ocispecs"
@@ -25,13 +27,33 @@ import (
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
-type memoryTestStore struct {
+// MemoryStore is a referrer store holding subjects, referrers, manifests and blobs in memory
+type MemoryStore struct {
 	Subjects  map[digest.Digest]*ocispecs.SubjectDescriptor
 	Referrers map[digest.Digest][]ocispecs.ReferenceDescriptor
+	Manifests map[digest.Digest]ocispecs.ReferenceManifest
+	Blobs     map[digest.Digest][]byte
 }
 
-func (store *memoryTestStore) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
-	// assume subjectDesc is given and good
+const memoryStoreName = "memoryTestStore"
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		Subjects:  make(map[digest.Digest]*ocispecs.SubjectDescriptor),
+		Referrers: make(map[digest.Digest][]ocispecs.ReferenceDescriptor),
+		Manifests: make(map[digest.Digest]ocispecs.ReferenceManifest),
+		Blobs:     make(map[digest.Digest][]byte),
+	}
+}
+
+func (store *MemoryStore) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	if subjectDesc == nil {
+		var err error
+		if subjectDesc, err = store.GetSubjectDescriptor(ctx, subjectReference); err != nil {
+			return referrerstore.ListReferrersResult{}, err
+		}
+	}
 
 	if item, ok := store.Referrers[subjectDesc.Digest]; ok {
 		return referrerstore.ListReferrersResult{
@@ -43,23 +65,41 @@ func (store *memoryTestStore) ListReferrers(ctx context.Context, subjectReferenc
 	return referrerstore.ListReferrersResult{}, nil
 }
 
-func (s *memoryTestStore) Name() string {
-	return "memoryTestStore"
+func (store *MemoryStore) Name() string {
+	return memoryStoreName
 }
 
-func (s *memoryTestStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
-	return nil, nil
+func (store *MemoryStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+	if blob, ok := store.Blobs[digest]; ok {
+		return blob, nil
+	}
+	return nil, fmt.Errorf("blob not found for %s", digest)
 }
 
-func (s *memoryTestStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
-	return ocispecs.ReferenceManifest{}, nil
+func (store *MemoryStore) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	blob, err := store.GetBlobContent(ctx, subjectReference, digest)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(blob)), nil
 }
 
-func (s *memoryTestStore) GetConfig() *config.StoreConfig {
-	return &config.StoreConfig{}
+func (store *MemoryStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	if manifest, ok := store.Manifests[referenceDesc.Digest]; ok {
+		return manifest, nil
+	}
+	return ocispecs.ReferenceManifest{}, fmt.Errorf("manifest not found for %s", referenceDesc.Digest)
+}
+
+// GetConfig returns a config naming the store, so that plugins reading content from the host request this store
+func (store *MemoryStore) GetConfig() *config.StoreConfig {
+	return &config.StoreConfig{
+		Version: "1.0.0",
+		Store:   config.StorePluginConfig{"name": memoryStoreName},
+	}
 }
 
-func (store *memoryTestStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+func (store *MemoryStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
 	if item, ok := store.Subjects[subjectReference.Digest]; ok {
 		return item, nil
 	}
@@ -67,12 +107,8 @@ func (store *memoryTestStore) GetSubjectDescriptor(ctx context.Context, subjectR
 	return nil, fmt.Errorf("subject not found for %s", subjectReference.Digest)
 }
 
-func createEmptyMemoryTestStore() *memoryTestStore {
-	return &memoryTestStore{Subjects: make(map[digest.Digest]*ocispecs.SubjectDescriptor), Referrers: make(map[digest.Digest][]ocispecs.ReferenceDescriptor)}
-}
-
 func CreateNewTestStoreForNestedSbom() referrerstore.ReferrerStore {
-	store := createEmptyMemoryTestStore()
+	store := NewMemoryStore()
 
 	addSignedImageWithSignedSbomToStore(store)
 
@@ -87,7 +123,7 @@ const (
 	artifactMediaType     = "application/vnd.oci.artifact.manifest.v1+json"
 )
 
-func addSignedImageWithSignedSbomToStore(store *memoryTestStore) {
+func addSignedImageWithSignedSbomToStore(store *MemoryStore) {
 	imageDigest := digest.NewDigestFromEncoded("sha256", "b556844e6e59451caf4429eb1de50aa7c50e4b1cc985f9f5893affe4b73f9935")
 	sbomDigest := digest.NewDigestFromEncoded("sha256", "9393779549fca5758811d7cf0444ddb1b254cb24b44fe1cf80fac6fd3199817f")
 	sbomSignatureDigest := digest.NewDigestFromEncoded("sha256", "ace31a6d260ee372caaed757b3411b634b2cecc379c31fda979dba4470699227")
@@ -18,6 +18,8 @@ package mocks
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/deislabs/ratify/pkg/common"
@@ -46,6 +48,10 @@ func (s *TestStore) GetBlobContent(ctx context.Context, subjectReference common.
 	return nil, nil
 }
 
+func (s *TestStore) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
 func (s *TestStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
 	return ocispecs.ReferenceManifest{}, nil
 }
@@ -0,0 +1,334 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocilayout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+	ocitarget "oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+
+	"github.com/deislabs/ratify/pkg/common"
+	commonutils "github.com/deislabs/ratify/pkg/common/utils"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/config"
+	"github.com/deislabs/ratify/pkg/referrerstore/factory"
+)
+
+const (
+	storeName = "ocilayout"
+)
+
+// OCILayoutStoreConf describes the configuration of the OCI layout store
+type OCILayoutStoreConf struct {
+	Name string `json:"name"`
+	// Path is the path of an OCI image layout directory or an oci-archive tarball
+	Path string `json:"path"`
+	// SizeLimits bounds the size of the blobs and manifests read from the layout
+	referrerstore.SizeLimits
+}
+
+type ociLayoutStoreFactory struct{}
+
+// ociLayoutStore reads subjects and their referrers from an OCI image layout, without access to a registry
+type ociLayoutStore struct {
+	config      *OCILayoutStoreConf
+	rawConfig   config.StoreConfig
+	layout      *ocitarget.ReadOnlyStore
+	maxBlobSize int64
+	// maxManifestSize also limits the size of the referrers indexes read from the layout
+	maxManifestSize int64
+}
+
+// manifest holds the fields of image, artifact and index manifests needed to discover referrers
+type manifest struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Config       *oci.Descriptor   `json:"config,omitempty"`
+	Subject      *oci.Descriptor   `json:"subject,omitempty"`
+	Manifests    []oci.Descriptor  `json:"manifests,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+func init() {
+	factory.Register(storeName, &ociLayoutStoreFactory{})
+}
+
+func (s *ociLayoutStoreFactory) Create(version string, storeConfig config.StorePluginConfig) (referrerstore.ReferrerStore, error) {
+	conf := OCILayoutStoreConf{}
+
+	storeConfigBytes, err := json.Marshal(storeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(storeConfigBytes, &conf); err != nil {
+		return nil, fmt.Errorf("failed to parse ocilayout store configuration: %w", err)
+	}
+
+	if conf.Path == "" {
+		return nil, fmt.Errorf("path of the OCI image layout is required")
+	}
+
+	maxBlobSize, maxManifestSize, err := conf.SizeLimits.Parse()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ocilayout store size limits: %w", err)
+	}
+
+	layout, err := loadLayout(context.Background(), conf.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OCI image layout at path %s: %w", conf.Path, err)
+	}
+
+	return &ociLayoutStore{
+		config:          &conf,
+		rawConfig:       config.StoreConfig{Version: version, Store: storeConfig},
+		layout:          layout,
+		maxBlobSize:     maxBlobSize,
+		maxManifestSize: maxManifestSize,
+	}, nil
+}
+
+// loadLayout opens an OCI image layout directory or an oci-archive tarball
+func loadLayout(ctx context.Context, path string) (*ocitarget.ReadOnlyStore, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ocitarget.NewFromFS(ctx, os.DirFS(path))
+	}
+	return ocitarget.NewFromTar(ctx, path)
+}
+
+func (store *ociLayoutStore) Name() string {
+	return storeName
+}
+
+func (store *ociLayoutStore) GetConfig() *config.StoreConfig {
+	return &store.rawConfig
+}
+
+// ListReferrers returns the manifests of the layout with the subject as their subject field, and the referrers
+// listed by the referrers tag schema. All referrers are returned in a single page.
+func (store *ociLayoutStore) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	if nextToken != "" {
+		return referrerstore.ListReferrersResult{}, fmt.Errorf("invalid continuation token: the ocilayout store does not support pagination")
+	}
+	if subjectDesc == nil {
+		var err error
+		if subjectDesc, err = store.GetSubjectDescriptor(ctx, subjectReference); err != nil {
+			return referrerstore.ListReferrersResult{}, err
+		}
+	}
+
+	found := map[digest.Digest]bool{}
+	referrers := []ocispecs.ReferenceDescriptor{}
+	addReferrer := func(desc oci.Descriptor) {
+		if found[desc.Digest] || !referrerstore.MatchesArtifactTypes(desc.ArtifactType, artifactTypes) {
+			return
+		}
+		found[desc.Digest] = true
+		referrers = append(referrers, ocispecs.ReferenceDescriptor{Descriptor: desc, ArtifactType: desc.ArtifactType})
+	}
+
+	predecessors, err := store.layout.Predecessors(ctx, subjectDesc.Descriptor)
+	if err != nil {
+		return referrerstore.ListReferrersResult{}, err
+	}
+	for _, predecessor := range predecessors {
+		referrer, err := store.fetchManifest(ctx, predecessor)
+		if err != nil {
+			return referrerstore.ListReferrersResult{}, err
+		}
+		// indexes listing the subject are predecessors but not referrers
+		if referrer.Subject == nil || referrer.Subject.Digest != subjectDesc.Digest {
+			continue
+		}
+		addReferrer(referrerDescriptor(predecessor, referrer))
+	}
+
+	tagged, err := store.listTaggedReferrers(ctx, subjectDesc.Digest)
+	if err != nil {
+		return referrerstore.ListReferrersResult{}, err
+	}
+	for _, desc := range tagged {
+		addReferrer(desc)
+	}
+
+	return referrerstore.ListReferrersResult{Referrers: referrers}, nil
+}
+
+// listTaggedReferrers returns the referrers in the index tagged with the referrers tag schema of the subject
+func (store *ociLayoutStore) listTaggedReferrers(ctx context.Context, subjectDigest digest.Digest) ([]oci.Descriptor, error) {
+	tag := strings.Replace(subjectDigest.String(), ":", "-", 1)
+	indexDesc, err := store.layout.Resolve(ctx, tag)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	index, err := store.fetchManifest(ctx, indexDesc)
+	if err != nil {
+		return nil, err
+	}
+	if index.MediaType != oci.MediaTypeImageIndex {
+		logrus.Warnf("ignoring referrers tag %s of media type %s", tag, index.MediaType)
+		return nil, nil
+	}
+	return index.Manifests, nil
+}
+
+func (store *ociLayoutStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+	return store.readBlob(ctx, digest, store.maxBlobSize)
+}
+
+func (store *ociLayoutStore) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	rc, err := store.layout.Fetch(ctx, oci.Descriptor{Digest: digest})
+	if err != nil {
+		return nil, err
+	}
+	// blobs of directory and tarball layouts are files, their size is checked before the blob is read
+	file, ok := rc.(fs.File)
+	if !ok {
+		rc.Close()
+		return nil, fmt.Errorf("blob %s: unable to determine the size of the blob in the OCI image layout", digest)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("blob %s: %w", digest, err)
+	}
+	if err := referrerstore.CheckSize(info.Size(), store.maxBlobSize); err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("blob %s: %w", digest, err)
+	}
+	// the content is checked against the digest once the blob is read to the end
+	return referrerstore.NewVerifyReader(rc, oci.Descriptor{Digest: digest, Size: info.Size()}), nil
+}
+
+func (store *ociLayoutStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	if err := referrerstore.CheckSize(referenceDesc.Size, store.maxManifestSize); err != nil {
+		return ocispecs.ReferenceManifest{}, fmt.Errorf("manifest %s: %w", referenceDesc.Digest, err)
+	}
+	manifestBytes, err := store.readBlob(ctx, referenceDesc.Digest, store.maxManifestSize)
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, err
+	}
+
+	referenceManifest := ocispecs.ReferenceManifest{}
+	switch referenceDesc.Descriptor.MediaType {
+	case oci.MediaTypeImageManifest:
+		var imageManifest oci.Manifest
+		if err := json.Unmarshal(manifestBytes, &imageManifest); err != nil {
+			return ocispecs.ReferenceManifest{}, err
+		}
+		referenceManifest = commonutils.OciManifestToReferenceManifest(imageManifest)
+	case oci.MediaTypeArtifactManifest:
+		if err := json.Unmarshal(manifestBytes, &referenceManifest); err != nil {
+			return ocispecs.ReferenceManifest{}, err
+		}
+	default:
+		return ocispecs.ReferenceManifest{}, fmt.Errorf("unsupported manifest media type: %s", referenceDesc.Descriptor.MediaType)
+	}
+	return referenceManifest, nil
+}
+
+// GetSubjectDescriptor resolves the subject by digest, then by the tag or full reference it is tagged with in the layout
+func (store *ociLayoutStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	references := []string{}
+	if subjectReference.Digest != "" {
+		references = append(references, subjectReference.Digest.String())
+	}
+	if subjectReference.Tag != "" {
+		references = append(references, subjectReference.Tag)
+	}
+	references = append(references, subjectReference.Original)
+
+	for _, reference := range references {
+		desc, err := store.layout.Resolve(ctx, reference)
+		if errors.Is(err, errdef.ErrNotFound) || errors.Is(err, errdef.ErrInvalidDigest) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if desc.MediaType == "application/octet-stream" {
+			// manifests not listed in the index of the layout are resolved as blobs
+			subject, err := store.fetchManifest(ctx, desc)
+			if err != nil {
+				return nil, err
+			}
+			desc.MediaType = subject.MediaType
+		}
+		return &ocispecs.SubjectDescriptor{Descriptor: desc}, nil
+	}
+	return nil, fmt.Errorf("subject %s not found in the OCI image layout %s: %w", subjectReference.Original, store.config.Path, errdef.ErrNotFound)
+}
+
+// readBlob reads a blob of at most limit bytes from the layout and verifies it matches its digest
+func (store *ociLayoutStore) readBlob(ctx context.Context, digest digest.Digest, limit int64) ([]byte, error) {
+	rc, err := store.layout.Fetch(ctx, oci.Descriptor{Digest: digest})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(referrerstore.NewLimitedReader(rc, limit))
+	if err != nil {
+		return nil, fmt.Errorf("blob %s: %w", digest, err)
+	}
+	if actual := digest.Algorithm().FromBytes(content); actual != digest {
+		return nil, fmt.Errorf("blob %s in the OCI image layout does not match its digest %s", digest, actual)
+	}
+	return content, nil
+}
+
+func (store *ociLayoutStore) fetchManifest(ctx context.Context, desc oci.Descriptor) (manifest, error) {
+	rc, err := store.layout.Fetch(ctx, desc)
+	if err != nil {
+		return manifest{}, err
+	}
+	defer rc.Close()
+
+	var m manifest
+	if err := json.NewDecoder(referrerstore.NewLimitedReader(rc, store.maxManifestSize)).Decode(&m); err != nil {
+		return manifest{}, fmt.Errorf("failed to decode manifest %s: %w", desc.Digest, err)
+	}
+	return m, nil
+}
+
+// referrerDescriptor returns the descriptor of a referrer as listed by the Referrers API
+func referrerDescriptor(desc oci.Descriptor, referrer manifest) oci.Descriptor {
+	desc.ArtifactType = referrer.ArtifactType
+	if desc.ArtifactType == "" && referrer.Config != nil {
+		desc.ArtifactType = referrer.Config.MediaType
+	}
+	desc.Annotations = referrer.Annotations
+	return desc
+}
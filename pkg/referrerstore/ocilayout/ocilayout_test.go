@@ -0,0 +1,320 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocilayout
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	ocitarget "oras.land/oras-go/v2/content/oci"
+
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/config"
+)
+
+const (
+	testArtifactTypeSignature = "application/vnd.cncf.notary.signature"
+	testArtifactTypeSbom      = "application/spdx+json"
+	testSubjectTag            = "v1"
+)
+
+type testLayout struct {
+	path      string
+	subject   oci.Descriptor
+	signature oci.Descriptor
+	sbom      oci.Descriptor
+	sbomBlob  []byte
+}
+
+// newTestLayout writes a layout with a subject, a signature referencing it through the subject field
+// and an sbom listed by the referrers tag schema
+func newTestLayout(t *testing.T) testLayout {
+	ctx := context.Background()
+	layout := testLayout{path: t.TempDir(), sbomBlob: []byte(`{"spdxVersion":"SPDX-2.3"}`)}
+	store, err := ocitarget.New(layout.path)
+	if err != nil {
+		t.Fatalf("failed to create layout: %v", err)
+	}
+	push := func(mediaType string, content []byte) oci.Descriptor {
+		desc := oci.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(content), Size: int64(len(content))}
+		if err := store.Push(ctx, desc, bytes.NewReader(content)); err != nil {
+			t.Fatalf("failed to push %s: %v", mediaType, err)
+		}
+		return desc
+	}
+	pushJSON := func(mediaType string, v interface{}) oci.Descriptor {
+		content, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("failed to marshal %s: %v", mediaType, err)
+		}
+		return push(mediaType, content)
+	}
+
+	emptyConfig := push("application/vnd.oci.image.config.v1+json", []byte("{}"))
+	layout.subject = pushJSON(oci.MediaTypeImageManifest, oci.Manifest{MediaType: oci.MediaTypeImageManifest, Config: emptyConfig, Layers: []oci.Descriptor{}})
+	if err := store.Tag(ctx, layout.subject, testSubjectTag); err != nil {
+		t.Fatalf("failed to tag subject: %v", err)
+	}
+
+	signatureConfig := push(testArtifactTypeSignature, []byte(`{"signature":true}`))
+	layout.signature = pushJSON(oci.MediaTypeImageManifest, oci.Manifest{
+		MediaType:   oci.MediaTypeImageManifest,
+		Config:      signatureConfig,
+		Layers:      []oci.Descriptor{push("application/jose+json", []byte("signature"))},
+		Subject:     &layout.subject,
+		Annotations: map[string]string{"io.cncf.notary.x509chain.thumbprint#S256": "[]"},
+	})
+
+	sbomBlob := push(testArtifactTypeSbom, layout.sbomBlob)
+	layout.sbom = pushJSON(oci.MediaTypeArtifactManifest, oci.Artifact{MediaType: oci.MediaTypeArtifactManifest, ArtifactType: testArtifactTypeSbom, Blobs: []oci.Descriptor{sbomBlob}})
+	layout.sbom.ArtifactType = testArtifactTypeSbom
+	referrersIndex := pushJSON(oci.MediaTypeImageIndex, oci.Index{MediaType: oci.MediaTypeImageIndex, Manifests: []oci.Descriptor{layout.sbom}})
+	if err := store.Tag(ctx, referrersIndex, strings.Replace(layout.subject.Digest.String(), ":", "-", 1)); err != nil {
+		t.Fatalf("failed to tag referrers index: %v", err)
+	}
+	return layout
+}
+
+// writeTestArchive writes the layout directory as an oci-archive tarball
+func writeTestArchive(t *testing.T, dir string) string {
+	archivePath := filepath.Join(t.TempDir(), "image.tar")
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	defer file.Close()
+	tw := tar.NewWriter(file)
+	defer tw.Close()
+
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(name), Mode: 0600, Size: int64(len(content))}); err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, bytes.NewReader(content))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	return archivePath
+}
+
+func createTestStore(t *testing.T, path string) referrerstore.ReferrerStore {
+	store, err := (&ociLayoutStoreFactory{}).Create("1.0.0", config.StorePluginConfig{"name": storeName, "path": path})
+	if err != nil {
+		t.Fatalf("failed to create ocilayout store: %v", err)
+	}
+	return store
+}
+
+func TestOCILayoutStore_ListReferrers(t *testing.T) {
+	layout := newTestLayout(t)
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{name: "layout directory", path: layout.path},
+		{name: "oci-archive tarball", path: writeTestArchive(t, layout.path)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := createTestStore(t, tc.path)
+			subjectRef := common.Reference{Original: "docker.io/library/image:" + testSubjectTag, Tag: testSubjectTag, Path: "docker.io/library/image"}
+
+			subjectDesc, err := store.GetSubjectDescriptor(ctx, subjectRef)
+			if err != nil {
+				t.Fatalf("failed to resolve subject: %v", err)
+			}
+			if subjectDesc.Digest != layout.subject.Digest || subjectDesc.MediaType != oci.MediaTypeImageManifest {
+				t.Fatalf("expected subject %v, got %v", layout.subject, subjectDesc.Descriptor)
+			}
+
+			result, err := store.ListReferrers(ctx, subjectRef, nil, "", nil)
+			if err != nil {
+				t.Fatalf("failed to list referrers: %v", err)
+			}
+			found := map[string]ocispecs.ReferenceDescriptor{}
+			for _, referrer := range result.Referrers {
+				found[referrer.ArtifactType] = referrer
+			}
+			if len(result.Referrers) != 2 || found[testArtifactTypeSignature].Digest != layout.signature.Digest || found[testArtifactTypeSbom].Digest != layout.sbom.Digest {
+				t.Fatalf("expected signature and sbom referrers, got %v", result.Referrers)
+			}
+			if found[testArtifactTypeSignature].Annotations["io.cncf.notary.x509chain.thumbprint#S256"] == "" {
+				t.Fatalf("expected referrer annotations from the manifest")
+			}
+
+			result, err = store.ListReferrers(ctx, subjectRef, []string{"*"}, "", subjectDesc)
+			if err != nil {
+				t.Fatalf("failed to list referrers: %v", err)
+			}
+			if len(result.Referrers) != 2 {
+				t.Fatalf("expected all referrers with the wildcard artifact type, got %v", result.Referrers)
+			}
+
+			result, err = store.ListReferrers(ctx, subjectRef, []string{testArtifactTypeSbom}, "", subjectDesc)
+			if err != nil {
+				t.Fatalf("failed to list referrers: %v", err)
+			}
+			if len(result.Referrers) != 1 || result.Referrers[0].ArtifactType != testArtifactTypeSbom {
+				t.Fatalf("expected only the sbom referrer, got %v", result.Referrers)
+			}
+
+			manifest, err := store.GetReferenceManifest(ctx, subjectRef, found[testArtifactTypeSbom])
+			if err != nil {
+				t.Fatalf("failed to get reference manifest: %v", err)
+			}
+			content, err := store.GetBlobContent(ctx, subjectRef, manifest.Blobs[0].Digest)
+			if err != nil {
+				t.Fatalf("failed to get blob content: %v", err)
+			}
+			if !bytes.Equal(content, layout.sbomBlob) {
+				t.Fatalf("expected sbom content %s, got %s", layout.sbomBlob, content)
+			}
+		})
+	}
+}
+
+func TestOCILayoutStore_GetSubjectDescriptor(t *testing.T) {
+	layout := newTestLayout(t)
+	store := createTestStore(t, layout.path)
+	ctx := context.Background()
+
+	desc, err := store.GetSubjectDescriptor(ctx, common.Reference{Original: "docker.io/library/image@" + layout.subject.Digest.String(), Digest: layout.subject.Digest})
+	if err != nil {
+		t.Fatalf("failed to resolve subject by digest: %v", err)
+	}
+	if desc.Digest != layout.subject.Digest {
+		t.Fatalf("expected subject %v, got %v", layout.subject.Digest, desc.Digest)
+	}
+
+	if _, err := store.GetSubjectDescriptor(ctx, common.Reference{Original: "docker.io/library/image:missing", Tag: "missing"}); err == nil {
+		t.Fatalf("expected error for a subject missing from the layout")
+	}
+}
+
+func TestOCILayoutStore_InvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name string
+		conf config.StorePluginConfig
+	}{
+		{name: "missing path", conf: config.StorePluginConfig{"name": storeName}},
+		{name: "path does not exist", conf: config.StorePluginConfig{"name": storeName, "path": filepath.Join(t.TempDir(), "missing")}},
+		{name: "not a layout", conf: config.StorePluginConfig{"name": storeName, "path": t.TempDir()}},
+		{name: "invalid max blob size", conf: config.StorePluginConfig{"name": storeName, "path": newTestLayout(t).path, "maxBlobSize": "large"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := (&ociLayoutStoreFactory{}).Create("1.0.0", tc.conf); err == nil {
+				t.Fatalf("expected error for invalid configuration")
+			}
+		})
+	}
+}
+
+func TestOCILayoutStore_SizeLimits(t *testing.T) {
+	ctx := context.Background()
+	layout := newTestLayout(t)
+	sbomDigest := digest.FromBytes(layout.sbomBlob)
+
+	rc, err := createTestStore(t, layout.path).GetBlobReader(ctx, common.Reference{}, sbomDigest)
+	if err != nil {
+		t.Fatalf("expected blob reader, got %v", err)
+	}
+	defer rc.Close()
+	if content, err := io.ReadAll(rc); err != nil || !bytes.Equal(content, layout.sbomBlob) {
+		t.Fatalf("expected streamed sbom %s, got %s and error %v", layout.sbomBlob, content, err)
+	}
+
+	store, err := (&ociLayoutStoreFactory{}).Create("1.0.0", config.StorePluginConfig{"name": storeName, "path": layout.path, "maxBlobSize": "16", "maxManifestSize": "64"})
+	if err != nil {
+		t.Fatalf("failed to create ocilayout store: %v", err)
+	}
+	if _, err := store.GetBlobContent(ctx, common.Reference{}, sbomDigest); !errors.Is(err, referrerstore.ErrMaxSizeExceeded) {
+		t.Fatalf("expected blob larger than the maximum size to be rejected, got %v", err)
+	}
+	if _, err := store.GetBlobReader(ctx, common.Reference{}, sbomDigest); !errors.Is(err, referrerstore.ErrMaxSizeExceeded) {
+		t.Fatalf("expected streamed blob larger than the maximum size to be rejected, got %v", err)
+	}
+	if _, err := store.GetReferenceManifest(ctx, common.Reference{}, ocispecs.ReferenceDescriptor{Descriptor: layout.sbom}); !errors.Is(err, referrerstore.ErrMaxSizeExceeded) {
+		t.Fatalf("expected manifest larger than the maximum size to be rejected, got %v", err)
+	}
+}
+
+// TestOCILayoutStore_GetBlobReader_VerifiesDigest tests that streamed blobs are checked against their digest
+func TestOCILayoutStore_GetBlobReader_VerifiesDigest(t *testing.T) {
+	ctx := context.Background()
+	layout := newTestLayout(t)
+	sbomDigest := digest.FromBytes(layout.sbomBlob)
+
+	rc, err := createTestStore(t, writeTestArchive(t, layout.path)).GetBlobReader(ctx, common.Reference{}, sbomDigest)
+	if err != nil {
+		t.Fatalf("expected blob reader of the archive, got %v", err)
+	}
+	defer rc.Close()
+	if content, err := io.ReadAll(rc); err != nil || !bytes.Equal(content, layout.sbomBlob) {
+		t.Fatalf("expected streamed sbom %s, got %s and error %v", layout.sbomBlob, content, err)
+	}
+
+	tampered := bytes.ToUpper(layout.sbomBlob)
+	if err := os.WriteFile(filepath.Join(layout.path, "blobs", sbomDigest.Algorithm().String(), sbomDigest.Encoded()), tampered, 0600); err != nil {
+		t.Fatalf("failed to tamper with the blob: %v", err)
+	}
+	rc, err = createTestStore(t, layout.path).GetBlobReader(ctx, common.Reference{}, sbomDigest)
+	if err != nil {
+		t.Fatalf("expected blob reader, got %v", err)
+	}
+	defer rc.Close()
+	if _, err := io.ReadAll(rc); !errors.Is(err, content.ErrMismatchedDigest) {
+		t.Fatalf("expected the tampered blob to be rejected, got %v", err)
+	}
+}
+
+func TestOCILayoutStore_Pagination(t *testing.T) {
+	layout := newTestLayout(t)
+	store := createTestStore(t, layout.path)
+	if _, err := store.ListReferrers(context.Background(), common.Reference{Original: testSubjectTag, Tag: testSubjectTag}, nil, "token", nil); err == nil {
+		t.Fatalf("expected error for a continuation token")
+	}
+}
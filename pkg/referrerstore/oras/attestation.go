@@ -0,0 +1,147 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+)
+
+const (
+	// DSSEEnvelopeMediaType is the media type of the layers of a cosign attestation
+	DSSEEnvelopeMediaType = "application/vnd.dsse.envelope.v1+json"
+	// InTotoPayloadType is the payload type of DSSE envelopes holding an in-toto statement
+	InTotoPayloadType = "application/vnd.in-toto+json"
+	// cosignPredicateTypeAnnotation is set by cosign on attestation layers
+	cosignPredicateTypeAnnotation = "predicateType"
+)
+
+// DSSEEnvelope is a Dead Simple Signing Envelope, see https://github.com/secure-systems-lab/dsse
+type DSSEEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	// Payload is the base64 encoded signed payload
+	Payload    string          `json:"payload"`
+	Signatures []DSSESignature `json:"signatures"`
+}
+
+// DSSESignature is a signature of the payload of a DSSE envelope
+type DSSESignature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   string `json:"sig"`
+}
+
+// InTotoStatement is an in-toto attestation statement, see https://github.com/in-toto/attestation
+type InTotoStatement struct {
+	Type          string          `json:"_type"`
+	PredicateType string          `json:"predicateType"`
+	Subject       []InTotoSubject `json:"subject"`
+	Predicate     json.RawMessage `json:"predicate,omitempty"`
+}
+
+// InTotoSubject is an artifact an in-toto statement is about
+type InTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// CosignAttestation is a layer of a cosign attestation unpacked into its DSSE envelope
+type CosignAttestation struct {
+	Descriptor oci.Descriptor
+	// PredicateType is the predicate type annotation of the layer, empty if cosign did not set it
+	PredicateType string
+	Envelope      DSSEEnvelope
+}
+
+// GetCosignAttestations fetches the layers of a cosign attestation listed by the referrer store
+// and unpacks them into DSSE envelopes. Layers that are not DSSE envelopes are skipped.
+func GetCosignAttestations(ctx context.Context, store referrerstore.ReferrerStore, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) ([]CosignAttestation, error) {
+	if referenceDesc.ArtifactType != CosignAttestationArtifactType {
+		return nil, fmt.Errorf("referrer %s of artifact type %s is not a cosign attestation", referenceDesc.Digest, referenceDesc.ArtifactType)
+	}
+	manifest, err := store.GetReferenceManifest(ctx, subjectReference, referenceDesc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cosign attestation manifest: %w", err)
+	}
+
+	attestations := []CosignAttestation{}
+	for _, layer := range manifest.Blobs {
+		if layer.MediaType != DSSEEnvelopeMediaType {
+			continue
+		}
+		content, err := store.GetBlobContent(ctx, subjectReference, layer.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cosign attestation layer %s: %w", layer.Digest, err)
+		}
+		envelope, err := ParseDSSEEnvelope(content)
+		if err != nil {
+			return nil, fmt.Errorf("cosign attestation layer %s: %w", layer.Digest, err)
+		}
+		attestations = append(attestations, CosignAttestation{
+			Descriptor:    layer,
+			PredicateType: layer.Annotations[cosignPredicateTypeAnnotation],
+			Envelope:      envelope,
+		})
+	}
+	return attestations, nil
+}
+
+// ParseDSSEEnvelope parses a DSSE envelope in its JSON serialization
+func ParseDSSEEnvelope(content []byte) (DSSEEnvelope, error) {
+	var envelope DSSEEnvelope
+	if err := json.Unmarshal(content, &envelope); err != nil {
+		return DSSEEnvelope{}, fmt.Errorf("failed to parse DSSE envelope: %w", err)
+	}
+	if envelope.PayloadType == "" || envelope.Payload == "" {
+		return DSSEEnvelope{}, fmt.Errorf("invalid DSSE envelope: payload and payload type are required")
+	}
+	return envelope, nil
+}
+
+// DecodePayload returns the signed payload of the envelope
+func (envelope DSSEEnvelope) DecodePayload() ([]byte, error) {
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		// the DSSE spec allows URL safe encoding of the payload
+		if payload, err = base64.URLEncoding.DecodeString(envelope.Payload); err != nil {
+			return nil, fmt.Errorf("failed to decode DSSE payload: %w", err)
+		}
+	}
+	return payload, nil
+}
+
+// InTotoStatement returns the in-toto statement signed by the envelope
+func (envelope DSSEEnvelope) InTotoStatement() (InTotoStatement, error) {
+	if envelope.PayloadType != InTotoPayloadType {
+		return InTotoStatement{}, fmt.Errorf("DSSE payload type %s is not an in-toto statement", envelope.PayloadType)
+	}
+	payload, err := envelope.DecodePayload()
+	if err != nil {
+		return InTotoStatement{}, err
+	}
+	var statement InTotoStatement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return InTotoStatement{}, fmt.Errorf("failed to parse in-toto statement: %w", err)
+	}
+	return statement, nil
+}
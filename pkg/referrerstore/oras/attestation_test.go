@@ -0,0 +1,166 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/config"
+)
+
+const testPredicateType = "https://slsa.dev/provenance/v0.2"
+
+// attestationTestStore serves a single reference manifest and its blobs
+type attestationTestStore struct {
+	manifest ocispecs.ReferenceManifest
+	blobs    map[digest.Digest][]byte
+}
+
+func (s *attestationTestStore) Name() string {
+	return "attestationTestStore"
+}
+
+func (s *attestationTestStore) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	return referrerstore.ListReferrersResult{}, nil
+}
+
+func (s *attestationTestStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+	if content, ok := s.blobs[digest]; ok {
+		return content, nil
+	}
+	return nil, fmt.Errorf("blob %s not found", digest)
+}
+
+func (s *attestationTestStore) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	content, err := s.GetBlobContent(ctx, subjectReference, digest)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (s *attestationTestStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	return s.manifest, nil
+}
+
+func (s *attestationTestStore) GetConfig() *config.StoreConfig {
+	return &config.StoreConfig{}
+}
+
+func (s *attestationTestStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func newTestEnvelope(t *testing.T, payloadType string, payload interface{}) []byte {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("failed to marshal payload: %v", err)
+	}
+	envelope, err := json.Marshal(DSSEEnvelope{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payloadBytes),
+		Signatures:  []DSSESignature{{Sig: "c2lnbmF0dXJl"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+	return envelope
+}
+
+func TestGetCosignAttestations(t *testing.T) {
+	statement := InTotoStatement{
+		Type:          "https://in-toto.io/Statement/v0.1",
+		PredicateType: testPredicateType,
+		Subject:       []InTotoSubject{{Name: "localhost:5000/net-monitor", Digest: map[string]string{"sha256": testSubjectDigest.Hex()}}},
+		Predicate:     json.RawMessage(`{"builder":{"id":"https://github.com/actions"}}`),
+	}
+	envelope := newTestEnvelope(t, InTotoPayloadType, statement)
+	envelopeDigest := digest.FromBytes(envelope)
+	signature := []byte("signature")
+	store := &attestationTestStore{
+		manifest: ocispecs.ReferenceManifest{
+			MediaType: oci.MediaTypeImageManifest,
+			Blobs: []oci.Descriptor{
+				{MediaType: DSSEEnvelopeMediaType, Digest: envelopeDigest, Annotations: map[string]string{"predicateType": testPredicateType}},
+				{MediaType: "application/vnd.dev.cosign.simplesigning.v1+json", Digest: digest.FromBytes(signature)},
+			},
+		},
+		blobs: map[digest.Digest][]byte{envelopeDigest: envelope},
+	}
+	referenceDesc := ocispecs.ReferenceDescriptor{ArtifactType: CosignAttestationArtifactType}
+
+	attestations, err := GetCosignAttestations(context.Background(), store, common.Reference{}, referenceDesc)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(attestations) != 1 || attestations[0].PredicateType != testPredicateType || attestations[0].Descriptor.Digest != envelopeDigest {
+		t.Fatalf("expected the DSSE envelope layer, got %v", attestations)
+	}
+
+	actual, err := attestations[0].Envelope.InTotoStatement()
+	if err != nil {
+		t.Fatalf("expected in-toto statement, got %v", err)
+	}
+	if actual.PredicateType != testPredicateType || len(actual.Subject) != 1 || actual.Subject[0].Digest["sha256"] != testSubjectDigest.Hex() {
+		t.Fatalf("unexpected in-toto statement %v", actual)
+	}
+
+	if _, err := GetCosignAttestations(context.Background(), store, common.Reference{}, ocispecs.ReferenceDescriptor{ArtifactType: CosignArtifactType}); err == nil {
+		t.Fatalf("expected error for a referrer that is not a cosign attestation")
+	}
+}
+
+func TestParseDSSEEnvelope(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         []byte
+		expectErr       bool
+		expectStatement bool
+	}{
+		{name: "in-toto statement", content: newTestEnvelope(t, InTotoPayloadType, InTotoStatement{PredicateType: testPredicateType}), expectStatement: true},
+		{name: "other payload type", content: newTestEnvelope(t, "application/vnd.example+json", map[string]string{}), expectStatement: false},
+		{name: "url safe payload", content: []byte(`{"payloadType":"` + InTotoPayloadType + `","payload":"` + base64.URLEncoding.EncodeToString([]byte(`{"predicateType":">>>"}`)) + `"}`), expectStatement: true},
+		{name: "missing payload", content: []byte(`{"payloadType":"` + InTotoPayloadType + `"}`), expectErr: true},
+		{name: "not json", content: []byte("envelope"), expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			envelope, err := ParseDSSEEnvelope(tc.content)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if _, err := envelope.InTotoStatement(); tc.expectStatement != (err == nil) {
+				t.Fatalf("expected in-toto statement %v, got error %v", tc.expectStatement, err)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -103,7 +104,7 @@ func createCachedStore(storeBase referrerstore.ReferrerStore, cacheConf *cacheCo
 }
 
 func (store *orasStoreWithInMemoryCache) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
-	val, found := memoryCache.Get(getCacheKey(operationListReferrers, subjectReference))
+	val, found := memoryCache.Get(getListReferrersCacheKey(subjectReference, artifactTypes, nextToken))
 	if found {
 		if result, ok := val.(referrerstore.ListReferrersResult); ok {
 			return result, nil
@@ -112,7 +113,7 @@ func (store *orasStoreWithInMemoryCache) ListReferrers(ctx context.Context, subj
 
 	result, err := store.ReferrerStore.ListReferrers(ctx, subjectReference, artifactTypes, nextToken, subjectDesc)
 	if err == nil {
-		if added := memoryCache.SetWithTTL(getCacheKey(operationListReferrers, subjectReference), result, 1, time.Duration(store.cacheConf.TTL)*time.Second); !added {
+		if added := memoryCache.SetWithTTL(getListReferrersCacheKey(subjectReference, artifactTypes, nextToken), result, 1, time.Duration(store.cacheConf.TTL)*time.Second); !added {
 			logrus.WithContext(ctx).Warnf("failed to add cache with key: %+v, val: %+v", subjectReference, result)
 		}
 	}
@@ -147,3 +148,8 @@ func toCacheConfig(storePluginConfig map[string]interface{}) (*cacheConf, error)
 func getCacheKey(op operation, ref common.Reference) string {
 	return fmt.Sprintf("%d+%s@%s", op, ref.Path, ref.Digest)
 }
+
+// getListReferrersCacheKey returns the key of a referrers page, pages differ by the artifact types listed and the continuation token
+func getListReferrersCacheKey(ref common.Reference, artifactTypes []string, nextToken string) string {
+	return fmt.Sprintf("%s+%s+%s", getCacheKey(operationListReferrers, ref), strings.Join(referrerstore.ArtifactTypeFilters(artifactTypes), ","), nextToken)
+}
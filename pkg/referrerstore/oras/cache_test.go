@@ -16,7 +16,9 @@ limitations under the License.
 package oras
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"reflect"
 	"testing"
 	"time"
@@ -71,7 +73,9 @@ var (
 	testNextToken2 = "2"
 )
 
-type mockBase struct{}
+type mockBase struct {
+	listReferrersCalls int
+}
 
 func (m *mockBase) Name() string {
 	return testName
@@ -82,6 +86,7 @@ func (m *mockBase) GetConfig() *config.StoreConfig {
 }
 
 func (m *mockBase) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	m.listReferrersCalls++
 	if nextToken == testNextToken1 {
 		return testResult1, nil
 	} else if nextToken == testNextToken2 {
@@ -94,6 +99,10 @@ func (m *mockBase) GetBlobContent(ctx context.Context, subjectReference common.R
 	return testBlob, nil
 }
 
+func (m *mockBase) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(testBlob)), nil
+}
+
 func (m *mockBase) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
 	return ocispecs.ReferenceManifest{}, nil
 }
@@ -154,16 +163,36 @@ func TestListReferrers_CacheHit(t *testing.T) {
 	store, _ := createCachedStore(base, conf)
 
 	result, _ := store.ListReferrers(context.Background(), testReference, []string{}, testNextToken1, nil)
+	calls := base.listReferrersCalls
 
 	time.Sleep(time.Duration(ttl-5) * time.Second)
 
-	cachedResult, err := store.ListReferrers(context.Background(), testReference, []string{}, testNextToken2, nil)
+	cachedResult, err := store.ListReferrers(context.Background(), testReference, []string{}, testNextToken1, nil)
 	if err != nil {
 		t.Fatalf("err should be nil, but got %v", err)
 	}
 	if !reflect.DeepEqual(result, cachedResult) {
 		t.Fatalf("cached result: %+v is different from result: %+v", cachedResult, result)
 	}
+	if base.listReferrersCalls != calls {
+		t.Fatalf("expected cached result to be returned without listing referrers")
+	}
+}
+
+func TestListReferrers_CacheKeyedByPage(t *testing.T) {
+	store, _ := createCachedStore(base, conf)
+
+	firstPage, _ := store.ListReferrers(context.Background(), testReference, []string{}, testNextToken1, nil)
+	secondPage, err := store.ListReferrers(context.Background(), testReference, []string{}, testNextToken2, nil)
+	if err != nil {
+		t.Fatalf("err should be nil, but got %v", err)
+	}
+	if !reflect.DeepEqual(secondPage, testResult2) {
+		t.Fatalf("expected page %+v for the second token, got %+v", testResult2, secondPage)
+	}
+	if reflect.DeepEqual(firstPage, secondPage) {
+		t.Fatalf("pages of different continuation tokens must not share a cache entry")
+	}
 }
 
 func TestListReferrers_CacheMiss(t *testing.T) {
@@ -20,59 +20,75 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/deislabs/ratify/pkg/common"
 	"github.com/deislabs/ratify/pkg/ocispecs"
-	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/deislabs/ratify/pkg/referrerstore"
 
-	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote/errcode"
 )
 
-const CosignArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
-const CosignSignatureTagSuffix = ".sig"
+const CosignArtifactType = referrerstore.CosignSignatureArtifactType
+const CosignAttestationArtifactType = "application/vnd.dev.cosign.artifact.att.v1+json"
+const CosignSBOMArtifactType = "application/vnd.dev.cosign.artifact.sbom.v1+json"
+const CosignSignatureTagSuffix = referrerstore.CosignSignatureTagSuffix
+const CosignAttestationTagSuffix = ".att"
+const CosignSBOMTagSuffix = ".sbom"
 
 var ErrNoCosignSubjectDigest = errors.New("failed to mutate cosign image tag: no digest specified for subject")
 
-func getCosignReferences(ctx context.Context, subjectReference common.Reference, store *orasStore, repository registry.Repository) (*[]ocispecs.ReferenceDescriptor, error) {
-	var references []ocispecs.ReferenceDescriptor
-	signatureTag, err := attachedImageTag(subjectReference, CosignSignatureTagSuffix)
-	if err != nil {
-		return nil, err
-	}
+// cosignAttachments are the tag suffixes cosign attaches content to a subject with, and the artifact types they are reported as
+var cosignAttachments = []struct {
+	tagSuffix    string
+	artifactType string
+}{
+	{tagSuffix: CosignSignatureTagSuffix, artifactType: CosignArtifactType},
+	{tagSuffix: CosignAttestationTagSuffix, artifactType: CosignAttestationArtifactType},
+	{tagSuffix: CosignSBOMTagSuffix, artifactType: CosignSBOMArtifactType},
+}
 
-	desc, err := repository.Resolve(ctx, signatureTag)
-	if err != nil {
-		if errors.Is(err, errdef.ErrNotFound) {
-			return nil, nil
+// getCosignReferences returns the cosign signature, attestation and SBOM attached to the subject with one of artifactTypes
+func getCosignReferences(ctx context.Context, subjectReference common.Reference, artifactTypes []string, store *orasStore, repository registry.Repository) (*[]ocispecs.ReferenceDescriptor, error) {
+	var references []ocispecs.ReferenceDescriptor
+	for _, attachment := range cosignAttachments {
+		if !referrerstore.MatchesArtifactTypes(attachment.artifactType, artifactTypes) {
+			continue
 		}
-		var ec errcode.Error
-		if errors.As(err, &ec) && (ec.Code == fmt.Sprint(http.StatusForbidden) || ec.Code == fmt.Sprint(http.StatusUnauthorized)) {
-			store.evictAuthCache(subjectReference.Original, err)
+		attachmentTag, err := attachedImageTag(subjectReference, attachment.tagSuffix)
+		if err != nil {
 			return nil, err
 		}
-		return nil, err
-	}
 
-	references = append(references, ocispecs.ReferenceDescriptor{
-		ArtifactType: CosignArtifactType,
-		Descriptor: oci.Descriptor{
-			MediaType: desc.MediaType,
-			Digest:    desc.Digest,
-			Size:      desc.Size,
-		},
-	})
+		desc, found, err := referrerstore.ResolveCosignAttachment(ctx, repository, attachmentTag)
+		if err != nil {
+			var ec errcode.Error
+			if errors.As(err, &ec) && (ec.Code == fmt.Sprint(http.StatusForbidden) || ec.Code == fmt.Sprint(http.StatusUnauthorized)) {
+				store.evictAuthCache(subjectReference.Original, err)
+				return nil, err
+			}
+			return nil, err
+		}
+		if !found {
+			continue
+		}
 
+		references = append(references, ocispecs.ReferenceDescriptor{
+			ArtifactType: attachment.artifactType,
+			DiscoveredBy: ReferrersDiscoveryCosignTag,
+			Descriptor:   desc,
+		})
+	}
+
+	if references == nil {
+		return nil, nil
+	}
 	return &references, nil
 }
 
 func attachedImageTag(subjectReference common.Reference, tagSuffix string) (string, error) {
-	// sha256:d34db33f -> sha256-d34db33f.suffix
 	if subjectReference.Digest.String() == "" {
 		return "", ErrNoCosignSubjectDigest
 	}
-	tagStr := strings.ReplaceAll(subjectReference.Digest.String(), ":", "-") + tagSuffix
-	return fmt.Sprintf("%s:%s", subjectReference.Path, tagStr), nil
+	return fmt.Sprintf("%s:%s", subjectReference.Path, referrerstore.CosignAttachmentTag(subjectReference.Digest, tagSuffix)), nil
 }
@@ -74,13 +74,27 @@ func TestGetCosignReferences(t *testing.T) {
 	testSubjectDigest := digest.FromString("test")
 	testCosignSubjectTag := fmt.Sprintf("%s-%s.sig", testSubjectDigest.Algorithm().String(), testSubjectDigest.Hex())
 	testCosignImageDigest := digest.FromString("test_cosign")
+	testCosignAttestationDigest := digest.FromString("test_cosign_attestation")
+	testCosignSBOMDigest := digest.FromString("test_cosign_sbom")
+	testCosignAttachments := map[string]oci.Descriptor{
+		fmt.Sprintf("localhost:5000/net-monitor:%s", testCosignSubjectTag): {
+			Digest: testCosignImageDigest,
+		},
+		fmt.Sprintf("localhost:5000/net-monitor:%s-%s.att", testSubjectDigest.Algorithm().String(), testSubjectDigest.Hex()): {
+			Digest: testCosignAttestationDigest,
+		},
+		fmt.Sprintf("localhost:5000/net-monitor:%s-%s.sbom", testSubjectDigest.Algorithm().String(), testSubjectDigest.Hex()): {
+			Digest: testCosignSBOMDigest,
+		},
+	}
 	testcases := []struct {
-		name       string
-		subjectRef common.Reference
-		store      *orasStore
-		repository registry.Repository
-		output     *[]ocispecs.ReferenceDescriptor
-		err        error
+		name          string
+		subjectRef    common.Reference
+		artifactTypes []string
+		store         *orasStore
+		repository    registry.Repository
+		output        *[]ocispecs.ReferenceDescriptor
+		err           error
 	}{
 		{
 			name: "no subject digest",
@@ -132,6 +146,66 @@ func TestGetCosignReferences(t *testing.T) {
 						Digest: testCosignImageDigest,
 					},
 					ArtifactType: CosignArtifactType,
+					DiscoveredBy: ReferrersDiscoveryCosignTag,
+				},
+			},
+			err: nil,
+		},
+		{
+			name: "cosign signature, attestation and sbom",
+			subjectRef: common.Reference{
+				Path:   "localhost:5000/net-monitor",
+				Tag:    "v1",
+				Digest: testSubjectDigest,
+			},
+			store: &orasStore{},
+			repository: mocks.TestRepository{
+				ResolveMap: testCosignAttachments,
+			},
+			output: &[]ocispecs.ReferenceDescriptor{
+				{
+					Descriptor: oci.Descriptor{
+						Digest: testCosignImageDigest,
+					},
+					ArtifactType: CosignArtifactType,
+					DiscoveredBy: ReferrersDiscoveryCosignTag,
+				},
+				{
+					Descriptor: oci.Descriptor{
+						Digest: testCosignAttestationDigest,
+					},
+					ArtifactType: CosignAttestationArtifactType,
+					DiscoveredBy: ReferrersDiscoveryCosignTag,
+				},
+				{
+					Descriptor: oci.Descriptor{
+						Digest: testCosignSBOMDigest,
+					},
+					ArtifactType: CosignSBOMArtifactType,
+					DiscoveredBy: ReferrersDiscoveryCosignTag,
+				},
+			},
+			err: nil,
+		},
+		{
+			name: "cosign attestation requested",
+			subjectRef: common.Reference{
+				Path:   "localhost:5000/net-monitor",
+				Tag:    "v1",
+				Digest: testSubjectDigest,
+			},
+			artifactTypes: []string{CosignAttestationArtifactType},
+			store:         &orasStore{},
+			repository: mocks.TestRepository{
+				ResolveMap: testCosignAttachments,
+			},
+			output: &[]ocispecs.ReferenceDescriptor{
+				{
+					Descriptor: oci.Descriptor{
+						Digest: testCosignAttestationDigest,
+					},
+					ArtifactType: CosignAttestationArtifactType,
+					DiscoveredBy: ReferrersDiscoveryCosignTag,
 				},
 			},
 			err: nil,
@@ -139,7 +213,7 @@ func TestGetCosignReferences(t *testing.T) {
 	}
 	for _, testcase := range testcases {
 		t.Run(testcase.name, func(t *testing.T) {
-			refs, err := getCosignReferences(ctx, testcase.subjectRef, testcase.store, testcase.repository)
+			refs, err := getCosignReferences(ctx, testcase.subjectRef, testcase.artifactTypes, testcase.store, testcase.repository)
 			if !errors.Is(err, testcase.err) {
 				t.Fatalf("test case: %s; expected error to be %v, but got %v", testcase.name, testcase.err, err)
 			}
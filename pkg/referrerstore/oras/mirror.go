@@ -0,0 +1,321 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+
+	"github.com/deislabs/ratify/pkg/common/oras/authprovider"
+)
+
+// RegistryMirror describes an endpoint serving the content of a registry, like a registry mirror or a pull-through cache
+type RegistryMirror struct {
+	// host and optional port of the mirror
+	Endpoint string `json:"endpoint"`
+	// RepositoryPrefix is prepended to repositories, e.g. the project of a Harbor proxy cache
+	RepositoryPrefix string `json:"repositoryPrefix,omitempty"`
+	RegistryTLSConfig
+	AuthProvider *authprovider.AuthProviderConfig `json:"authProvider,omitempty"`
+}
+
+// mirrorEndpoint is a configured mirror with the auth provider and http client used to access it
+type mirrorEndpoint struct {
+	RegistryMirror
+	authProvider authprovider.AuthProvider
+	// client is only set if the mirror has custom TLS settings
+	client *http.Client
+}
+
+// endpointRepository is a repository on one of the endpoints serving the upstream repository
+type endpointRepository struct {
+	*remote.Repository
+	upstream registry.Reference
+}
+
+// mirrorRepository reads content through an ordered list of endpoints, falling back to the next endpoint on failure.
+// The upstream registry is always the last endpoint. Write operations are only sent to the upstream registry.
+type mirrorRepository struct {
+	endpoints []endpointRepository
+}
+
+// createMirrorEndpoints creates the auth providers and http clients of the mirrors that do not use the ones of the store
+func createMirrorEndpoints(mirrors map[string][]RegistryMirror, transport *registryTransport) (map[string][]mirrorEndpoint, error) {
+	endpoints := map[string][]mirrorEndpoint{}
+	for host, hostMirrors := range mirrors {
+		for _, mirror := range hostMirrors {
+			if strings.TrimSpace(mirror.Endpoint) == "" {
+				return nil, fmt.Errorf("mirror endpoint of registry %s is not set", host)
+			}
+			endpoint := mirrorEndpoint{RegistryMirror: mirror}
+			if mirror.AuthProvider != nil {
+				provider, err := authprovider.CreateAuthProviderFromConfig(*mirror.AuthProvider)
+				if err != nil {
+					return nil, fmt.Errorf("failed to create auth provider of mirror %s: %w", mirror.Endpoint, err)
+				}
+				endpoint.authProvider = provider
+			}
+			if mirror.hasCustomTLS() {
+				client, err := createTLSClient(mirror.RegistryTLSConfig, transport)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load TLS configuration of mirror %s: %w", mirror.Endpoint, err)
+				}
+				endpoint.client = client
+			}
+			endpoints[host] = append(endpoints[host], endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+// mirrorReference returns the reference of the upstream reference on the mirror
+func (mirror RegistryMirror) mirrorReference(upstream registry.Reference) registry.Reference {
+	return registry.Reference{
+		Registry:   strings.TrimSuffix(mirror.Endpoint, "/"),
+		Repository: path.Join(strings.Trim(mirror.RepositoryPrefix, "/"), upstream.Repository),
+		Reference:  upstream.Reference,
+	}
+}
+
+// rewrite returns the reference on the endpoint if reference targets the upstream repository
+func (r endpointRepository) rewrite(reference string) string {
+	ref, err := registry.ParseReference(reference)
+	if err != nil || ref.Registry != r.upstream.Registry || ref.Repository != r.upstream.Repository {
+		return reference
+	}
+	ref.Registry = r.Reference.Registry
+	ref.Repository = r.Reference.Repository
+	return ref.String()
+}
+
+// tryEndpoints runs op on each endpoint in order and returns the first successful result
+func tryEndpoints[T any](ctx context.Context, endpoints []endpointRepository, op func(endpoint endpointRepository) (T, error)) (T, error) {
+	var result T
+	var err error
+	for _, endpoint := range endpoints {
+		if result, err = op(endpoint); err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return result, err
+		}
+		logrus.Debugf("registry endpoint %s failed, trying next endpoint: %v", endpoint.Reference.Registry, err)
+	}
+	return result, err
+}
+
+func (m *mirrorRepository) upstream() endpointRepository {
+	return m.endpoints[len(m.endpoints)-1]
+}
+
+func (m *mirrorRepository) Fetch(ctx context.Context, target oci.Descriptor) (io.ReadCloser, error) {
+	return tryEndpoints(ctx, m.endpoints, func(endpoint endpointRepository) (io.ReadCloser, error) {
+		return endpoint.Fetch(ctx, target)
+	})
+}
+
+func (m *mirrorRepository) Exists(ctx context.Context, target oci.Descriptor) (bool, error) {
+	return tryEndpoints(ctx, m.endpoints, func(endpoint endpointRepository) (bool, error) {
+		return endpoint.Exists(ctx, target)
+	})
+}
+
+func (m *mirrorRepository) Resolve(ctx context.Context, reference string) (oci.Descriptor, error) {
+	return tryEndpoints(ctx, m.endpoints, func(endpoint endpointRepository) (oci.Descriptor, error) {
+		return endpoint.Resolve(ctx, endpoint.rewrite(reference))
+	})
+}
+
+func (m *mirrorRepository) FetchReference(ctx context.Context, reference string) (oci.Descriptor, io.ReadCloser, error) {
+	return fetchReference(ctx, m.endpoints, reference, func(endpoint endpointRepository) registry.ReferenceFetcher {
+		return endpoint.Repository
+	})
+}
+
+func (m *mirrorRepository) Referrers(ctx context.Context, desc oci.Descriptor, artifactType string, fn func(referrers []oci.Descriptor) error) error {
+	// referrers are buffered so fn is not invoked with the partial result of a failed endpoint
+	referrers, err := tryEndpoints(ctx, m.endpoints, func(endpoint endpointRepository) ([]oci.Descriptor, error) {
+		var referrers []oci.Descriptor
+		err := endpoint.Referrers(ctx, desc, artifactType, func(page []oci.Descriptor) error {
+			referrers = append(referrers, page...)
+			return nil
+		})
+		return referrers, err
+	})
+	if err != nil || len(referrers) == 0 {
+		return err
+	}
+	return fn(referrers)
+}
+
+func (m *mirrorRepository) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	tags, err := tryEndpoints(ctx, m.endpoints, func(endpoint endpointRepository) ([]string, error) {
+		var tags []string
+		err := endpoint.Tags(ctx, last, func(page []string) error {
+			tags = append(tags, page...)
+			return nil
+		})
+		return tags, err
+	})
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+	return fn(tags)
+}
+
+// referrersPage lists a page of referrers from the first endpoint that succeeds, or from the endpoint serving the link of a previous page
+func (m *mirrorRepository) referrersPage(ctx context.Context, discovery *referrersDiscovery, subjectDesc oci.Descriptor, filter string, link string) ([]oci.Descriptor, string, string, error) {
+	type page struct {
+		referrers    []oci.Descriptor
+		link         string
+		discoveredBy string
+	}
+
+	endpoints := m.endpoints
+	if link != "" {
+		linkURL, err := url.Parse(link)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("invalid continuation token: %w", err)
+		}
+		endpoints = nil
+		for _, endpoint := range m.endpoints {
+			if endpoint.Reference.Host() == linkURL.Host {
+				endpoints = []endpointRepository{endpoint}
+				break
+			}
+		}
+		if endpoints == nil {
+			return nil, "", "", fmt.Errorf("invalid continuation token: host %s is not an endpoint of registry %s", linkURL.Host, m.upstream().upstream.Registry)
+		}
+	}
+
+	result, err := tryEndpoints(ctx, endpoints, func(endpoint endpointRepository) (page, error) {
+		referrers, next, discoveredBy, err := discovery.fetchPage(ctx, endpoint.Repository, subjectDesc, filter, link)
+		return page{referrers: referrers, link: next, discoveredBy: discoveredBy}, err
+	})
+	return result.referrers, result.link, result.discoveredBy, err
+}
+
+func (m *mirrorRepository) Push(ctx context.Context, expected oci.Descriptor, content io.Reader) error {
+	return m.upstream().Push(ctx, expected, content)
+}
+
+func (m *mirrorRepository) Delete(ctx context.Context, target oci.Descriptor) error {
+	return m.upstream().Delete(ctx, target)
+}
+
+func (m *mirrorRepository) Tag(ctx context.Context, desc oci.Descriptor, reference string) error {
+	return m.upstream().Tag(ctx, desc, reference)
+}
+
+func (m *mirrorRepository) PushReference(ctx context.Context, expected oci.Descriptor, content io.Reader, reference string) error {
+	return m.upstream().PushReference(ctx, expected, content, reference)
+}
+
+func (m *mirrorRepository) Blobs() registry.BlobStore {
+	return &mirrorBlobStore{
+		endpoints: m.endpoints,
+		store: func(endpoint endpointRepository) registry.BlobStore {
+			return endpoint.Blobs()
+		},
+	}
+}
+
+func (m *mirrorRepository) Manifests() registry.ManifestStore {
+	return &mirrorManifestStore{
+		mirrorBlobStore: mirrorBlobStore{
+			endpoints: m.endpoints,
+			store: func(endpoint endpointRepository) registry.BlobStore {
+				return endpoint.Manifests()
+			},
+		},
+		upstream: m.upstream().Manifests(),
+	}
+}
+
+// mirrorBlobStore reads from the blob or manifest store of each endpoint in order
+type mirrorBlobStore struct {
+	endpoints []endpointRepository
+	store     func(endpoint endpointRepository) registry.BlobStore
+}
+
+func (s *mirrorBlobStore) Fetch(ctx context.Context, target oci.Descriptor) (io.ReadCloser, error) {
+	return tryEndpoints(ctx, s.endpoints, func(endpoint endpointRepository) (io.ReadCloser, error) {
+		return s.store(endpoint).Fetch(ctx, target)
+	})
+}
+
+func (s *mirrorBlobStore) Exists(ctx context.Context, target oci.Descriptor) (bool, error) {
+	return tryEndpoints(ctx, s.endpoints, func(endpoint endpointRepository) (bool, error) {
+		return s.store(endpoint).Exists(ctx, target)
+	})
+}
+
+func (s *mirrorBlobStore) Resolve(ctx context.Context, reference string) (oci.Descriptor, error) {
+	return tryEndpoints(ctx, s.endpoints, func(endpoint endpointRepository) (oci.Descriptor, error) {
+		return s.store(endpoint).Resolve(ctx, endpoint.rewrite(reference))
+	})
+}
+
+func (s *mirrorBlobStore) FetchReference(ctx context.Context, reference string) (oci.Descriptor, io.ReadCloser, error) {
+	return fetchReference(ctx, s.endpoints, reference, func(endpoint endpointRepository) registry.ReferenceFetcher {
+		return s.store(endpoint)
+	})
+}
+
+func (s *mirrorBlobStore) Push(ctx context.Context, expected oci.Descriptor, content io.Reader) error {
+	return s.store(s.endpoints[len(s.endpoints)-1]).Push(ctx, expected, content)
+}
+
+func (s *mirrorBlobStore) Delete(ctx context.Context, target oci.Descriptor) error {
+	return s.store(s.endpoints[len(s.endpoints)-1]).Delete(ctx, target)
+}
+
+// mirrorManifestStore reads manifests through the endpoints and writes them to the upstream registry
+type mirrorManifestStore struct {
+	mirrorBlobStore
+	upstream registry.ManifestStore
+}
+
+func (s *mirrorManifestStore) Tag(ctx context.Context, desc oci.Descriptor, reference string) error {
+	return s.upstream.Tag(ctx, desc, reference)
+}
+
+func (s *mirrorManifestStore) PushReference(ctx context.Context, expected oci.Descriptor, content io.Reader, reference string) error {
+	return s.upstream.PushReference(ctx, expected, content, reference)
+}
+
+func fetchReference(ctx context.Context, endpoints []endpointRepository, reference string, fetcher func(endpoint endpointRepository) registry.ReferenceFetcher) (oci.Descriptor, io.ReadCloser, error) {
+	type fetched struct {
+		desc oci.Descriptor
+		rc   io.ReadCloser
+	}
+	result, err := tryEndpoints(ctx, endpoints, func(endpoint endpointRepository) (fetched, error) {
+		desc, rc, err := fetcher(endpoint).FetchReference(ctx, endpoint.rewrite(reference))
+		return fetched{desc: desc, rc: rc}, err
+	})
+	return result.desc, result.rc, err
+}
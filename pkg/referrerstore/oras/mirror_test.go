@@ -0,0 +1,222 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+const (
+	testMirrorUpstream = "registry.test"
+	testMirrorPrefix   = "proxy"
+)
+
+var testMirrorBlob = []byte("blob content")
+
+// newTestContentRegistry serves a manifest tagged v1 and a blob in repository, all other requests fail with status
+func newTestContentRegistry(t *testing.T, repository string, status int) (*httptest.Server, *[]string) {
+	requests := []string{}
+	manifest := []byte(`{"schemaVersion":2}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		var content []byte
+		mediaType := "application/octet-stream"
+		switch r.URL.Path {
+		case fmt.Sprintf("/v2/%s/manifests/v1", repository), fmt.Sprintf("/v2/%s/manifests/%s", repository, digest.FromBytes(manifest)):
+			content, mediaType = manifest, oci.MediaTypeImageManifest
+		case fmt.Sprintf("/v2/%s/blobs/%s", repository, digest.FromBytes(testMirrorBlob)):
+			content = testMirrorBlob
+		default:
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", mediaType)
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(content).String())
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(content)
+		}
+	}))
+	return server, &requests
+}
+
+func newTestEndpointRepository(t *testing.T, server *httptest.Server, repository string) endpointRepository {
+	remoteRepository, err := remote.NewRepository(strings.TrimPrefix(server.URL, "http://") + "/" + repository)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	remoteRepository.PlainHTTP = true
+	return endpointRepository{
+		Repository: remoteRepository,
+		upstream:   registry.Reference{Registry: testMirrorUpstream, Repository: testReferrersRepository},
+	}
+}
+
+func TestMirrorReference(t *testing.T) {
+	upstream := registry.Reference{Registry: testMirrorUpstream, Repository: "library/nginx", Reference: "1.0"}
+	testCases := []struct {
+		mirror   RegistryMirror
+		expected string
+	}{
+		{mirror: RegistryMirror{Endpoint: "mirror.test"}, expected: "mirror.test/library/nginx:1.0"},
+		{mirror: RegistryMirror{Endpoint: "mirror.test:5000/", RepositoryPrefix: "/proxy/"}, expected: "mirror.test:5000/proxy/library/nginx:1.0"},
+	}
+
+	for _, tc := range testCases {
+		if actual := tc.mirror.mirrorReference(upstream).String(); actual != tc.expected {
+			t.Fatalf("expected mirror reference %v, got %v", tc.expected, actual)
+		}
+	}
+}
+
+func TestEndpointRepository_Rewrite(t *testing.T) {
+	endpoint := endpointRepository{
+		Repository: &remote.Repository{Reference: registry.Reference{Registry: "mirror.test", Repository: testMirrorPrefix + "/" + testReferrersRepository}},
+		upstream:   registry.Reference{Registry: testMirrorUpstream, Repository: testReferrersRepository},
+	}
+	testCases := []struct {
+		reference string
+		expected  string
+	}{
+		{reference: testMirrorUpstream + "/" + testReferrersRepository + ":v1", expected: "mirror.test/proxy/test/image:v1"},
+		{reference: testMirrorUpstream + "/" + testReferrersRepository + "@" + testSubjectDigest.String(), expected: "mirror.test/proxy/test/image@" + testSubjectDigest.String()},
+		{reference: "other.test/test/image:v1", expected: "other.test/test/image:v1"},
+		{reference: "v1", expected: "v1"},
+	}
+
+	for _, tc := range testCases {
+		if actual := endpoint.rewrite(tc.reference); actual != tc.expected {
+			t.Fatalf("expected %v to be rewritten to %v, got %v", tc.reference, tc.expected, actual)
+		}
+	}
+}
+
+func TestMirrorRepository_FallsBackToNextEndpoint(t *testing.T) {
+	mirrorRepo := testMirrorPrefix + "/" + testReferrersRepository
+	// the failing mirror does not serve the repository so every request fails
+	failing, failingRequests := newTestContentRegistry(t, "unused", http.StatusNotFound)
+	defer failing.Close()
+	mirror, mirrorRequests := newTestContentRegistry(t, mirrorRepo, http.StatusNotFound)
+	defer mirror.Close()
+	upstream, upstreamRequests := newTestContentRegistry(t, testReferrersRepository, http.StatusNotFound)
+	defer upstream.Close()
+
+	repository := &mirrorRepository{endpoints: []endpointRepository{
+		newTestEndpointRepository(t, failing, mirrorRepo),
+		newTestEndpointRepository(t, mirror, mirrorRepo),
+		newTestEndpointRepository(t, upstream, testReferrersRepository),
+	}}
+	ctx := context.Background()
+
+	desc, err := repository.Resolve(ctx, testMirrorUpstream+"/"+testReferrersRepository+":v1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if desc.MediaType != oci.MediaTypeImageManifest {
+		t.Fatalf("unexpected descriptor %v", desc)
+	}
+
+	_, rc, err := repository.Blobs().FetchReference(ctx, testMirrorUpstream+"/"+testReferrersRepository+"@"+digest.FromBytes(testMirrorBlob).String())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	if err != nil || string(content) != string(testMirrorBlob) {
+		t.Fatalf("expected blob content from the mirror, got %q and error %v", content, err)
+	}
+
+	if len(*failingRequests) != 2 || len(*mirrorRequests) != 2 || len(*upstreamRequests) != 0 {
+		t.Fatalf("expected requests to fall back to the mirror only, got %v, %v, %v", *failingRequests, *mirrorRequests, *upstreamRequests)
+	}
+}
+
+func TestMirrorRepository_AllEndpointsFail(t *testing.T) {
+	mirror, _ := newTestContentRegistry(t, "unused", http.StatusNotFound)
+	defer mirror.Close()
+	upstream, _ := newTestContentRegistry(t, testReferrersRepository, http.StatusNotFound)
+	defer upstream.Close()
+
+	repository := &mirrorRepository{endpoints: []endpointRepository{
+		newTestEndpointRepository(t, mirror, testReferrersRepository),
+		newTestEndpointRepository(t, upstream, testReferrersRepository),
+	}}
+	if _, err := repository.Resolve(context.Background(), testMirrorUpstream+"/"+testReferrersRepository+":missing"); err == nil {
+		t.Fatalf("expected error when no endpoint serves the reference")
+	}
+}
+
+func TestMirrorRepository_ReferrersPage(t *testing.T) {
+	failing, _ := newTestContentRegistry(t, "unused", http.StatusForbidden)
+	defer failing.Close()
+	mirror, mirrorRequests := newTestReferrersRegistry(t, true, true)
+	defer mirror.Close()
+
+	repository := &mirrorRepository{endpoints: []endpointRepository{
+		newTestEndpointRepository(t, failing, testReferrersRepository),
+		newTestEndpointRepository(t, mirror, testReferrersRepository),
+	}}
+	subject := oci.Descriptor{Digest: testSubjectDigest}
+
+	referrers, nextToken, err := newTestReferrersDiscovery(t, ReferrersDiscoveryAuto).listReferrersPage(context.Background(), repository, subject, nil, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(referrers) != 2 || nextToken == "" {
+		t.Fatalf("expected first page of 2 referrers with a continuation token, got %v referrers and token %q", len(referrers), nextToken)
+	}
+
+	// the next page is requested from the endpoint that served the first page
+	referrers, nextToken, err = newTestReferrersDiscovery(t, ReferrersDiscoveryAuto).listReferrersPage(context.Background(), repository, subject, nil, nextToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(referrers) != 1 || nextToken != "" || len(*mirrorRequests) != 2 {
+		t.Fatalf("expected last page from the mirror, got %v referrers, token %q and requests %v", len(referrers), nextToken, *mirrorRequests)
+	}
+
+	otherHostToken, _ := encodeReferrersPageToken(referrersPageToken{Link: "https://example.com/v2/test/image/referrers/" + testSubjectDigest.String()})
+	if _, _, err := newTestReferrersDiscovery(t, ReferrersDiscoveryAuto).listReferrersPage(context.Background(), repository, subject, nil, otherHostToken); err == nil {
+		t.Fatalf("expected error for continuation token of an unknown host")
+	}
+}
+
+func TestCreateMirrorEndpoints(t *testing.T) {
+	endpoints, err := createMirrorEndpoints(map[string][]RegistryMirror{
+		testMirrorUpstream: {{Endpoint: "mirror1.test"}, {Endpoint: "mirror2.test"}},
+	}, newTestRegistryTransport(t))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(endpoints[testMirrorUpstream]) != 2 || endpoints[testMirrorUpstream][0].Endpoint != "mirror1.test" {
+		t.Fatalf("expected mirrors to keep their order, got %v", endpoints)
+	}
+
+	if _, err := createMirrorEndpoints(map[string][]RegistryMirror{testMirrorUpstream: {{Endpoint: " "}}}, newTestRegistryTransport(t)); err == nil {
+		t.Fatalf("expected error for mirror without endpoint")
+	}
+}
@@ -30,13 +30,11 @@ import (
 
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/content"
-	ocitarget "oras.land/oras-go/v2/content/oci"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/errcode"
-	"oras.land/oras-go/v2/registry/remote/retry"
 
 	ratifyconfig "github.com/deislabs/ratify/config"
 	"github.com/deislabs/ratify/pkg/common"
@@ -45,13 +43,14 @@ import (
 	_ "github.com/deislabs/ratify/pkg/common/oras/authprovider/azure"
 	commonutils "github.com/deislabs/ratify/pkg/common/utils"
 	"github.com/deislabs/ratify/pkg/homedir"
-	"github.com/deislabs/ratify/pkg/metrics"
 	"github.com/deislabs/ratify/pkg/ocispecs"
 	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/blobcache"
 	"github.com/deislabs/ratify/pkg/referrerstore/config"
 	"github.com/deislabs/ratify/pkg/referrerstore/factory"
 	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
@@ -68,6 +67,10 @@ const (
 	defaultLocalCachePath = "local_oras_cache"
 	dockerConfigFileName  = "config.json"
 	ratifyUserAgent       = "ratify"
+	// credentials are refreshed when they expire within the refresh window
+	authRefreshWindow = 5 * time.Minute
+	// a shared credential fetch is bounded by its own timeout rather than the context of the first request
+	authFetchTimeout = 30 * time.Second
 )
 
 // OrasStoreConf describes the configuration of ORAS store
@@ -77,6 +80,18 @@ type OrasStoreConf struct {
 	CosignEnabled  bool                            `json:"cosignEnabled,omitempty"`
 	AuthProvider   authprovider.AuthProviderConfig `json:"authProvider,omitempty"`
 	LocalCachePath string                          `json:"localCachePath,omitempty"`
+	// BlobCache bounds the size and age of the blobs cached at the local cache path
+	BlobCache      blobcache.Config     `json:"blobCache,omitempty"`
+	Retry          RetryConfig          `json:"retry,omitempty"`
+	CircuitBreaker CircuitBreakerConfig `json:"circuitBreaker,omitempty"`
+	// TLS maps a registry host to the TLS settings used to access it
+	TLS map[string]RegistryTLSConfig `json:"tls,omitempty"`
+	// Mirrors maps a registry host to the ordered list of mirrors content is read from before the registry itself
+	Mirrors map[string][]RegistryMirror `json:"mirrors,omitempty"`
+	// ReferrersDiscovery is the mechanism used to list referrers: auto, referrersAPI or tagSchema
+	ReferrersDiscovery string `json:"referrersDiscovery,omitempty"`
+	// SizeLimits bounds the size of the blobs and manifests read from registries
+	referrerstore.SizeLimits
 }
 
 type orasStoreFactory struct{}
@@ -91,10 +106,16 @@ type orasStore struct {
 	rawConfig              config.StoreConfig
 	localCache             content.Storage
 	authProvider           authprovider.AuthProvider
+	mirrors                map[string][]mirrorEndpoint
+	referrersDiscovery     *referrersDiscovery
 	authCache              sync.Map
+	authGroup              singleflight.Group
 	subjectDescriptorCache sync.Map
 	httpClient             *http.Client
 	httpClientInsecure     *http.Client
+	tlsClients             map[string]*http.Client
+	maxBlobSize            int64
+	maxManifestSize        int64
 	createRepository       func(ctx context.Context, store *orasStore, targetRef common.Reference) (registry.Repository, time.Time, error)
 }
 
@@ -136,63 +157,53 @@ func createBaseStore(version string, storeConfig config.StorePluginConfig) (*ora
 		return nil, fmt.Errorf("failed to create auth provider from configuration: %w", err)
 	}
 
-	// Set up the local cache where content will land when we pull
-	if conf.LocalCachePath == "" {
-		conf.LocalCachePath = paths.Join(homedir.Get(), ratifyconfig.ConfigFileDir, defaultLocalCachePath)
+	transport, err := newRegistryTransport(conf.Retry, conf.CircuitBreaker)
+	if err != nil {
+		return nil, err
 	}
 
-	localRegistry, err := ocitarget.New(conf.LocalCachePath)
+	mirrors, err := createMirrorEndpoints(conf.Mirrors, transport)
 	if err != nil {
-		return nil, fmt.Errorf("could not create local oras cache at path %s: %w", conf.LocalCachePath, err)
+		return nil, fmt.Errorf("failed to create registry mirrors from configuration: %w", err)
 	}
 
-	var customPredicate retry.Predicate = func(resp *http.Response, err error) (bool, error) {
-		host := ""
-		if resp != nil {
-			if resp.Request != nil && resp.Request.URL != nil {
-				host = resp.Request.URL.Host
-			}
-			metrics.ReportRegistryRequestCount(resp.Request.Context(), resp.StatusCode, host)
-		}
-		return retry.DefaultPredicate(resp, err)
+	discovery, err := newReferrersDiscovery(conf.ReferrersDiscovery)
+	if err != nil {
+		return nil, err
 	}
 
-	customRetryPolicy := func() retry.Policy {
-		return &retry.GenericPolicy{
-			Retryable: customPredicate,
-			Backoff:   retry.DefaultBackoff,
-			MinWait:   HttpRetryDurationMin,
-			MaxWait:   HttpRetryDurationMax,
-			MaxRetry:  HttpRetryMax,
-		}
+	maxBlobSize, maxManifestSize, err := conf.SizeLimits.Parse()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse oras store size limits: %w", err)
 	}
 
-	// define the http client for TLS enabled
-	secureTransport := http.DefaultTransport.(*http.Transport).Clone()
-	secureTransport.MaxIdleConns = HttpMaxIdleConns
-	secureTransport.MaxConnsPerHost = HttpMaxConnsPerHost
-	secureTransport.MaxIdleConnsPerHost = HttpMaxIdleConnsPerHost
-	secureRetryTransport := retry.NewTransport(secureTransport)
-	secureRetryTransport.Policy = customRetryPolicy
+	// Set up the local cache where content will land when we pull
+	if conf.LocalCachePath == "" {
+		conf.LocalCachePath = paths.Join(homedir.Get(), ratifyconfig.ConfigFileDir, defaultLocalCachePath)
+	}
 
-	// define the http client for TLS disabled
-	insecureTransport := http.DefaultTransport.(*http.Transport).Clone()
-	insecureTransport.MaxIdleConns = HttpMaxIdleConns
-	insecureTransport.MaxConnsPerHost = HttpMaxConnsPerHost
-	insecureTransport.MaxIdleConnsPerHost = HttpMaxIdleConnsPerHost
-	// #nosec G402
-	insecureTransport.TLSClientConfig = &tls.Config{
-		InsecureSkipVerify: true,
+	localRegistry, err := blobcache.New(conf.LocalCachePath, conf.BlobCache)
+	if err != nil {
+		return nil, fmt.Errorf("could not create local oras cache at path %s: %w", conf.LocalCachePath, err)
+	}
+
+	tlsClients, err := createTLSClients(conf.TLS, transport)
+	if err != nil {
+		return nil, err
 	}
-	insecureRetryTransport := retry.NewTransport(insecureTransport)
-	insecureRetryTransport.Policy = customRetryPolicy
 
 	return &orasStore{config: &conf,
 		rawConfig:          config.StoreConfig{Version: version, Store: storeConfig},
 		localCache:         localRegistry,
 		authProvider:       authenticationProvider,
-		httpClient:         &http.Client{Transport: secureRetryTransport},
-		httpClientInsecure: &http.Client{Transport: insecureRetryTransport},
+		mirrors:            mirrors,
+		referrersDiscovery: discovery,
+		httpClient:         transport.newClient(nil),
+		// #nosec G402
+		httpClientInsecure: transport.newClient(&tls.Config{InsecureSkipVerify: true}),
+		tlsClients:         tlsClients,
+		maxBlobSize:        maxBlobSize,
+		maxManifestSize:    maxManifestSize,
 		createRepository:   createDefaultRepository}, nil
 }
 
@@ -224,31 +235,26 @@ func (store *orasStore) ListReferrers(ctx context.Context, subjectReference comm
 		}
 	}
 
-	// find all referrers referencing subject descriptor
-	artifactTypeFilter := ""
-	var referrerDescriptors []oci.Descriptor
-	if err := repository.Referrers(ctx, resolvedSubjectDesc.Descriptor, artifactTypeFilter, func(referrers []oci.Descriptor) error {
-		referrerDescriptors = append(referrerDescriptors, referrers...)
-		return nil
-	}); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+	// find a page of referrers referencing subject descriptor
+	referrers, continuationToken, err := store.referrersDiscovery.listReferrersPage(ctx, repository, resolvedSubjectDesc.Descriptor, artifactTypes, nextToken)
+	if err != nil && !errors.Is(err, errdef.ErrNotFound) {
 		var ec errcode.Error
 		if errors.As(err, &ec) && (ec.Code == fmt.Sprint(http.StatusForbidden) || ec.Code == fmt.Sprint(http.StatusUnauthorized)) {
 			store.evictAuthCache(subjectReference.Original, err)
 		}
+		var errResp *errcode.ErrorResponse
+		if errors.As(err, &errResp) && (errResp.StatusCode == http.StatusForbidden || errResp.StatusCode == http.StatusUnauthorized) {
+			store.evictAuthCache(subjectReference.Original, err)
+		}
 		return referrerstore.ListReferrersResult{}, err
 	}
 	// add the repository client to the auth cache if all repository operations successful
 	store.addAuthCache(subjectReference.Original, repository, expiry)
 
-	// convert artifact descriptors to oci descriptor with artifact type
-	referrers := []ocispecs.ReferenceDescriptor{}
-	for _, referrer := range referrerDescriptors {
-		referrers = append(referrers, OciDescriptorToReferenceDescriptor(referrer))
-	}
-
-	if store.config.CosignEnabled {
-		// add cosign descriptor if exists
-		cosignReferences, err := getCosignReferences(ctx, subjectReference, store, repository)
+	// cosign signatures, attestations and SBOMs are only discovered once, along with the first page
+	if store.config.CosignEnabled && nextToken == "" {
+		// add cosign descriptors if they exist
+		cosignReferences, err := getCosignReferences(ctx, subjectReference, artifactTypes, store, repository)
 		if err != nil {
 			return referrerstore.ListReferrersResult{}, err
 		}
@@ -258,16 +264,22 @@ func (store *orasStore) ListReferrers(ctx context.Context, subjectReference comm
 		}
 	}
 
-	return referrerstore.ListReferrersResult{Referrers: referrers}, nil
+	return referrerstore.ListReferrersResult{Referrers: referrers, NextToken: continuationToken}, nil
 }
 
 func (store *orasStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
-	var err error
-	repository, expiry, err := store.createRepository(ctx, store, subjectReference)
+	rc, err := store.GetBlobReader(ctx, subjectReference, digest)
 	if err != nil {
 		return nil, err
 	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
 
+// GetBlobReader streams the blob from the local cache, or from the registry while it is written to the cache.
+// Blobs larger than the cache are streamed from the registry without being cached.
+func (store *orasStore) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
 	// create a dummy Descriptor to check the local store cache
 	blobDescriptor := oci.Descriptor{
 		Digest: digest,
@@ -275,54 +287,133 @@ func (store *orasStore) GetBlobContent(ctx context.Context, subjectReference com
 	}
 
 	// check if blob exists in local ORAS cache
-	isCached, err := store.localCache.Exists(ctx, blobDescriptor)
+	rc, err := store.localCache.Fetch(ctx, blobDescriptor)
+	if err == nil {
+		return referrerstore.NewLimitedReader(rc, store.maxBlobSize), nil
+	}
+	if !errors.Is(err, errdef.ErrNotFound) {
+		return nil, err
+	}
+
+	blobDesc, rc, err := store.fetchBlob(ctx, subjectReference, digest)
 	if err != nil {
 		return nil, err
 	}
-	metrics.ReportBlobCacheCount(ctx, isCached)
 
-	if !isCached {
-		// generate the reference path with digest
-		ref := fmt.Sprintf("%s@%s", subjectReference.Path, digest)
+	// the blob is cached while it is streamed from the registry, rather than fetched again from the cache
+	cache, ok := store.localCache.(blobWriterCache)
+	if !ok {
+		return referrerstore.NewLimitedReader(rc, store.maxBlobSize), nil
+	}
+	writer, err := cache.NewWriter(ctx, blobDesc)
+	if err != nil {
+		logrus.Debugf("not caching %s: %v", digest, err)
+		return referrerstore.NewLimitedReader(rc, store.maxBlobSize), nil
+	}
+	return referrerstore.NewLimitedReader(&cachingReader{ctx: ctx, rc: rc, writer: writer}, store.maxBlobSize), nil
+}
 
-		// fetch blob content from remote repository
-		blobDesc, rc, err := repository.Blobs().FetchReference(ctx, ref)
-		if err != nil {
-			var ec errcode.Error
-			if errors.As(err, &ec) && (ec.Code == fmt.Sprint(http.StatusForbidden) || ec.Code == fmt.Sprint(http.StatusUnauthorized)) {
-				store.evictAuthCache(subjectReference.Original, err)
-			}
-			return nil, err
+// blobWriterCache is a local cache blobs are written to as they are streamed
+type blobWriterCache interface {
+	NewWriter(ctx context.Context, expected oci.Descriptor) (*blobcache.Writer, error)
+}
+
+// cachingReader writes the content read from a registry to the blob cache, the blob is cached once it is read to the end
+type cachingReader struct {
+	ctx    context.Context
+	rc     io.ReadCloser
+	writer *blobcache.Writer
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	if r.writer == nil {
+		return n, err
+	}
+	if n > 0 {
+		if _, writeErr := r.writer.Write(p[:n]); writeErr != nil {
+			// blobs larger than the cache are still read from the registry
+			logrus.Debugf("not caching %s: %v", r.writer.Digest(), writeErr)
+			r.writer.Abort()
+			r.writer = nil
+			return n, err
+		}
+	}
+	switch {
+	case err == io.EOF:
+		// content that does not match the descriptor of the blob is an error, like when it is pushed to the cache
+		writer := r.writer
+		r.writer = nil
+		commitErr := writer.Commit(r.ctx)
+		if errors.Is(commitErr, blobcache.ErrInvalidContent) {
+			return n, commitErr
 		}
+		if commitErr != nil && !errors.Is(commitErr, errdef.ErrAlreadyExists) {
+			logrus.Debugf("not caching %s: %v", writer.Digest(), commitErr)
+		}
+	case err != nil:
+		r.writer.Abort()
+		r.writer = nil
+	}
+	return n, err
+}
+
+func (r *cachingReader) Close() error {
+	// a blob not read to the end is not cached
+	if r.writer != nil {
+		r.writer.Abort()
+		r.writer = nil
+	}
+	return r.rc.Close()
+}
+
+// fetchBlob opens the blob with the given digest in the repository of the subject
+func (store *orasStore) fetchBlob(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (oci.Descriptor, io.ReadCloser, error) {
+	repository, expiry, err := store.createRepository(ctx, store, subjectReference)
+	if err != nil {
+		return oci.Descriptor{}, nil, err
+	}
 
-		// push fetched content to local ORAS cache
-		orasExistsExpectedError := fmt.Errorf("%s: %s: %w", blobDesc.Digest, blobDesc.MediaType, errdef.ErrAlreadyExists)
-		err = store.localCache.Push(ctx, blobDesc, rc)
-		if err != nil && err.Error() != orasExistsExpectedError.Error() {
-			return nil, err
+	// generate the reference path with digest
+	ref := fmt.Sprintf("%s@%s", subjectReference.Path, digest)
+
+	// fetch blob content from remote repository
+	blobDesc, rc, err := repository.Blobs().FetchReference(ctx, ref)
+	if err != nil {
+		var ec errcode.Error
+		if errors.As(err, &ec) && (ec.Code == fmt.Sprint(http.StatusForbidden) || ec.Code == fmt.Sprint(http.StatusUnauthorized)) {
+			store.evictAuthCache(subjectReference.Original, err)
 		}
+		return oci.Descriptor{}, nil, err
 	}
 
 	// add the repository client to the auth cache if all repository operations successful
 	store.addAuthCache(subjectReference.Original, repository, expiry)
 
-	return store.getRawContentFromCache(ctx, blobDescriptor)
+	if err := referrerstore.CheckSize(blobDesc.Size, store.maxBlobSize); err != nil {
+		rc.Close()
+		return oci.Descriptor{}, nil, fmt.Errorf("blob %s: %w", digest, err)
+	}
+	return blobDesc, rc, nil
 }
 
 func (store *orasStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
-	repository, expiry, err := store.createRepository(ctx, store, subjectReference)
-	if err != nil {
-		return ocispecs.ReferenceManifest{}, err
+	if err := referrerstore.CheckSize(referenceDesc.Size, store.maxManifestSize); err != nil {
+		return ocispecs.ReferenceManifest{}, fmt.Errorf("manifest %s: %w", referenceDesc.Digest, err)
 	}
-	var manifestBytes []byte
+
 	// check if manifest exists in local ORAS cache
-	isCached, err := store.localCache.Exists(ctx, referenceDesc.Descriptor)
+	manifestBytes, err := store.getRawContentFromCache(ctx, referenceDesc.Descriptor, store.maxManifestSize)
 	if err != nil {
-		return ocispecs.ReferenceManifest{}, err
-	}
-	metrics.ReportBlobCacheCount(ctx, isCached)
+		if !errors.Is(err, errdef.ErrNotFound) {
+			return ocispecs.ReferenceManifest{}, err
+		}
+
+		repository, expiry, err := store.createRepository(ctx, store, subjectReference)
+		if err != nil {
+			return ocispecs.ReferenceManifest{}, err
+		}
 
-	if !isCached {
 		// fetch manifest content from repository
 		manifestReader, err := repository.Fetch(ctx, referenceDesc.Descriptor)
 		if err != nil {
@@ -332,26 +423,20 @@ func (store *orasStore) GetReferenceManifest(ctx context.Context, subjectReferen
 			}
 			return ocispecs.ReferenceManifest{}, err
 		}
+		defer manifestReader.Close()
 
-		manifestBytes, err = io.ReadAll(manifestReader)
+		manifestBytes, err = io.ReadAll(referrerstore.NewLimitedReader(manifestReader, store.maxManifestSize))
 		if err != nil {
-			return ocispecs.ReferenceManifest{}, err
+			return ocispecs.ReferenceManifest{}, fmt.Errorf("manifest %s: %w", referenceDesc.Digest, err)
 		}
 
 		// push fetched manifest to local ORAS cache
-		orasExistsExpectedError := fmt.Errorf("%s: %s: %w", referenceDesc.Descriptor.Digest, referenceDesc.Descriptor.MediaType, errdef.ErrAlreadyExists)
-		err = store.localCache.Push(ctx, referenceDesc.Descriptor, bytes.NewReader(manifestBytes))
-		if err != nil && err.Error() != orasExistsExpectedError.Error() {
+		if err := store.pushToCache(ctx, referenceDesc.Descriptor, manifestBytes); err != nil {
 			return ocispecs.ReferenceManifest{}, err
 		}
 
 		// add the repository client to the auth cache if all repository operations successful
 		store.addAuthCache(subjectReference.Original, repository, expiry)
-	} else {
-		manifestBytes, err = store.getRawContentFromCache(ctx, referenceDesc.Descriptor)
-		if err != nil {
-			return ocispecs.ReferenceManifest{}, err
-		}
 	}
 
 	referenceManifest := ocispecs.ReferenceManifest{}
@@ -408,22 +493,145 @@ func createDefaultRepository(ctx context.Context, store *orasStore, targetRef co
 		return nil, time.Now(), fmt.Errorf("auth provider not properly enabled")
 	}
 
-	if entry, ok := store.authCache.Load(targetRef.Original); ok {
-		// if the auth cache entry expiration has not expired or it was never set
+	key := authCacheKey(targetRef.Original)
+	var cached *authCacheEntry
+	if entry, ok := store.authCache.Load(key); ok {
+		// if the auth cache entry expiration is not within the refresh window or it was never set
 		cacheEntry := entry.(authCacheEntry)
-		if cacheEntry.expiresOn.IsZero() || cacheEntry.expiresOn.After(time.Now()) {
+		if cacheEntry.expiresOn.IsZero() || cacheEntry.expiresOn.After(time.Now().Add(authRefreshWindow)) {
 			return cacheEntry.client, cacheEntry.expiresOn, nil
 		}
+		// credentials about to expire are refreshed, but still used if the refresh fails
+		if cacheEntry.expiresOn.After(time.Now()) {
+			cached = &cacheEntry
+		}
 	}
 
-	authConfig, err := store.authProvider.Provide(ctx, targetRef.Original)
+	// concurrent requests for the same repository share a single credential fetch, which is detached from the
+	// request that started it so that its cancellation does not fail the other requests waiting on the fetch
+	fetch := store.authGroup.DoChan(key, func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, authFetchTimeout)
+		defer cancel()
+		// valid credentials are not replaced by anonymous ones if the refresh fails
+		repository, expiry, err := createRepositoryClient(fetchCtx, store, targetRef.Original, cached == nil)
+		return authCacheEntry{client: repository, expiresOn: expiry}, err
+	})
+	var result singleflight.Result
+	select {
+	case result = <-fetch:
+	case <-ctx.Done():
+		result = singleflight.Result{Err: ctx.Err()}
+	}
+	if result.Err != nil {
+		if cached != nil {
+			logrus.Warnf("failed to refresh credentials of %s, using cached credentials until they expire: %v", key, result.Err)
+			return cached.client, cached.expiresOn, nil
+		}
+		return nil, time.Now(), result.Err
+	}
+	entry := result.Val.(authCacheEntry)
+	return entry.client, entry.expiresOn, nil
+}
+
+// detachedContext carries the values of its parent but is never canceled and has no deadline
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// createRepositoryClient creates a client of the repository of reference, reading through the mirrors of the registry if configured
+func createRepositoryClient(ctx context.Context, store *orasStore, reference string, allowAnonymous bool) (registry.Repository, time.Time, error) {
+	upstreamRef, err := registry.ParseReference(reference)
 	if err != nil {
+		return nil, time.Now(), err
+	}
+	// the client is shared by all tags and digests of the repository
+	upstreamRef.Reference = ""
+	plainHTTP, client := store.registryClient(upstreamRef.Registry, reference)
+	upstream, expiry, err := store.createRemoteRepository(ctx, upstreamRef.String(), store.authProvider, plainHTTP, client, allowAnonymous)
+	if err != nil {
+		return nil, time.Now(), err
+	}
+
+	mirrors := store.mirrors[upstream.Reference.Registry]
+	if len(mirrors) == 0 {
+		return upstream, expiry, nil
+	}
+
+	// mirrors are tried in the configured order before the upstream registry
+	repository := &mirrorRepository{}
+	for _, mirror := range mirrors {
+		provider := mirror.authProvider
+		if provider == nil {
+			provider = store.authProvider
+		}
+		mirrorRef := mirror.mirrorReference(upstream.Reference)
+		client := store.httpClient
+		if mirror.client != nil {
+			client = mirror.client
+		} else if mirror.UseHttp {
+			client = store.httpClientInsecure
+		}
+		mirrorRepo, mirrorExpiry, err := store.createRemoteRepository(ctx, mirrorRef.String(), provider, mirror.UseHttp, client, allowAnonymous)
+		if err != nil {
+			logrus.Warnf("skipping mirror %s of %s, error: %v", mirror.Endpoint, upstream.Reference.Registry, err)
+			continue
+		}
+		repository.endpoints = append(repository.endpoints, endpointRepository{Repository: mirrorRepo, upstream: upstream.Reference})
+		if !mirrorExpiry.IsZero() && (expiry.IsZero() || mirrorExpiry.Before(expiry)) {
+			expiry = mirrorExpiry
+		}
+	}
+	repository.endpoints = append(repository.endpoints, endpointRepository{Repository: upstream, upstream: upstream.Reference})
+
+	return repository, expiry, nil
+}
+
+// registryClient returns whether plain HTTP is used and the http client to access the registry host
+func (store *orasStore) registryClient(host string, reference string) (bool, *http.Client) {
+	plainHTTP := store.config.UseHttp
+	client := store.httpClient
+	if isInsecureRegistry(reference, store.config) {
+		client = store.httpClientInsecure
+	}
+	if conf, ok := store.config.TLS[host]; ok {
+		plainHTTP = plainHTTP || conf.UseHttp
+		if tlsClient, ok := store.tlsClients[host]; ok {
+			client = tlsClient
+		}
+	}
+	return plainHTTP, client
+}
+
+// createRemoteRepository creates a repository client for reference with the credentials resolved by provider,
+// falling back to anonymous credentials if allowed
+func (store *orasStore) createRemoteRepository(ctx context.Context, reference string, provider authprovider.AuthProvider, plainHTTP bool, client *http.Client, allowAnonymous bool) (*remote.Repository, time.Time, error) {
+	authConfig, err := provider.Provide(ctx, reference)
+	if err != nil {
+		if !allowAnonymous {
+			return nil, time.Now(), err
+		}
 		logrus.Warningf("auth provider failed with err, %v", err)
 		logrus.Info("attempting to use anonymous credentials")
 	}
 
 	// create new ORAS repository target to the image/repository reference
-	repository, err := remote.NewRepository(targetRef.Original)
+	repository, err := remote.NewRepository(reference)
 	if err != nil {
 		return nil, time.Now(), err
 	}
@@ -442,7 +650,7 @@ func createDefaultRepository(ctx context.Context, store *orasStore, targetRef co
 
 	// set the repository client credentials
 	repoClient := &auth.Client{
-		Client: store.httpClient,
+		Client: client,
 		Header: http.Header{
 			"User-Agent": {ratifyUserAgent},
 		},
@@ -450,38 +658,57 @@ func createDefaultRepository(ctx context.Context, store *orasStore, targetRef co
 		Credential: credentialProvider,
 	}
 
-	// enable insecure if specified in config
-	if isInsecureRegistry(targetRef.Original, store.config) {
-		repoClient.Client = store.httpClientInsecure
-	}
-
 	repository.Client = repoClient
 	// enable plain HTTP if specified in config
-	repository.PlainHTTP = store.config.UseHttp
+	repository.PlainHTTP = plainHTTP
 
 	return repository, authConfig.ExpiresOn, nil
 }
 
-func (store *orasStore) getRawContentFromCache(ctx context.Context, descriptor oci.Descriptor) ([]byte, error) {
+func (store *orasStore) getRawContentFromCache(ctx context.Context, descriptor oci.Descriptor, limit int64) ([]byte, error) {
 	reader, err := store.localCache.Fetch(ctx, descriptor)
 	if err != nil {
 		return nil, err
 	}
+	defer reader.Close()
 
-	buf, err := io.ReadAll(reader)
+	buf, err := io.ReadAll(referrerstore.NewLimitedReader(reader, limit))
 	if err != nil {
 		return nil, err
 	}
 	return buf, nil
 }
 
+// pushToCache adds content fetched from the registry to the local cache, content larger than the cache is not cached
+func (store *orasStore) pushToCache(ctx context.Context, desc oci.Descriptor, content []byte) error {
+	err := store.localCache.Push(ctx, desc, bytes.NewReader(content))
+	if errors.Is(err, blobcache.ErrBlobTooLarge) {
+		logrus.Debugf("not caching %s: %v", desc.Digest, err)
+		return nil
+	}
+	if err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return err
+	}
+	return nil
+}
+
 func (store *orasStore) addAuthCache(ref string, repository registry.Repository, expiry time.Time) {
-	store.authCache.LoadOrStore(ref, authCacheEntry{
+	// refreshed credentials replace the cached ones
+	store.authCache.Store(authCacheKey(ref), authCacheEntry{
 		client:    repository,
 		expiresOn: expiry,
 	})
 }
 
 func (store *orasStore) evictAuthCache(ref string, err error) {
-	store.authCache.Delete(ref)
+	store.authCache.Delete(authCacheKey(ref))
+}
+
+// authCacheKey returns the registry and repository of ref, credentials are scoped to a repository
+func authCacheKey(ref string) string {
+	parsed, err := registry.ParseReference(ref)
+	if err != nil {
+		return ref
+	}
+	return parsed.Registry + "/" + parsed.Repository
 }
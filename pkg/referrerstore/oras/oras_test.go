@@ -26,11 +26,15 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/common/oras/authprovider"
 	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/blobcache"
 	"github.com/deislabs/ratify/pkg/referrerstore/config"
 	"github.com/deislabs/ratify/pkg/referrerstore/oras/mocks"
 	"github.com/opencontainers/go-digest"
@@ -476,6 +480,196 @@ func TestORASGetBlobContent_NotCachedDesc(t *testing.T) {
 	}
 }
 
+// TestORASGetBlobContent_BlobCache tests that fetched blobs are cached within the bounds of the blob cache
+func TestORASGetBlobContent_BlobCache(t *testing.T) {
+	ctx := context.Background()
+	inputRef := common.Reference{
+		Original: inputOriginalPath,
+		Path:     inputOriginalPath,
+		Digest:   digest.FromString("testDigest"),
+	}
+	store, err := createBaseStore("1.0.0", config.StorePluginConfig{
+		"name":           "oras",
+		"localCachePath": t.TempDir(),
+		"blobCache":      map[string]interface{}{"maxSize": "8"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+
+	smallContent := []byte("sbom")
+	largeContent := []byte("large sbom")
+	blobMap := map[string]mocks.BlobPair{}
+	for _, content := range [][]byte{smallContent, largeContent} {
+		desc := oci.Descriptor{Digest: digest.FromBytes(content), Size: int64(len(content))}
+		blobMap[fmt.Sprintf("%s@%s", inputRef.Path, desc.Digest)] = mocks.BlobPair{Descriptor: desc, Reader: io.NopCloser(bytes.NewReader(content))}
+	}
+	store.createRepository = func(ctx context.Context, store *orasStore, targetRef common.Reference) (registry.Repository, time.Time, error) {
+		return mocks.TestRepository{BlobStoreTest: mocks.TestBlobStore{BlobMap: blobMap}}, time.Now().Add(time.Minute), nil
+	}
+
+	for _, content := range [][]byte{smallContent, largeContent} {
+		actual, err := store.GetBlobContent(ctx, inputRef, digest.FromBytes(content))
+		if err != nil {
+			t.Fatalf("failed to get blob content: %v", err)
+		}
+		if !bytes.Equal(actual, content) {
+			t.Fatalf("expected content %s, got %s", content, actual)
+		}
+	}
+
+	// blobs larger than the cache are served without being cached
+	for content, expected := range map[string]bool{string(smallContent): true, string(largeContent): false} {
+		if cached, _ := store.localCache.Exists(ctx, oci.Descriptor{Digest: digest.FromString(content)}); cached != expected {
+			t.Fatalf("expected blob %s cached %v", content, expected)
+		}
+	}
+}
+
+// TestORASGetBlobReader_BlobCache tests that blobs streamed from the registry are cached as they are read
+func TestORASGetBlobReader_BlobCache(t *testing.T) {
+	ctx := context.Background()
+	inputRef := common.Reference{
+		Original: inputOriginalPath,
+		Path:     inputOriginalPath,
+		Digest:   digest.FromString("testDigest"),
+	}
+	store, err := createBaseStore("1.0.0", config.StorePluginConfig{
+		"name":           "oras",
+		"localCachePath": t.TempDir(),
+		"blobCache":      map[string]interface{}{"maxSize": "8"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+
+	smallContent := []byte("sbom")
+	partialContent := []byte("vex")
+	largeContent := []byte("large sbom")
+	// each blob can be read from the registry once, fetching it again returns no content
+	blobMap := map[string]mocks.BlobPair{}
+	for _, content := range [][]byte{smallContent, partialContent, largeContent} {
+		desc := oci.Descriptor{Digest: digest.FromBytes(content), Size: int64(len(content))}
+		blobMap[fmt.Sprintf("%s@%s", inputRef.Path, desc.Digest)] = mocks.BlobPair{Descriptor: desc, Reader: io.NopCloser(bytes.NewReader(content))}
+	}
+	store.createRepository = func(ctx context.Context, store *orasStore, targetRef common.Reference) (registry.Repository, time.Time, error) {
+		return mocks.TestRepository{BlobStoreTest: mocks.TestBlobStore{BlobMap: blobMap}}, time.Now().Add(time.Minute), nil
+	}
+	readBlob := func(content []byte) []byte {
+		rc, err := store.GetBlobReader(ctx, inputRef, digest.FromBytes(content))
+		if err != nil {
+			t.Fatalf("failed to get blob reader: %v", err)
+		}
+		defer rc.Close()
+		actual, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("failed to read blob: %v", err)
+		}
+		return actual
+	}
+
+	for _, content := range [][]byte{smallContent, largeContent, smallContent} {
+		if actual := readBlob(content); !bytes.Equal(actual, content) {
+			t.Fatalf("expected content %s, got %s", content, actual)
+		}
+	}
+
+	// a blob not read to the end is not cached
+	rc, err := store.GetBlobReader(ctx, inputRef, digest.FromBytes(partialContent))
+	if err != nil {
+		t.Fatalf("failed to get blob reader: %v", err)
+	}
+	if _, err := rc.Read(make([]byte, 1)); err != nil {
+		t.Fatalf("failed to read blob: %v", err)
+	}
+	rc.Close()
+
+	for content, expected := range map[string]bool{string(smallContent): true, string(partialContent): false, string(largeContent): false} {
+		if cached, _ := store.localCache.Exists(ctx, oci.Descriptor{Digest: digest.FromString(content)}); cached != expected {
+			t.Fatalf("expected blob %s cached %v", content, expected)
+		}
+	}
+
+	// content not matching the digest of the blob is neither served nor cached
+	tampered := digest.FromString("sig")
+	blobMap[fmt.Sprintf("%s@%s", inputRef.Path, tampered)] = mocks.BlobPair{Descriptor: oci.Descriptor{Digest: tampered, Size: 3}, Reader: io.NopCloser(bytes.NewReader([]byte("bad")))}
+	rc, err = store.GetBlobReader(ctx, inputRef, tampered)
+	if err != nil {
+		t.Fatalf("failed to get blob reader: %v", err)
+	}
+	defer rc.Close()
+	if _, err := io.ReadAll(rc); !errors.Is(err, blobcache.ErrInvalidContent) {
+		t.Fatalf("expected tampered content to be rejected, got %v", err)
+	}
+	if cached, _ := store.localCache.Exists(ctx, oci.Descriptor{Digest: tampered}); cached {
+		t.Fatalf("expected tampered content not to be cached")
+	}
+}
+
+// TestORASGetBlobReader_MaxSize tests that blobs and manifests larger than the maximum size of the store are rejected
+func TestORASGetBlobReader_MaxSize(t *testing.T) {
+	ctx := context.Background()
+	inputRef := common.Reference{
+		Original: inputOriginalPath,
+		Path:     inputOriginalPath,
+		Digest:   digest.FromString("testDigest"),
+	}
+	store, err := createBaseStore("1.0.0", config.StorePluginConfig{
+		"name":            "oras",
+		"maxBlobSize":     "8",
+		"maxManifestSize": "16",
+	})
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+
+	smallContent := []byte("sbom")
+	largeContent := []byte("large sbom")
+	blobMap := map[string]mocks.BlobPair{
+		fmt.Sprintf("%s@%s", inputRef.Path, digest.FromBytes(smallContent)): {
+			Descriptor: oci.Descriptor{Digest: digest.FromBytes(smallContent), Size: int64(len(smallContent))},
+			Reader:     io.NopCloser(bytes.NewReader(smallContent)),
+		},
+		fmt.Sprintf("%s@%s", inputRef.Path, digest.FromBytes(largeContent)): {
+			Descriptor: oci.Descriptor{Digest: digest.FromBytes(largeContent), Size: int64(len(largeContent))},
+			Reader:     io.NopCloser(bytes.NewReader(largeContent)),
+		},
+	}
+	// the registry understates the size of the blob
+	understated := []byte("understated sbom")
+	blobMap[fmt.Sprintf("%s@%s", inputRef.Path, digest.FromBytes(understated))] = mocks.BlobPair{
+		Descriptor: oci.Descriptor{Digest: digest.FromBytes(understated), Size: 4},
+		Reader:     io.NopCloser(bytes.NewReader(understated)),
+	}
+	store.createRepository = func(ctx context.Context, store *orasStore, targetRef common.Reference) (registry.Repository, time.Time, error) {
+		return mocks.TestRepository{BlobStoreTest: mocks.TestBlobStore{BlobMap: blobMap}}, time.Now().Add(time.Minute), nil
+	}
+	store.localCache = mocks.TestStorage{
+		ExistsMap: map[digest.Digest]io.Reader{},
+	}
+
+	rc, err := store.GetBlobReader(ctx, inputRef, digest.FromBytes(smallContent))
+	if err != nil {
+		t.Fatalf("failed to get blob reader: %v", err)
+	}
+	defer rc.Close()
+	if content, err := io.ReadAll(rc); err != nil || !bytes.Equal(content, smallContent) {
+		t.Fatalf("expected content %s, got %s and error %v", smallContent, content, err)
+	}
+
+	if _, err := store.GetBlobReader(ctx, inputRef, digest.FromBytes(largeContent)); !errors.Is(err, referrerstore.ErrMaxSizeExceeded) {
+		t.Fatalf("expected blob larger than the maximum size to be rejected, got %v", err)
+	}
+	if _, err := store.GetBlobContent(ctx, inputRef, digest.FromBytes(understated)); !errors.Is(err, referrerstore.ErrMaxSizeExceeded) {
+		t.Fatalf("expected blob read past the maximum size to be rejected, got %v", err)
+	}
+
+	manifestDesc := ocispecs.ReferenceDescriptor{Descriptor: oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("manifest"), Size: 17}}
+	if _, err := store.GetReferenceManifest(ctx, inputRef, manifestDesc); !errors.Is(err, referrerstore.ErrMaxSizeExceeded) {
+		t.Fatalf("expected manifest larger than the maximum size to be rejected, got %v", err)
+	}
+}
+
 // Test_ORASRetryClient tests that the retry client retries on 429 for specified number of retries
 func Test_ORASRetryClient(t *testing.T) {
 	ctx := context.Background()
@@ -524,3 +718,200 @@ func Test_ORASRetryClient(t *testing.T) {
 		t.Fatalf("expected 6 retries, got %d", count)
 	}
 }
+
+// countingAuthProvider counts the credentials provided and fails when err is set
+type countingAuthProvider struct {
+	lock      sync.Mutex
+	calls     int
+	expiresOn time.Time
+	err       error
+	release   chan struct{}
+	ctxErr    error
+}
+
+func (p *countingAuthProvider) Enabled(_ context.Context) bool {
+	return true
+}
+
+func (p *countingAuthProvider) Provide(ctx context.Context, _ string) (authprovider.AuthConfig, error) {
+	p.lock.Lock()
+	p.calls++
+	p.lock.Unlock()
+	if p.release != nil {
+		<-p.release
+	}
+	p.lock.Lock()
+	p.ctxErr = ctx.Err()
+	p.lock.Unlock()
+	return authprovider.AuthConfig{Username: "user", Password: "password", ExpiresOn: p.expiresOn}, p.err
+}
+
+func (p *countingAuthProvider) getCalls() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.calls
+}
+
+// TestORASCreateRepository_RepositoryScopedAuthCache tests that credentials are shared by all references of a repository
+func TestORASCreateRepository_RepositoryScopedAuthCache(t *testing.T) {
+	ctx := context.Background()
+	store, err := createBaseStore("1.0.0", config.StorePluginConfig{"name": "oras"})
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+	provider := &countingAuthProvider{expiresOn: time.Now().Add(time.Hour)}
+	store.authProvider = provider
+
+	references := []string{
+		"registry.test/test/image:v1",
+		"registry.test/test/image:v2",
+		"registry.test/test/image@" + digest.FromString("subject").String(),
+	}
+	var first registry.Repository
+	for _, ref := range references {
+		repository, expiry, err := createDefaultRepository(ctx, store, common.Reference{Original: ref})
+		if err != nil {
+			t.Fatalf("failed to create repository: %v", err)
+		}
+		if first == nil {
+			first = repository
+		} else if repository != first {
+			t.Fatalf("expected the repository client to be reused for %s", ref)
+		}
+		store.addAuthCache(ref, repository, expiry)
+	}
+	if provider.getCalls() != 1 {
+		t.Fatalf("expected credentials to be provided once for the repository, got %d calls", provider.getCalls())
+	}
+
+	if _, _, err := createDefaultRepository(ctx, store, common.Reference{Original: "registry.test/test/other:v1"}); err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	if provider.getCalls() != 2 {
+		t.Fatalf("expected credentials to be provided for another repository, got %d calls", provider.getCalls())
+	}
+
+	store.evictAuthCache(references[1], nil)
+	if _, ok := store.authCache.Load("registry.test/test/image"); ok {
+		t.Fatalf("expected the repository credentials to be evicted")
+	}
+}
+
+// TestORASCreateRepository_SingleFlight tests that concurrent requests of a repository share a credential fetch
+func TestORASCreateRepository_SingleFlight(t *testing.T) {
+	ctx := context.Background()
+	store, err := createBaseStore("1.0.0", config.StorePluginConfig{"name": "oras"})
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+	provider := &countingAuthProvider{release: make(chan struct{})}
+	store.authProvider = provider
+
+	var wg sync.WaitGroup
+	repositories := make([]registry.Repository, 5)
+	for i := range repositories {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			repository, _, err := createDefaultRepository(ctx, store, common.Reference{Original: fmt.Sprintf("registry.test/test/image:v%d", i)})
+			if err != nil {
+				t.Errorf("failed to create repository: %v", err)
+			}
+			repositories[i] = repository
+		}(i)
+	}
+	// let all requests wait on the pending credential fetch
+	time.Sleep(100 * time.Millisecond)
+	close(provider.release)
+	wg.Wait()
+
+	if provider.getCalls() != 1 {
+		t.Fatalf("expected a single credential fetch, got %d", provider.getCalls())
+	}
+	for _, repository := range repositories {
+		if repository != repositories[0] {
+			t.Fatalf("expected concurrent requests to share the repository client")
+		}
+	}
+}
+
+// TestORASCreateRepository_SingleFlightDetached tests that canceling the request that started a credential fetch does not fail the requests sharing it
+func TestORASCreateRepository_SingleFlightDetached(t *testing.T) {
+	store, err := createBaseStore("1.0.0", config.StorePluginConfig{"name": "oras"})
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+	provider := &countingAuthProvider{release: make(chan struct{})}
+	store.authProvider = provider
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, _, err := createDefaultRepository(firstCtx, store, common.Reference{Original: "registry.test/test/image:v1"})
+		firstErr <- err
+	}()
+	// let the first request start the credential fetch
+	time.Sleep(100 * time.Millisecond)
+	type fetchResult struct {
+		repository registry.Repository
+		err        error
+	}
+	second := make(chan fetchResult, 1)
+	go func() {
+		repository, _, err := createDefaultRepository(context.Background(), store, common.Reference{Original: "registry.test/test/image:v2"})
+		second <- fetchResult{repository: repository, err: err}
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the canceled request to return its context error, got %v", err)
+	}
+	close(provider.release)
+	result := <-second
+	if result.err != nil || result.repository == nil {
+		t.Fatalf("expected the shared credential fetch to succeed, got %v", result.err)
+	}
+	if provider.getCalls() != 1 {
+		t.Fatalf("expected a single credential fetch, got %d", provider.getCalls())
+	}
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+	if provider.ctxErr != nil {
+		t.Fatalf("expected the credential fetch context not to be canceled, got %v", provider.ctxErr)
+	}
+}
+
+// TestORASCreateRepository_RefreshAheadOfExpiry tests that credentials are refreshed before they expire
+func TestORASCreateRepository_RefreshAheadOfExpiry(t *testing.T) {
+	ctx := context.Background()
+	store, err := createBaseStore("1.0.0", config.StorePluginConfig{"name": "oras"})
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+	ref := common.Reference{Original: "registry.test/test/image:v1"}
+	cached := mocks.TestRepository{}
+	expiry := time.Now().Add(authRefreshWindow / 2)
+	store.addAuthCache(ref.Original, cached, expiry)
+
+	provider := &countingAuthProvider{expiresOn: time.Now().Add(time.Hour)}
+	store.authProvider = provider
+	repository, refreshedExpiry, err := createDefaultRepository(ctx, store, ref)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	if provider.getCalls() != 1 || !refreshedExpiry.Equal(provider.expiresOn) || reflect.DeepEqual(repository, cached) {
+		t.Fatalf("expected credentials within the refresh window to be refreshed")
+	}
+
+	// the cached credentials are used until they expire if the refresh fails
+	store.addAuthCache(ref.Original, cached, expiry)
+	store.authProvider = &countingAuthProvider{err: errors.New("provider unavailable")}
+	repository, cachedExpiry, err := createDefaultRepository(ctx, store, ref)
+	if err != nil {
+		t.Fatalf("expected cached credentials to be used, got %v", err)
+	}
+	if !reflect.DeepEqual(repository, cached) || !cachedExpiry.Equal(expiry) {
+		t.Fatalf("expected the cached repository client to be returned")
+	}
+}
@@ -0,0 +1,424 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+)
+
+const (
+	// referrersFiltersAppliedHeader is set by registries that filter the referrers listing
+	referrersFiltersAppliedHeader = "OCI-Filters-Applied"
+	artifactTypeFilter            = "artifactType"
+	// limits the size of a referrers page read from the registry
+	maxReferrersPageBytes = 4 << 20
+	// registries are probed for Referrers API support again once their capability expires
+	referrersCapabilityTTL = time.Hour
+)
+
+const (
+	// ReferrersDiscoveryAuto uses the Referrers API of registries supporting it and the referrers tag schema otherwise
+	ReferrersDiscoveryAuto = "auto"
+	// ReferrersDiscoveryAPI lists referrers with the Referrers API of the OCI distribution spec
+	ReferrersDiscoveryAPI = "referrersAPI"
+	// ReferrersDiscoveryTagSchema lists referrers from the index tagged with the digest of the subject, e.g. sha256-d34db33f
+	ReferrersDiscoveryTagSchema = "tagSchema"
+	// ReferrersDiscoveryCosignTag is recorded for cosign attachments found through the .sig, .att and .sbom tags of the subject
+	ReferrersDiscoveryCosignTag = "cosignTag"
+)
+
+// referrersPageToken is the continuation state of a paged referrers listing
+type referrersPageToken struct {
+	// index of the artifact type filter being listed
+	FilterIndex int `json:"filterIndex"`
+	// link to the next page of the Referrers API, empty for the first page of a filter
+	Link string `json:"link,omitempty"`
+}
+
+// referrersDiscovery selects the mechanism used to list the referrers of a registry, and caches whether
+// registries support the Referrers API when the mechanism is detected
+type referrersDiscovery struct {
+	mode string
+	// capabilities maps a registry host to its referrersCapability
+	capabilities sync.Map
+	now          func() time.Time
+}
+
+// referrersCapability records whether a registry supports the Referrers API
+type referrersCapability struct {
+	supported bool
+	checkedOn time.Time
+}
+
+func newReferrersDiscovery(mode string) (*referrersDiscovery, error) {
+	switch mode {
+	case "":
+		mode = ReferrersDiscoveryAuto
+	case ReferrersDiscoveryAuto, ReferrersDiscoveryAPI, ReferrersDiscoveryTagSchema:
+	default:
+		return nil, fmt.Errorf("unsupported referrers discovery %q, must be one of %s, %s or %s", mode, ReferrersDiscoveryAuto, ReferrersDiscoveryAPI, ReferrersDiscoveryTagSchema)
+	}
+	return &referrersDiscovery{mode: mode, now: time.Now}, nil
+}
+
+// listReferrersPage returns a single page of referrers of the subject and the token of the next page.
+// Each artifact type is listed using the Referrers API filter, the referrers are filtered
+// client side if the registry does not apply the filter.
+func (d *referrersDiscovery) listReferrersPage(ctx context.Context, repository registry.Repository, subjectDesc oci.Descriptor, artifactTypes []string, nextToken string) ([]ocispecs.ReferenceDescriptor, string, error) {
+	filters := referrerstore.ArtifactTypeFilters(artifactTypes)
+	token, err := decodeReferrersPageToken(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+	if token.FilterIndex < 0 || token.FilterIndex >= len(filters) {
+		return nil, "", fmt.Errorf("invalid continuation token: artifact type index %d out of range", token.FilterIndex)
+	}
+
+	var fetchPage func(filter string, link string) ([]oci.Descriptor, string, string, error)
+	switch repo := repository.(type) {
+	case *remote.Repository:
+		fetchPage = func(filter string, link string) ([]oci.Descriptor, string, string, error) {
+			return d.fetchPage(ctx, repo, subjectDesc, filter, link)
+		}
+	case *mirrorRepository:
+		fetchPage = func(filter string, link string) ([]oci.Descriptor, string, string, error) {
+			return repo.referrersPage(ctx, d, subjectDesc, filter, link)
+		}
+	default:
+		// pagination is only available through the registry Referrers API
+		if nextToken != "" {
+			return nil, "", fmt.Errorf("invalid continuation token: repository does not support pagination")
+		}
+		if d.mode == ReferrersDiscoveryTagSchema {
+			var referrers []oci.Descriptor
+			for _, filter := range filters {
+				page, err := listTagSchemaReferrers(ctx, repository, subjectDesc, filter)
+				if err != nil {
+					return nil, "", err
+				}
+				referrers = append(referrers, page...)
+			}
+			return toReferenceDescriptors(referrers, ReferrersDiscoveryTagSchema), "", nil
+		}
+		referrers, err := listAllReferrers(ctx, repository, subjectDesc, filters)
+		return toReferenceDescriptors(referrers, ReferrersDiscoveryAPI), "", err
+	}
+
+	referrers, link, discoveredBy, err := fetchPage(filters[token.FilterIndex], token.Link)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := referrersPageToken{FilterIndex: token.FilterIndex, Link: link}
+	if link == "" {
+		if token.FilterIndex+1 == len(filters) {
+			return toReferenceDescriptors(referrers, discoveredBy), "", nil
+		}
+		next = referrersPageToken{FilterIndex: token.FilterIndex + 1}
+	}
+	nextToken, err = encodeReferrersPageToken(next)
+	return toReferenceDescriptors(referrers, discoveredBy), nextToken, err
+}
+
+// fetchPage requests a page of referrers using the Referrers API or the referrers tag schema, and returns
+// the referrers, the link to the next page and the mechanism the referrers were found with.
+// In auto mode, a registry returning 404 from the Referrers API is recorded as not supporting it,
+// and the referrers tag schema is used directly until the capability is checked again.
+func (d *referrersDiscovery) fetchPage(ctx context.Context, repository *remote.Repository, subjectDesc oci.Descriptor, filter string, link string) ([]oci.Descriptor, string, string, error) {
+	host := repository.Reference.Host()
+	// continuation links are only returned by the Referrers API
+	if link == "" && !d.useReferrersAPI(host) {
+		referrers, err := listTagSchemaReferrers(ctx, repository, subjectDesc, filter)
+		return referrers, "", ReferrersDiscoveryTagSchema, err
+	}
+
+	referrers, next, err := fetchReferrersPage(ctx, repository, subjectDesc, filter, link)
+	if err == nil {
+		d.setCapability(host, true)
+		return referrers, next, ReferrersDiscoveryAPI, nil
+	}
+	var errResp *errcode.ErrorResponse
+	if d.mode != ReferrersDiscoveryAuto || !errors.As(err, &errResp) || errResp.StatusCode != http.StatusNotFound || isNameUnknown(errResp) {
+		return nil, "", "", err
+	}
+	// a 404 returned by the Referrers API indicates that it is not supported,
+	// the referrers are listed using the referrers tag schema which is not paged
+	d.setCapability(host, false)
+	referrers, err = listTagSchemaReferrers(ctx, repository, subjectDesc, filter)
+	return referrers, "", ReferrersDiscoveryTagSchema, err
+}
+
+// useReferrersAPI returns whether the Referrers API of the registry host is used to list referrers
+func (d *referrersDiscovery) useReferrersAPI(host string) bool {
+	switch d.mode {
+	case ReferrersDiscoveryAPI:
+		return true
+	case ReferrersDiscoveryTagSchema:
+		return false
+	}
+	entry, ok := d.capabilities.Load(host)
+	if !ok {
+		return true
+	}
+	capability := entry.(referrersCapability)
+	// the Referrers API is probed again once the capability expires, registries may be upgraded
+	if d.now().Sub(capability.checkedOn) >= referrersCapabilityTTL {
+		return true
+	}
+	return capability.supported
+}
+
+// setCapability records whether the registry host supports the Referrers API when the mechanism is detected
+func (d *referrersDiscovery) setCapability(host string, supported bool) {
+	if d.mode != ReferrersDiscoveryAuto {
+		return
+	}
+	if previous, ok := d.capabilities.Load(host); !ok || previous.(referrersCapability).supported != supported {
+		logrus.Debugf("registry %s supports the referrers API: %v", host, supported)
+	}
+	d.capabilities.Store(host, referrersCapability{supported: supported, checkedOn: d.now()})
+}
+
+// listTagSchemaReferrers returns the referrers listed in the index tagged with the referrers tag schema of the subject
+func listTagSchemaReferrers(ctx context.Context, repository registry.Repository, subjectDesc oci.Descriptor, filter string) ([]oci.Descriptor, error) {
+	tag := referrersTag(subjectDesc.Digest)
+	indexDesc, rc, err := repository.FetchReference(ctx, tag)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+	if indexDesc.MediaType != oci.MediaTypeImageIndex {
+		logrus.Warnf("ignoring referrers tag %s of media type %s", tag, indexDesc.MediaType)
+		return nil, nil
+	}
+
+	var index oci.Index
+	if err := json.NewDecoder(io.LimitReader(rc, maxReferrersPageBytes)).Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to decode referrers index %s: %w", tag, err)
+	}
+	return filterReferrers(index.Manifests, filter), nil
+}
+
+// referrersTag returns the tag of the referrers index of a subject, e.g. sha256-d34db33f
+func referrersTag(subjectDigest digest.Digest) string {
+	return strings.Replace(subjectDigest.String(), ":", "-", 1)
+}
+
+// toReferenceDescriptors converts the referrers to reference descriptors recording how they were discovered
+func toReferenceDescriptors(referrers []oci.Descriptor, discoveredBy string) []ocispecs.ReferenceDescriptor {
+	references := make([]ocispecs.ReferenceDescriptor, 0, len(referrers))
+	for _, referrer := range referrers {
+		reference := OciDescriptorToReferenceDescriptor(referrer)
+		reference.DiscoveredBy = discoveredBy
+		references = append(references, reference)
+	}
+	return references
+}
+
+// listAllReferrers lists the referrers matching any of the filters in a single result
+func listAllReferrers(ctx context.Context, repository registry.Repository, subjectDesc oci.Descriptor, filters []string) ([]oci.Descriptor, error) {
+	var referrers []oci.Descriptor
+	for _, filter := range filters {
+		if err := repository.Referrers(ctx, subjectDesc, filter, func(page []oci.Descriptor) error {
+			// the filter is not guaranteed to be applied by every repository implementation
+			referrers = append(referrers, filterReferrers(page, filter)...)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return referrers, nil
+}
+
+// fetchReferrersPage requests a page of the Referrers API and returns the referrers and the link to the next page
+func fetchReferrersPage(ctx context.Context, repository *remote.Repository, subjectDesc oci.Descriptor, filter string, link string) ([]oci.Descriptor, string, error) {
+	pageURL := link
+	if pageURL == "" {
+		pageURL = buildReferrersURL(repository, subjectDesc, filter)
+	} else if err := validateReferrersLink(repository, link); err != nil {
+		return nil, "", err
+	}
+
+	ctx = auth.AppendScopes(ctx, auth.ScopeRepository(repository.Reference.Repository, auth.ActionPull))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	client := repository.Client
+	if client == nil {
+		client = auth.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", parseErrorResponse(resp)
+	}
+
+	var index oci.Index
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxReferrersPageBytes)).Decode(&index); err != nil {
+		return nil, "", fmt.Errorf("%s %q: failed to decode referrers response: %w", resp.Request.Method, resp.Request.URL, err)
+	}
+	referrers := index.Manifests
+	if filter != "" && !isReferrersFilterApplied(resp, index) {
+		referrers = filterReferrers(referrers, filter)
+	}
+
+	next, err := parseNextLink(resp)
+	return referrers, next, err
+}
+
+func buildReferrersURL(repository *remote.Repository, subjectDesc oci.Descriptor, filter string) string {
+	scheme := "https"
+	if repository.PlainHTTP {
+		scheme = "http"
+	}
+	referrersURL := fmt.Sprintf("%s://%s/v2/%s/referrers/%s", scheme, repository.Reference.Host(), repository.Reference.Repository, subjectDesc.Digest)
+	if filter != "" {
+		referrersURL += "?" + url.Values{artifactTypeFilter: {filter}}.Encode()
+	}
+	return referrersURL
+}
+
+// validateReferrersLink ensures a continuation token cannot redirect requests and credentials to another host
+func validateReferrersLink(repository *remote.Repository, link string) error {
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return fmt.Errorf("invalid continuation token: %w", err)
+	}
+	if linkURL.Host != repository.Reference.Host() {
+		return fmt.Errorf("invalid continuation token: host %s does not match registry %s", linkURL.Host, repository.Reference.Host())
+	}
+	return nil
+}
+
+func isReferrersFilterApplied(resp *http.Response, index oci.Index) bool {
+	applied := resp.Header.Get(referrersFiltersAppliedHeader)
+	if applied == "" {
+		applied = index.Annotations[oci.AnnotationReferrersFiltersApplied]
+	}
+	for _, filter := range strings.Split(applied, ",") {
+		if strings.TrimSpace(filter) == artifactTypeFilter {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNextLink returns the absolute URL of the next page in the Link header, empty if it is the last page
+func parseNextLink(resp *http.Response) (string, error) {
+	link := resp.Header.Get("Link")
+	if link == "" {
+		return "", nil
+	}
+	start, end := strings.IndexByte(link, '<'), strings.IndexByte(link, '>')
+	if start != 0 || end == -1 {
+		return "", fmt.Errorf("invalid next link %q", link)
+	}
+	linkURL, err := resp.Request.URL.Parse(link[1:end])
+	if err != nil {
+		return "", err
+	}
+	return linkURL.String(), nil
+}
+
+func parseErrorResponse(resp *http.Response) error {
+	errResp := &errcode.ErrorResponse{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL,
+		StatusCode: resp.StatusCode,
+	}
+	var body struct {
+		Errors errcode.Errors `json:"errors"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxReferrersPageBytes)).Decode(&body); err == nil {
+		errResp.Errors = body.Errors
+	}
+	return errResp
+}
+
+func isNameUnknown(errResp *errcode.ErrorResponse) bool {
+	for _, err := range errResp.Errors {
+		if err.Code == errcode.ErrorCodeNameUnknown {
+			return true
+		}
+	}
+	return false
+}
+
+func filterReferrers(referrers []oci.Descriptor, artifactType string) []oci.Descriptor {
+	if artifactType == "" {
+		return referrers
+	}
+	filtered := []oci.Descriptor{}
+	for _, referrer := range referrers {
+		if referrer.ArtifactType == artifactType {
+			filtered = append(filtered, referrer)
+		}
+	}
+	return filtered
+}
+
+func encodeReferrersPageToken(token referrersPageToken) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeReferrersPageToken(nextToken string) (referrersPageToken, error) {
+	token := referrersPageToken{}
+	if nextToken == "" {
+		return token, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(nextToken)
+	if err != nil {
+		return token, fmt.Errorf("invalid continuation token: %w", err)
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return token, fmt.Errorf("invalid continuation token: %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,360 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deislabs/ratify/pkg/referrerstore/oras/mocks"
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+const (
+	testArtifactTypeNotation = "application/vnd.cncf.notary.signature"
+	testArtifactTypeSbom     = "application/spdx+json"
+	testReferrersRepository  = "test/image"
+)
+
+var testSubjectDigest = digest.FromString("subject")
+
+// newTestReferrersRegistry serves two pages of referrers and applies the artifactType filter if filterApplied is set.
+// If the Referrers API is not supported, the referrers are served by the referrers tag schema.
+func newTestReferrersRegistry(t *testing.T, supported bool, filterApplied bool) (*httptest.Server, *[]string) {
+	requests := []string{}
+	referrers := []oci.Descriptor{
+		{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("1"), ArtifactType: testArtifactTypeNotation},
+		{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("2"), ArtifactType: testArtifactTypeSbom},
+		{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("3"), ArtifactType: testArtifactTypeNotation},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		if r.URL.Path == fmt.Sprintf("/v2/%s/manifests/%s", testReferrersRepository, referrersTag(testSubjectDigest)) && !supported {
+			index, err := json.Marshal(oci.Index{MediaType: oci.MediaTypeImageIndex, Manifests: referrers})
+			if err != nil {
+				t.Errorf("failed to marshal referrers index: %v", err)
+			}
+			w.Header().Set("Content-Type", oci.MediaTypeImageIndex)
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(index).String())
+			if _, err := w.Write(index); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+			return
+		}
+		if r.URL.Path != fmt.Sprintf("/v2/%s/referrers/%s", testReferrersRepository, testSubjectDigest) || !supported {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		page := referrers[:2]
+		if r.URL.Query().Get("last") != "" {
+			page = referrers[2:]
+		} else {
+			w.Header().Set("Link", fmt.Sprintf("</v2/%s/referrers/%s?last=2&%s>; rel=\"next\"", testReferrersRepository, testSubjectDigest, r.URL.RawQuery))
+		}
+		if artifactType := r.URL.Query().Get("artifactType"); artifactType != "" && filterApplied {
+			page = filterReferrers(page, artifactType)
+			w.Header().Set("OCI-Filters-Applied", "artifactType")
+		}
+		w.Header().Set("Content-Type", oci.MediaTypeImageIndex)
+		if err := json.NewEncoder(w).Encode(oci.Index{MediaType: oci.MediaTypeImageIndex, Manifests: page}); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	return server, &requests
+}
+
+func newTestReferrersDiscovery(t *testing.T, mode string) *referrersDiscovery {
+	discovery, err := newReferrersDiscovery(mode)
+	if err != nil {
+		t.Fatalf("failed to create referrers discovery: %v", err)
+	}
+	return discovery
+}
+
+func newTestRemoteRepository(t *testing.T, server *httptest.Server) *remote.Repository {
+	host := strings.TrimPrefix(server.URL, "http://")
+	repository, err := remote.NewRepository(host + "/" + testReferrersRepository)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	repository.PlainHTTP = true
+	return repository
+}
+
+func TestListReferrersPage_Paginates(t *testing.T) {
+	server, requests := newTestReferrersRegistry(t, true, true)
+	defer server.Close()
+	repository := newTestRemoteRepository(t, server)
+	subject := oci.Descriptor{Digest: testSubjectDigest}
+
+	discovery := newTestReferrersDiscovery(t, ReferrersDiscoveryAuto)
+
+	referrers, nextToken, err := discovery.listReferrersPage(context.Background(), repository, subject, nil, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(referrers) != 2 || nextToken == "" {
+		t.Fatalf("expected first page of 2 referrers with a continuation token, got %v referrers and token %q", len(referrers), nextToken)
+	}
+
+	referrers, nextToken, err = discovery.listReferrersPage(context.Background(), repository, subject, nil, nextToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(referrers) != 1 || nextToken != "" {
+		t.Fatalf("expected last page of 1 referrer without continuation token, got %v referrers and token %q", len(referrers), nextToken)
+	}
+	if len(*requests) != 2 {
+		t.Fatalf("expected one request per page, got %v", *requests)
+	}
+}
+
+func TestListReferrersPage_FiltersArtifactTypes(t *testing.T) {
+	testCases := []struct {
+		name          string
+		filterApplied bool
+	}{
+		{name: "server side filter", filterApplied: true},
+		{name: "client side filter", filterApplied: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, requests := newTestReferrersRegistry(t, true, tc.filterApplied)
+			defer server.Close()
+			repository := newTestRemoteRepository(t, server)
+			subject := oci.Descriptor{Digest: testSubjectDigest}
+
+			discovery := newTestReferrersDiscovery(t, ReferrersDiscoveryAuto)
+
+			artifactTypes := []string{testArtifactTypeNotation, testArtifactTypeSbom}
+			found := map[string]int{}
+			nextToken := ""
+			for {
+				referrers, token, err := discovery.listReferrersPage(context.Background(), repository, subject, artifactTypes, nextToken)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				for _, referrer := range referrers {
+					found[referrer.ArtifactType]++
+				}
+				if nextToken = token; nextToken == "" {
+					break
+				}
+			}
+
+			if found[testArtifactTypeNotation] != 2 || found[testArtifactTypeSbom] != 1 {
+				t.Fatalf("unexpected referrers found %v", found)
+			}
+			for _, request := range *requests {
+				if !strings.Contains(request, "artifactType=") {
+					t.Fatalf("expected artifactType filter to be sent to the registry, got request %v", request)
+				}
+			}
+		})
+	}
+}
+
+func TestListReferrersPage_WildcardArtifactType(t *testing.T) {
+	server, requests := newTestReferrersRegistry(t, true, true)
+	defer server.Close()
+	repository := newTestRemoteRepository(t, server)
+	subject := oci.Descriptor{Digest: testSubjectDigest}
+	discovery := newTestReferrersDiscovery(t, ReferrersDiscoveryAuto)
+
+	listAll := func(artifactTypes []string) map[string]int {
+		found := map[string]int{}
+		nextToken := ""
+		for {
+			referrers, token, err := discovery.listReferrersPage(context.Background(), repository, subject, artifactTypes, nextToken)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			for _, referrer := range referrers {
+				found[referrer.ArtifactType]++
+			}
+			if nextToken = token; nextToken == "" {
+				return found
+			}
+		}
+	}
+
+	// nested verification lists the referrers of a referrer with the "*" artifact type
+	found := listAll([]string{"*"})
+	if found[testArtifactTypeNotation] != 2 || found[testArtifactTypeSbom] != 1 {
+		t.Fatalf("expected all referrers, got %v", found)
+	}
+	for _, request := range *requests {
+		if strings.Contains(request, "artifactType=") {
+			t.Fatalf("expected no artifactType filter to be sent to the registry, got request %v", request)
+		}
+	}
+}
+
+func TestListReferrersPage_FallbackToTagSchema(t *testing.T) {
+	server, requests := newTestReferrersRegistry(t, false, false)
+	defer server.Close()
+	repository := newTestRemoteRepository(t, server)
+	discovery := newTestReferrersDiscovery(t, ReferrersDiscoveryAuto)
+	subject := oci.Descriptor{Digest: testSubjectDigest}
+
+	referrers, nextToken, err := discovery.listReferrersPage(context.Background(), repository, subject, []string{testArtifactTypeNotation}, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(referrers) != 2 || nextToken != "" {
+		t.Fatalf("expected the notation referrers from the referrers tag schema, got %v referrers and token %q", len(referrers), nextToken)
+	}
+	for _, referrer := range referrers {
+		if referrer.DiscoveredBy != ReferrersDiscoveryTagSchema {
+			t.Fatalf("expected referrer discovered by the referrers tag schema, got %q", referrer.DiscoveredBy)
+		}
+	}
+
+	// the registry is known not to support the Referrers API
+	*requests = nil
+	if _, _, err := discovery.listReferrersPage(context.Background(), repository, subject, nil, ""); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(*requests) != 1 || strings.Contains((*requests)[0], "/referrers/") {
+		t.Fatalf("expected only the referrers tag schema to be requested, got %v", *requests)
+	}
+}
+
+func TestReferrersDiscovery_Modes(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		mode                 string
+		supported            bool
+		expectErr            bool
+		expectedDiscoveredBy string
+		expectedReferrers    int
+	}{
+		{name: "auto with referrers API", mode: ReferrersDiscoveryAuto, supported: true, expectedDiscoveredBy: ReferrersDiscoveryAPI, expectedReferrers: 2},
+		{name: "auto without referrers API", mode: ReferrersDiscoveryAuto, supported: false, expectedDiscoveredBy: ReferrersDiscoveryTagSchema, expectedReferrers: 3},
+		{name: "forced referrers API", mode: ReferrersDiscoveryAPI, supported: true, expectedDiscoveredBy: ReferrersDiscoveryAPI, expectedReferrers: 2},
+		{name: "forced referrers API not supported", mode: ReferrersDiscoveryAPI, supported: false, expectErr: true},
+		{name: "forced tag schema", mode: ReferrersDiscoveryTagSchema, supported: false, expectedDiscoveredBy: ReferrersDiscoveryTagSchema, expectedReferrers: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, _ := newTestReferrersRegistry(t, tc.supported, false)
+			defer server.Close()
+			repository := newTestRemoteRepository(t, server)
+
+			referrers, _, err := newTestReferrersDiscovery(t, tc.mode).listReferrersPage(context.Background(), repository, oci.Descriptor{Digest: testSubjectDigest}, nil, "")
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if len(referrers) != tc.expectedReferrers {
+				t.Fatalf("expected %d referrers, got %d", tc.expectedReferrers, len(referrers))
+			}
+			for _, referrer := range referrers {
+				if referrer.DiscoveredBy != tc.expectedDiscoveredBy {
+					t.Fatalf("expected referrer discovered by %q, got %q", tc.expectedDiscoveredBy, referrer.DiscoveredBy)
+				}
+			}
+		})
+	}
+}
+
+func TestReferrersDiscovery_CapabilityExpires(t *testing.T) {
+	now := time.Now()
+	discovery := newTestReferrersDiscovery(t, ReferrersDiscoveryAuto)
+	discovery.now = func() time.Time { return now }
+
+	if !discovery.useReferrersAPI("registry.test") {
+		t.Fatalf("expected the referrers API to be probed for an unknown registry")
+	}
+	discovery.setCapability("registry.test", false)
+	if discovery.useReferrersAPI("registry.test") {
+		t.Fatalf("expected the referrers tag schema for a registry without referrers API")
+	}
+	now = now.Add(referrersCapabilityTTL)
+	if !discovery.useReferrersAPI("registry.test") {
+		t.Fatalf("expected the referrers API to be probed again once the capability expires")
+	}
+}
+
+func TestNewReferrersDiscovery(t *testing.T) {
+	discovery, err := newReferrersDiscovery("")
+	if err != nil || discovery.mode != ReferrersDiscoveryAuto {
+		t.Fatalf("expected auto discovery by default, got %v", err)
+	}
+	if _, err := newReferrersDiscovery("registry"); err == nil {
+		t.Fatalf("expected error for unsupported referrers discovery")
+	}
+}
+
+func TestListReferrersPage_InvalidToken(t *testing.T) {
+	server, _ := newTestReferrersRegistry(t, true, true)
+	defer server.Close()
+	repository := newTestRemoteRepository(t, server)
+	subject := oci.Descriptor{Digest: testSubjectDigest}
+
+	otherHostToken, _ := encodeReferrersPageToken(referrersPageToken{Link: "https://example.com/v2/test/image/referrers/" + testSubjectDigest.String()})
+	outOfRangeToken, _ := encodeReferrersPageToken(referrersPageToken{FilterIndex: 2})
+	for _, token := range []string{"not a token", otherHostToken, outOfRangeToken} {
+		if _, _, err := newTestReferrersDiscovery(t, ReferrersDiscoveryAuto).listReferrersPage(context.Background(), repository, subject, nil, token); err == nil {
+			t.Fatalf("expected error for continuation token %q", token)
+		}
+	}
+}
+
+func TestListReferrersPage_NonRemoteRepository(t *testing.T) {
+	repository := mocks.TestRepository{
+		ReferrersList: []oci.Descriptor{
+			{Digest: digest.FromString("1"), ArtifactType: testArtifactTypeNotation},
+			{Digest: digest.FromString("2"), ArtifactType: testArtifactTypeSbom},
+		},
+	}
+
+	referrers, nextToken, err := newTestReferrersDiscovery(t, ReferrersDiscoveryAuto).listReferrersPage(context.Background(), repository, oci.Descriptor{Digest: testSubjectDigest}, []string{testArtifactTypeSbom}, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(referrers) != 1 || referrers[0].ArtifactType != testArtifactTypeSbom || nextToken != "" {
+		t.Fatalf("expected the single sbom referrer, got %v and token %q", referrers, nextToken)
+	}
+	if referrers[0].DiscoveredBy != ReferrersDiscoveryAPI {
+		t.Fatalf("expected referrer discovered by the referrers API, got %q", referrers[0].DiscoveredBy)
+	}
+}
+
+func TestParseNextLink(t *testing.T) {
+	requestURL, _ := url.Parse("https://registry.test/v2/test/image/referrers/" + testSubjectDigest.String())
+	resp := &http.Response{Header: http.Header{}, Request: &http.Request{URL: requestURL}}
+	resp.Header.Set("Link", "</v2/test/image/referrers/sha256:abc?last=1>; rel=\"next\"")
+
+	link, err := parseNextLink(resp)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if link != "https://registry.test/v2/test/image/referrers/sha256:abc?last=1" {
+		t.Fatalf("unexpected next link %v", link)
+	}
+}
@@ -0,0 +1,364 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"oras.land/oras-go/v2/registry/remote/retry"
+
+	"github.com/deislabs/ratify/pkg/metrics"
+)
+
+const (
+	defaultMaxRetryAfter             = 10 * time.Second
+	defaultCircuitBreakerThreshold   = 5
+	defaultCircuitBreakerOpenTimeout = 30 * time.Second
+)
+
+const (
+	circuitClosed int64 = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+// ErrCircuitOpen is returned for requests to a registry that failed repeatedly, until the circuit breaker lets a request probe it again
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// RetryConfig describes how failed registry requests are retried
+type RetryConfig struct {
+	// MaxRetries is the maximum number of retries of a request, 0 disables retries
+	MaxRetries *int `json:"maxRetries,omitempty"`
+	// MinWait and MaxWait bound the jittered exponential backoff between retries
+	MinWait string `json:"minWait,omitempty"`
+	MaxWait string `json:"maxWait,omitempty"`
+	// MaxRetryAfter is the longest wait requested by a Retry-After header that is honoured, requests asking for a longer wait are not retried
+	MaxRetryAfter string `json:"maxRetryAfter,omitempty"`
+}
+
+// CircuitBreakerConfig describes when requests to a registry fail fast
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed requests that opens the circuit, 0 disables the circuit breaker
+	FailureThreshold *int `json:"failureThreshold,omitempty"`
+	// OpenTimeout is how long requests fail fast before a single request is let through to probe the registry
+	OpenTimeout string `json:"openTimeout,omitempty"`
+}
+
+// registryTransport creates the http clients of a store, which share the retry policy and the circuit breakers of the registries
+type registryTransport struct {
+	policy   *retryPolicy
+	breakers *circuitBreakers
+}
+
+func newRegistryTransport(retryConf RetryConfig, breakerConf CircuitBreakerConfig) (*registryTransport, error) {
+	policy, err := newRetryPolicy(retryConf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry configuration: %w", err)
+	}
+	breakers, err := newCircuitBreakers(breakerConf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid circuit breaker configuration: %w", err)
+	}
+	return &registryTransport{policy: policy, breakers: breakers}, nil
+}
+
+// newClient creates an http client that fails fast for unavailable registries and retries failed requests
+func (t *registryTransport) newClient(tlsConfig *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = HttpMaxIdleConns
+	transport.MaxConnsPerHost = HttpMaxConnsPerHost
+	transport.MaxIdleConnsPerHost = HttpMaxIdleConnsPerHost
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	retryTransport := retry.NewTransport(transport)
+	retryTransport.Policy = func() retry.Policy {
+		return t.policy
+	}
+
+	// the circuit breaker records the outcome of a request after all of its retries
+	return &http.Client{Transport: &circuitBreakerTransport{base: retryTransport, breakers: t.breakers}}
+}
+
+// retryPolicy retries 408, 429, 5xx and dial timeouts with a jittered exponential backoff, honouring the Retry-After header
+type retryPolicy struct {
+	maxRetry      int
+	minWait       time.Duration
+	maxWait       time.Duration
+	maxRetryAfter time.Duration
+	backoff       retry.Backoff
+}
+
+func newRetryPolicy(conf RetryConfig) (*retryPolicy, error) {
+	policy := &retryPolicy{
+		maxRetry:      HttpRetryMax,
+		minWait:       HttpRetryDurationMin,
+		maxWait:       HttpRetryDurationMax,
+		maxRetryAfter: defaultMaxRetryAfter,
+		backoff:       retry.ExponentialBackoff(250*time.Millisecond, 2, 0.2),
+	}
+	if conf.MaxRetries != nil {
+		if *conf.MaxRetries < 0 {
+			return nil, fmt.Errorf("maxRetries must not be negative")
+		}
+		policy.maxRetry = *conf.MaxRetries
+	}
+	for _, duration := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{name: "minWait", value: conf.MinWait, dest: &policy.minWait},
+		{name: "maxWait", value: conf.MaxWait, dest: &policy.maxWait},
+		{name: "maxRetryAfter", value: conf.MaxRetryAfter, dest: &policy.maxRetryAfter},
+	} {
+		if err := parseDuration(duration.name, duration.value, duration.dest); err != nil {
+			return nil, err
+		}
+	}
+	if policy.minWait > policy.maxWait {
+		return nil, fmt.Errorf("minWait %v must not be greater than maxWait %v", policy.minWait, policy.maxWait)
+	}
+	return policy, nil
+}
+
+func (p *retryPolicy) Retry(attempt int, resp *http.Response, err error) (time.Duration, error) {
+	ctx, statusCode, host := context.Background(), 0, ""
+	if resp != nil {
+		if resp.Request != nil && resp.Request.URL != nil {
+			ctx, host = resp.Request.Context(), resp.Request.URL.Host
+		}
+		statusCode = resp.StatusCode
+		metrics.ReportRegistryRequestCount(ctx, statusCode, host)
+	}
+
+	if attempt >= p.maxRetry {
+		return -1, nil
+	}
+	if ok, err := retry.DefaultPredicate(resp, err); err != nil {
+		return -1, err
+	} else if !ok {
+		return -1, nil
+	}
+
+	wait, ok := retryAfter(resp, time.Now())
+	if ok {
+		if wait > p.maxRetryAfter {
+			logrus.Debugf("not retrying request to registry %s, Retry-After %v exceeds %v", host, wait, p.maxRetryAfter)
+			return -1, nil
+		}
+	} else {
+		wait = p.backoff(attempt, resp)
+		if wait < p.minWait {
+			wait = p.minWait
+		}
+		if wait > p.maxWait {
+			wait = p.maxWait
+		}
+	}
+	metrics.ReportRegistryRetry(ctx, statusCode, host)
+	return wait, nil
+}
+
+// retryAfter returns the wait requested by the Retry-After header of a 429 or 503 response, in seconds or as an HTTP date
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// circuitBreakers holds the circuit breaker of each registry host
+type circuitBreakers struct {
+	lock             sync.Mutex
+	failureThreshold int
+	openTimeout      time.Duration
+	breakers         map[string]*circuitBreaker
+	now              func() time.Time
+}
+
+// newCircuitBreakers returns nil if the circuit breaker is disabled
+func newCircuitBreakers(conf CircuitBreakerConfig) (*circuitBreakers, error) {
+	breakers := &circuitBreakers{
+		failureThreshold: defaultCircuitBreakerThreshold,
+		openTimeout:      defaultCircuitBreakerOpenTimeout,
+		breakers:         map[string]*circuitBreaker{},
+		now:              time.Now,
+	}
+	if conf.FailureThreshold != nil {
+		if *conf.FailureThreshold < 0 {
+			return nil, fmt.Errorf("failureThreshold must not be negative")
+		}
+		if *conf.FailureThreshold == 0 {
+			return nil, nil
+		}
+		breakers.failureThreshold = *conf.FailureThreshold
+	}
+	if err := parseDuration("openTimeout", conf.OpenTimeout, &breakers.openTimeout); err != nil {
+		return nil, err
+	}
+	return breakers, nil
+}
+
+func (c *circuitBreakers) get(host string) *circuitBreaker {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	breaker, ok := c.breakers[host]
+	if !ok {
+		breaker = &circuitBreaker{host: host, failureThreshold: c.failureThreshold, openTimeout: c.openTimeout, now: c.now}
+		c.breakers[host] = breaker
+	}
+	return breaker
+}
+
+// circuitBreaker opens after consecutive failed requests to a registry. Once open, requests fail fast until the
+// open timeout elapses, then a single request probes the registry and closes the circuit if it succeeds.
+type circuitBreaker struct {
+	lock             sync.Mutex
+	host             string
+	failureThreshold int
+	openTimeout      time.Duration
+	state            int64
+	failures         int
+	openedAt         time.Time
+	probing          bool
+	now              func() time.Time
+}
+
+// allow returns true if a request can be sent to the registry
+func (b *circuitBreaker) allow() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.setState(circuitHalfOpen)
+		b.probing = true
+		return true
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the state of the circuit with the outcome of an allowed request
+func (b *circuitBreaker) record(failed bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.setState(circuitClosed)
+		return
+	}
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.failureThreshold {
+		b.openedAt = b.now()
+		b.setState(circuitOpen)
+	}
+}
+
+// release lets another request probe the registry if the outcome of an allowed request is unknown
+func (b *circuitBreaker) release() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.probing = false
+}
+
+func (b *circuitBreaker) setState(state int64) {
+	if b.state == state {
+		return
+	}
+	switch state {
+	case circuitOpen:
+		logrus.Warnf("circuit breaker of registry %s opened after %d failed requests, failing fast for %v", b.host, b.failures, b.openTimeout)
+	case circuitClosed:
+		logrus.Infof("circuit breaker of registry %s closed", b.host)
+	}
+	b.state = state
+	metrics.ReportCircuitBreakerState(b.host, state)
+}
+
+// circuitBreakerTransport fails fast for registries whose circuit breaker is open
+type circuitBreakerTransport struct {
+	base     http.RoundTripper
+	breakers *circuitBreakers
+}
+
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.breakers == nil {
+		return t.base.RoundTrip(req)
+	}
+	breaker := t.breakers.get(req.URL.Host)
+	if !breaker.allow() {
+		return nil, fmt.Errorf("%w for registry %s", ErrCircuitOpen, req.URL.Host)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil && (errors.Is(err, context.Canceled) || errors.Is(req.Context().Err(), context.DeadlineExceeded)) {
+		// a request canceled or timed out by the caller says nothing about the registry
+		breaker.release()
+		return resp, err
+	}
+	breaker.record(err != nil || resp.StatusCode >= http.StatusInternalServerError)
+	return resp, err
+}
+
+func parseDuration(name string, value string, dest *time.Duration) error {
+	if value == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	if duration < 0 {
+		return fmt.Errorf("%s must not be negative", name)
+	}
+	*dest = duration
+	return nil
+}
@@ -0,0 +1,272 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestRegistryTransport(t *testing.T) *registryTransport {
+	transport, err := newRegistryTransport(RetryConfig{}, CircuitBreakerConfig{})
+	if err != nil {
+		t.Fatalf("failed to create registry transport: %v", err)
+	}
+	return transport
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestNewRegistryTransport(t *testing.T) {
+	testCases := []struct {
+		name          string
+		retry         RetryConfig
+		breaker       CircuitBreakerConfig
+		expectErr     bool
+		expectBreaker bool
+	}{
+		{name: "defaults", expectBreaker: true},
+		{name: "custom settings", retry: RetryConfig{MaxRetries: intPtr(2), MinWait: "100ms", MaxWait: "5s", MaxRetryAfter: "1m"}, breaker: CircuitBreakerConfig{FailureThreshold: intPtr(3), OpenTimeout: "1m"}, expectBreaker: true},
+		{name: "circuit breaker disabled", breaker: CircuitBreakerConfig{FailureThreshold: intPtr(0)}},
+		{name: "negative retries", retry: RetryConfig{MaxRetries: intPtr(-1)}, expectErr: true},
+		{name: "invalid duration", retry: RetryConfig{MaxWait: "soon"}, expectErr: true},
+		{name: "min wait greater than max wait", retry: RetryConfig{MinWait: "2s", MaxWait: "1s"}, expectErr: true},
+		{name: "negative open timeout", breaker: CircuitBreakerConfig{OpenTimeout: "-1s"}, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport, err := newRegistryTransport(tc.retry, tc.breaker)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if err == nil && tc.expectBreaker != (transport.breakers != nil) {
+				t.Fatalf("expected circuit breaker enabled %v", tc.expectBreaker)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name       string
+		statusCode int
+		header     string
+		expected   time.Duration
+		expectedOk bool
+	}{
+		{name: "seconds", statusCode: http.StatusTooManyRequests, header: "3", expected: 3 * time.Second, expectedOk: true},
+		{name: "http date", statusCode: http.StatusServiceUnavailable, header: now.Add(time.Minute).Format(http.TimeFormat), expected: time.Minute, expectedOk: true},
+		{name: "date in the past", statusCode: http.StatusServiceUnavailable, header: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0, expectedOk: true},
+		{name: "no header", statusCode: http.StatusTooManyRequests},
+		{name: "invalid header", statusCode: http.StatusTooManyRequests, header: "later"},
+		{name: "not a throttling status", statusCode: http.StatusInternalServerError, header: "3"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tc.statusCode, Header: http.Header{}}
+			if tc.header != "" {
+				resp.Header.Set("Retry-After", tc.header)
+			}
+			wait, ok := retryAfter(resp, now)
+			if wait != tc.expected || ok != tc.expectedOk {
+				t.Fatalf("expected %v %v, got %v %v", tc.expected, tc.expectedOk, wait, ok)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Retry(t *testing.T) {
+	policy, err := newRetryPolicy(RetryConfig{MaxRetries: intPtr(2), MinWait: "10ms", MaxWait: "100ms", MaxRetryAfter: "5s"})
+	if err != nil {
+		t.Fatalf("failed to create retry policy: %v", err)
+	}
+	response := func(statusCode int, retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: statusCode, Header: http.Header{}}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+
+	testCases := []struct {
+		name    string
+		attempt int
+		resp    *http.Response
+		min     time.Duration
+		max     time.Duration
+	}{
+		{name: "server error is retried with backoff", resp: response(http.StatusServiceUnavailable, ""), min: 10 * time.Millisecond, max: 100 * time.Millisecond},
+		{name: "retry after is honoured beyond max wait", resp: response(http.StatusTooManyRequests, "2"), min: 2 * time.Second, max: 2 * time.Second},
+		{name: "retry after exceeding the limit is not retried", resp: response(http.StatusTooManyRequests, "60"), min: -1, max: -1},
+		{name: "client error is not retried", resp: response(http.StatusNotFound, ""), min: -1, max: -1},
+		{name: "retries are exhausted", attempt: 2, resp: response(http.StatusServiceUnavailable, ""), min: -1, max: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wait, err := policy.Retry(tc.attempt, tc.resp, nil)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if wait < tc.min || wait > tc.max {
+				t.Fatalf("expected wait between %v and %v, got %v", tc.min, tc.max, wait)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_States(t *testing.T) {
+	now := time.Now()
+	breaker := &circuitBreaker{host: "registry.test", failureThreshold: 2, openTimeout: time.Minute, now: func() time.Time { return now }}
+
+	for i := 0; i < 2; i++ {
+		if !breaker.allow() {
+			t.Fatalf("expected closed circuit to allow requests")
+		}
+		breaker.record(true)
+	}
+	if breaker.state != circuitOpen || breaker.allow() {
+		t.Fatalf("expected circuit to open after consecutive failures")
+	}
+
+	// a single request probes the registry after the open timeout
+	now = now.Add(time.Minute)
+	if !breaker.allow() || breaker.state != circuitHalfOpen {
+		t.Fatalf("expected a probe request in half-open state")
+	}
+	if breaker.allow() {
+		t.Fatalf("expected a single probe request in half-open state")
+	}
+	breaker.record(true)
+	if breaker.state != circuitOpen || breaker.allow() {
+		t.Fatalf("expected failed probe to open the circuit")
+	}
+
+	now = now.Add(time.Minute)
+	if !breaker.allow() {
+		t.Fatalf("expected a probe request in half-open state")
+	}
+	breaker.record(false)
+	if breaker.state != circuitClosed || !breaker.allow() {
+		t.Fatalf("expected successful probe to close the circuit")
+	}
+}
+
+func TestRegistryTransport_FailsFast(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	transport, err := newRegistryTransport(RetryConfig{MaxRetries: intPtr(0)}, CircuitBreakerConfig{FailureThreshold: intPtr(2)})
+	if err != nil {
+		t.Fatalf("failed to create registry transport: %v", err)
+	}
+	client := transport.newClient(nil)
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL + "/v2/")
+		if err == nil {
+			resp.Body.Close()
+		}
+		if i == 2 && !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected open circuit error, got %v", err)
+		}
+	}
+	if requests != 2 {
+		t.Fatalf("expected requests to fail fast once the circuit is open, got %d requests", requests)
+	}
+
+	// the circuit breaker is shared by the clients of the store
+	if _, err := transport.newClient(nil).Get(server.URL + "/v2/"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected open circuit error from another client, got %v", err)
+	}
+}
+
+func TestCircuitBreakerTransport_IgnoresCallerContextErrors(t *testing.T) {
+	breakers, err := newCircuitBreakers(CircuitBreakerConfig{FailureThreshold: intPtr(1)})
+	if err != nil {
+		t.Fatalf("failed to create circuit breakers: %v", err)
+	}
+	transport := &circuitBreakerTransport{
+		base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}),
+		breakers: breakers,
+	}
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	expiredCtx, cancelExpired := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelExpired()
+
+	for _, ctx := range []context.Context{canceledCtx, expiredCtx} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://registry.test/v2/", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if _, err := transport.RoundTrip(req); errors.Is(err, ErrCircuitOpen) || err == nil {
+			t.Fatalf("expected the caller context error, got %v", err)
+		}
+	}
+	if breaker := breakers.get("registry.test"); breaker.state != circuitClosed || !breaker.allow() {
+		t.Fatalf("expected caller context errors not to open the circuit")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRegistryTransport_RetriesThrottledRequest(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := newTestRegistryTransport(t).newClient(nil).Get(server.URL + "/v2/")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests != 2 {
+		t.Fatalf("expected throttled request to be retried, got status %d after %d requests", resp.StatusCode, requests)
+	}
+	if time.Since(start) < time.Second {
+		t.Fatalf("expected the retry to wait for the Retry-After duration")
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// RegistryTLSConfig describes how a registry is accessed over TLS
+type RegistryTLSConfig struct {
+	// CACertPath is the path of a PEM bundle of CA certificates trusted in addition to the system roots
+	CACertPath string `json:"caCertPath,omitempty"`
+	// ClientCertPath and ClientKeyPath are the paths of the PEM certificate and key presented to the registry
+	ClientCertPath     string `json:"clientCertPath,omitempty"`
+	ClientKeyPath      string `json:"clientKeyPath,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+	UseHttp            bool   `json:"useHttp,omitempty"`
+}
+
+// hasCustomTLS returns true if the default http clients of the store cannot be used
+func (conf RegistryTLSConfig) hasCustomTLS() bool {
+	return conf.CACertPath != "" || conf.ClientCertPath != "" || conf.ClientKeyPath != "" || conf.InsecureSkipVerify
+}
+
+// createTLSClients creates the http clients of the registries with custom TLS settings
+func createTLSClients(registries map[string]RegistryTLSConfig, transport *registryTransport) (map[string]*http.Client, error) {
+	clients := map[string]*http.Client{}
+	for host, conf := range registries {
+		if !conf.hasCustomTLS() {
+			continue
+		}
+		client, err := createTLSClient(conf, transport)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS configuration of registry %s: %w", host, err)
+		}
+		clients[host] = client
+	}
+	return clients, nil
+}
+
+// createTLSClient creates an http client using the CA bundle and client certificate of conf
+func createTLSClient(conf RegistryTLSConfig, transport *registryTransport) (*http.Client, error) {
+	// #nosec G402
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+
+	if conf.CACertPath != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(conf.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", conf.CACertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if conf.ClientCertPath != "" || conf.ClientKeyPath != "" {
+		if conf.ClientCertPath == "" || conf.ClientKeyPath == "" {
+			return nil, fmt.Errorf("both clientCertPath and clientKeyPath must be set")
+		}
+		cert, err := tls.LoadX509KeyPair(conf.ClientCertPath, conf.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return transport.newClient(tlsConfig), nil
+}
@@ -0,0 +1,230 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/referrerstore/config"
+)
+
+type testTLSFiles struct {
+	caCertPath     string
+	clientCertPath string
+	clientKeyPath  string
+}
+
+// newTestTLSRegistry starts a TLS registry requiring a client certificate and writes its CA and a valid client certificate to files
+func newTestTLSRegistry(t *testing.T) (*httptest.Server, testTLSFiles) {
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ratify client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create client certificate: %v", err)
+	}
+	clientCert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatalf("failed to parse client certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal client key: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/test/image/manifests/v1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		w.Header().Set("Content-Length", "0")
+		w.Header().Set("Docker-Content-Digest", "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+
+	files := testTLSFiles{
+		caCertPath:     filepath.Join(dir, "ca.crt"),
+		clientCertPath: filepath.Join(dir, "client.crt"),
+		clientKeyPath:  filepath.Join(dir, "client.key"),
+	}
+	writeTestPEM(t, files.caCertPath, "CERTIFICATE", server.Certificate().Raw)
+	writeTestPEM(t, files.clientCertPath, "CERTIFICATE", certDER)
+	writeTestPEM(t, files.clientKeyPath, "EC PRIVATE KEY", keyDER)
+	return server, files
+}
+
+func writeTestPEM(t *testing.T, path string, blockType string, der []byte) {
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestCreateTLSClient(t *testing.T) {
+	server, files := newTestTLSRegistry(t)
+	defer server.Close()
+
+	testCases := []struct {
+		name      string
+		conf      RegistryTLSConfig
+		expectErr bool
+	}{
+		{
+			name:      "untrusted registry certificate",
+			conf:      RegistryTLSConfig{ClientCertPath: files.clientCertPath, ClientKeyPath: files.clientKeyPath},
+			expectErr: true,
+		},
+		{
+			name:      "missing client certificate",
+			conf:      RegistryTLSConfig{CACertPath: files.caCertPath},
+			expectErr: true,
+		},
+		{
+			name: "trusted CA bundle and client certificate",
+			conf: RegistryTLSConfig{CACertPath: files.caCertPath, ClientCertPath: files.clientCertPath, ClientKeyPath: files.clientKeyPath},
+		},
+		{
+			name: "insecure skip verify with client certificate",
+			conf: RegistryTLSConfig{InsecureSkipVerify: true, ClientCertPath: files.clientCertPath, ClientKeyPath: files.clientKeyPath},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := createTLSClient(tc.conf, newTestRegistryTransport(t))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			resp, err := client.Get(server.URL + "/v2/test/image/manifests/v1")
+			if err == nil {
+				resp.Body.Close()
+			}
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestCreateTLSClient_InvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		conf RegistryTLSConfig
+	}{
+		{name: "missing CA bundle", conf: RegistryTLSConfig{CACertPath: filepath.Join(dir, "missing.crt")}},
+		{name: "CA bundle without certificates", conf: RegistryTLSConfig{CACertPath: notPEM}},
+		{name: "client certificate without key", conf: RegistryTLSConfig{ClientCertPath: notPEM}},
+		{name: "invalid client certificate", conf: RegistryTLSConfig{ClientCertPath: notPEM, ClientKeyPath: notPEM}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := createTLSClient(tc.conf, newTestRegistryTransport(t)); err == nil {
+				t.Fatalf("expected error for invalid TLS configuration")
+			}
+		})
+	}
+}
+
+func TestCreateDefaultRepository_RegistryTLS(t *testing.T) {
+	server, files := newTestTLSRegistry(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	store, err := createBaseStore("1.0.0", config.StorePluginConfig{
+		"name":           "oras",
+		"localCachePath": t.TempDir(),
+		"tls": map[string]interface{}{
+			host: map[string]interface{}{
+				"caCertPath":     files.caCertPath,
+				"clientCertPath": files.clientCertPath,
+				"clientKeyPath":  files.clientKeyPath,
+			},
+			"plain.test": map[string]interface{}{
+				"useHttp": true,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create oras store: %v", err)
+	}
+
+	ref := common.Reference{Original: host + "/test/image:v1"}
+	repository, _, err := createDefaultRepository(context.Background(), store, ref)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	if _, err := repository.Resolve(context.Background(), ref.Original); err != nil {
+		t.Fatalf("expected registry to be reached with the configured TLS settings, got %v", err)
+	}
+
+	if plainHTTP, _ := store.registryClient("plain.test", "plain.test/test/image:v1"); !plainHTTP {
+		t.Fatalf("expected plain HTTP to be enabled for registry plain.test")
+	}
+	if plainHTTP, _ := store.registryClient("other.test", "other.test/test/image:v1"); plainHTTP {
+		t.Fatalf("expected plain HTTP to be disabled for registry other.test")
+	}
+}
+
+func TestCreateBaseStore_InvalidRegistryTLS(t *testing.T) {
+	_, err := createBaseStore("1.0.0", config.StorePluginConfig{
+		"name":           "oras",
+		"localCachePath": t.TempDir(),
+		"tls": map[string]interface{}{
+			"registry.test": map[string]interface{}{
+				"caCertPath": filepath.Join(t.TempDir(), "missing.crt"),
+			},
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error for missing CA bundle")
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"google.golang.org/grpc"
+
+	orchestratorpb "github.com/deislabs/ratify/experimental/proto/v1/orchestrator"
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/config"
+	"github.com/deislabs/ratify/pkg/referrerstore/types"
+)
+
+// Store is the referrer store of a verifier plugin reading manifests and blobs from the host.
+// Referrers and subject descriptors are not served by the host, they are read from a store created by the plugin.
+type Store struct {
+	name        string
+	config      config.StoreConfig
+	conn        *grpc.ClientConn
+	client      orchestratorpb.PluginOrchestratorClient
+	createLocal func() (referrerstore.ReferrerStore, error)
+
+	localOnce sync.Once
+	local     referrerstore.ReferrerStore
+	localErr  error
+}
+
+// NewStore connects to the content socket of the host. createLocal creates the store of the plugin
+// for the operations the host does not serve, it is only called if needed.
+func NewStore(socketPath string, storeConfig config.StoreConfig, createLocal func() (referrerstore.ReferrerStore, error)) (*Store, error) {
+	conn, err := grpcplugin.Dial(grpcplugin.UnixScheme + socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to content socket: %w", err)
+	}
+	return &Store{
+		name:        fmt.Sprintf("%v", storeConfig.Store[types.Name]),
+		config:      storeConfig,
+		conn:        conn,
+		client:      orchestratorpb.NewPluginOrchestratorClient(conn),
+		createLocal: createLocal,
+	}, nil
+}
+
+// Close closes the connection to the host
+func (s *Store) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Store) Name() string {
+	return s.name
+}
+
+func (s *Store) GetConfig() *config.StoreConfig {
+	return &s.config
+}
+
+func (s *Store) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	local, err := s.localStore()
+	if err != nil {
+		return referrerstore.ListReferrersResult{}, err
+	}
+	return local.ListReferrers(ctx, subjectReference, artifactTypes, nextToken, subjectDesc)
+}
+
+func (s *Store) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+	response, err := s.client.GetBlobs(ctx, &orchestratorpb.GetBlobsRequest{
+		Artifact:        grpcplugin.ToProtoDescriptor(subjectReference.Original, oci.Descriptor{Digest: digest}),
+		StorePluginName: s.name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob %s from the host: %w", digest, err)
+	}
+	if len(response.Content) != 1 {
+		return nil, fmt.Errorf("host returned %d blobs for blob %s", len(response.Content), digest)
+	}
+	return response.Content[0], nil
+}
+
+// GetBlobReader returns a reader of the blob content returned by the host, the content is held in memory
+func (s *Store) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	content, err := s.GetBlobContent(ctx, subjectReference, digest)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (s *Store) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	response, err := s.client.GetManifest(ctx, &orchestratorpb.GetManifestRequest{
+		Subject:         grpcplugin.ToProtoDescriptor(subjectReference.Original, oci.Descriptor{Digest: subjectReference.Digest}),
+		Referrer:        grpcplugin.ToProtoReferrer(subjectReference.Original, referenceDesc),
+		StorePluginName: s.name,
+	})
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, fmt.Errorf("failed to get manifest %s from the host: %w", referenceDesc.Digest, err)
+	}
+	return grpcplugin.FromProtoManifest(response.Manifest)
+}
+
+func (s *Store) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	local, err := s.localStore()
+	if err != nil {
+		return nil, err
+	}
+	return local.GetSubjectDescriptor(ctx, subjectReference)
+}
+
+func (s *Store) localStore() (referrerstore.ReferrerStore, error) {
+	s.localOnce.Do(func() {
+		s.local, s.localErr = s.createLocal()
+	})
+	return s.local, s.localErr
+}
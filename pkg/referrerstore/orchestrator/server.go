@@ -0,0 +1,210 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package orchestrator serves the content of the referrer store of the host to external verifier plugins,
+// over a Unix socket implementing the GetBlobs and GetManifest RPCs of the experimental orchestrator.proto.
+// Plugins read manifests and blobs with the credentials, caches and metrics of the host instead of
+// creating their own store in every process.
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	orchestratorpb "github.com/deislabs/ratify/experimental/proto/v1/orchestrator"
+	"github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/utils"
+)
+
+const socketName = "content.sock"
+
+// stopTimeout bounds how long Stop waits for requests in flight before canceling them
+var stopTimeout = 10 * time.Second
+
+var (
+	// sharedServers maps a store to the server of its content shared by all verifications
+	sharedServers     = map[referrerstore.ReferrerStore]*Server{}
+	sharedServersLock sync.Mutex
+)
+
+func init() {
+	// the store of a plugin source is only read to verify the signatures of the plugin
+	plugin.SubscribeSourceStoreRelease(StopShared)
+}
+
+// Server serves the content of a referrer store to a verifier plugin
+type Server struct {
+	orchestratorpb.UnimplementedPluginOrchestratorServer
+	store      referrerstore.ReferrerStore
+	dir        string
+	grpcServer *grpc.Server
+}
+
+// Serve starts serving the content of store on a new Unix socket, only accessible to the current user.
+// The caller must stop the server once the plugin exits.
+func Serve(store referrerstore.ReferrerStore) (*Server, error) {
+	dir, err := os.MkdirTemp("", "ratify-content-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create content socket directory: %w", err)
+	}
+	listener, err := net.Listen("unix", filepath.Join(dir, socketName))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to listen on content socket: %w", err)
+	}
+
+	server := &Server{
+		store:      store,
+		dir:        dir,
+		grpcServer: grpcplugin.NewServer(),
+	}
+	orchestratorpb.RegisterPluginOrchestratorServer(server.grpcServer, server)
+	go func() {
+		if err := server.grpcServer.Serve(listener); err != nil {
+			logrus.Debugf("content socket %s closed: %v", server.SocketPath(), err)
+		}
+	}()
+	return server, nil
+}
+
+// ServeShared returns the server of the content of store, starting it on first use. The server is shared by the
+// plugins of every verification reading from store, it is stopped by StopShared once the store is no longer used.
+func ServeShared(store referrerstore.ReferrerStore) (*Server, error) {
+	if store == nil || !reflect.TypeOf(store).Comparable() {
+		return nil, fmt.Errorf("the content of store %T cannot be shared", store)
+	}
+	sharedServersLock.Lock()
+	defer sharedServersLock.Unlock()
+	if server, ok := sharedServers[store]; ok {
+		return server, nil
+	}
+	server, err := Serve(store)
+	if err != nil {
+		return nil, err
+	}
+	sharedServers[store] = server
+	return server, nil
+}
+
+// StopShared stops the shared server of the content of store, if it was started
+func StopShared(store referrerstore.ReferrerStore) {
+	if store == nil || !reflect.TypeOf(store).Comparable() {
+		return
+	}
+	sharedServersLock.Lock()
+	server, ok := sharedServers[store]
+	delete(sharedServers, store)
+	sharedServersLock.Unlock()
+	if ok {
+		server.Stop()
+	}
+}
+
+// StopAllShared stops the shared servers of all stores
+func StopAllShared() {
+	sharedServersLock.Lock()
+	servers := sharedServers
+	sharedServers = map[referrerstore.ReferrerStore]*Server{}
+	sharedServersLock.Unlock()
+	for _, server := range servers {
+		server.Stop()
+	}
+}
+
+// SocketPath returns the path of the Unix socket the content is served on
+func (s *Server) SocketPath() string {
+	return filepath.Join(s.dir, socketName)
+}
+
+// Stop closes the socket and waits for the requests in flight, canceling those still running after stopTimeout
+func (s *Server) Stop() {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(stopTimeout):
+		logrus.Warnf("canceling requests in flight on content socket %s", s.SocketPath())
+		s.grpcServer.Stop()
+	}
+	if err := os.RemoveAll(s.dir); err != nil {
+		logrus.Warnf("failed to remove content socket directory %s: %v", s.dir, err)
+	}
+}
+
+// GetBlobs returns the content of the blob described by the artifact, the raw path of the artifact is the subject reference
+func (s *Server) GetBlobs(ctx context.Context, request *orchestratorpb.GetBlobsRequest) (*orchestratorpb.GetBlobsResponse, error) {
+	if err := s.checkStoreName(request.StorePluginName); err != nil {
+		return nil, err
+	}
+	subjectReference, err := utils.ParseSubjectReference(request.GetArtifact().GetRawPath())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	blobDesc, err := grpcplugin.FromProtoDescriptor(request.Artifact)
+	if err != nil || blobDesc.Digest == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid blob descriptor: %v", err)
+	}
+
+	content, err := s.store.GetBlobContent(ctx, subjectReference, blobDesc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return &orchestratorpb.GetBlobsResponse{Artifact: request.Artifact, Content: [][]byte{content}}, nil
+}
+
+// GetManifest returns the reference manifest of the referrer of the subject
+func (s *Server) GetManifest(ctx context.Context, request *orchestratorpb.GetManifestRequest) (*orchestratorpb.GetManifestResponse, error) {
+	if err := s.checkStoreName(request.StorePluginName); err != nil {
+		return nil, err
+	}
+	subjectReference, err := utils.ParseSubjectReference(request.GetSubject().GetRawPath())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	referenceDesc, err := grpcplugin.FromProtoReferrer(request.Referrer)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid referrer: %v", err)
+	}
+
+	manifest, err := s.store.GetReferenceManifest(ctx, subjectReference, referenceDesc)
+	if err != nil {
+		return nil, err
+	}
+	return &orchestratorpb.GetManifestResponse{Manifest: grpcplugin.ToProtoManifest(subjectReference.Original, manifest)}, nil
+}
+
+// checkStoreName rejects requests for a store other than the one served, an empty name selects the served store
+func (s *Server) checkStoreName(name string) error {
+	if name != "" && name != s.store.Name() {
+		return status.Errorf(codes.NotFound, "store %s is not served, only store %s is", name, s.store.Name())
+	}
+	return nil
+}
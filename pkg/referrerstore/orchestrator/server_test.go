@@ -0,0 +1,299 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/config"
+	"github.com/deislabs/ratify/pkg/utils"
+)
+
+const (
+	testSubject       = "localhost:5000/net-monitor:v1@sha256:a0fc570a245b09ed752c42d600ee3bb5b4f77bbd70d8898780b7ab43454530eb"
+	testSubjectDigest = "sha256:a0fc570a245b09ed752c42d600ee3bb5b4f77bbd70d8898780b7ab43454530eb"
+)
+
+// testStore serves a single reference manifest and its blobs, and counts the requests it serves
+type testStore struct {
+	manifest ocispecs.ReferenceManifest
+	blobs    map[digest.Digest][]byte
+	requests int
+}
+
+func (s *testStore) Name() string {
+	return "oras"
+}
+
+func (s *testStore) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	s.requests++
+	return referrerstore.ListReferrersResult{}, nil
+}
+
+func (s *testStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+	s.requests++
+	if content, ok := s.blobs[digest]; ok {
+		return content, nil
+	}
+	return nil, fmt.Errorf("blob %s not found", digest)
+}
+
+func (s *testStore) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	content, err := s.GetBlobContent(ctx, subjectReference, digest)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (s *testStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	s.requests++
+	if subjectReference.Path != "localhost:5000/net-monitor" || subjectReference.Digest != testSubjectDigest {
+		return ocispecs.ReferenceManifest{}, fmt.Errorf("unexpected subject %s", subjectReference.Original)
+	}
+	return s.manifest, nil
+}
+
+func (s *testStore) GetConfig() *config.StoreConfig {
+	return &config.StoreConfig{}
+}
+
+func (s *testStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	s.requests++
+	return &ocispecs.SubjectDescriptor{Descriptor: oci.Descriptor{Digest: subjectReference.Digest}}, nil
+}
+
+func newTestStore() *testStore {
+	sbom := []byte(`{"spdxVersion":"SPDX-2.3"}`)
+	subject := oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("subject"), Size: 42}
+	return &testStore{
+		manifest: ocispecs.ReferenceManifest{
+			MediaType:    oci.MediaTypeArtifactManifest,
+			ArtifactType: "application/spdx+json",
+			Blobs:        []oci.Descriptor{{MediaType: "application/spdx+json", Digest: digest.FromBytes(sbom), Size: int64(len(sbom)), Annotations: map[string]string{"org.example.sbom": "true"}}},
+			Subject:      &subject,
+			Annotations:  map[string]string{"org.opencontainers.artifact.created": "2023-01-01T00:00:00Z"},
+		},
+		blobs: map[digest.Digest][]byte{digest.FromBytes(sbom): sbom},
+	}
+}
+
+func newTestClient(t *testing.T, host referrerstore.ReferrerStore, storeName string, local referrerstore.ReferrerStore) *Store {
+	server, err := Serve(host)
+	if err != nil {
+		t.Fatalf("failed to serve content: %v", err)
+	}
+	t.Cleanup(server.Stop)
+
+	storeConfig := config.StoreConfig{Store: config.StorePluginConfig{"name": storeName}}
+	client, err := NewStore(server.SocketPath(), storeConfig, func() (referrerstore.ReferrerStore, error) {
+		if local == nil {
+			return nil, fmt.Errorf("local store not available")
+		}
+		return local, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to content socket: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestStore_ReadsContentFromHost(t *testing.T) {
+	ctx := context.Background()
+	host := newTestStore()
+	local := newTestStore()
+	client := newTestClient(t, host, "oras", local)
+	subjectReference, err := utils.ParseSubjectReference(testSubject)
+	if err != nil {
+		t.Fatalf("failed to parse subject: %v", err)
+	}
+
+	referenceDesc := ocispecs.ReferenceDescriptor{
+		Descriptor:   oci.Descriptor{MediaType: oci.MediaTypeArtifactManifest, Digest: digest.FromString("sbom"), Size: 100},
+		ArtifactType: "application/spdx+json",
+	}
+	manifest, err := client.GetReferenceManifest(ctx, subjectReference, referenceDesc)
+	if err != nil {
+		t.Fatalf("expected manifest from the host, got %v", err)
+	}
+	if !reflect.DeepEqual(manifest, host.manifest) {
+		t.Fatalf("expected manifest %+v, got %+v", host.manifest, manifest)
+	}
+
+	blobDigest := manifest.Blobs[0].Digest
+	content, err := client.GetBlobContent(ctx, subjectReference, blobDigest)
+	if err != nil || !bytes.Equal(content, host.blobs[blobDigest]) {
+		t.Fatalf("expected blob from the host, got %s and error %v", content, err)
+	}
+	if _, err := client.GetBlobContent(ctx, subjectReference, digest.FromString("missing")); err == nil {
+		t.Fatalf("expected error for a blob missing from the host")
+	}
+
+	// subject descriptors are not served by the host
+	if _, err := client.GetSubjectDescriptor(ctx, subjectReference); err != nil {
+		t.Fatalf("expected subject descriptor from the local store, got %v", err)
+	}
+	if host.requests != 3 || local.requests != 1 {
+		t.Fatalf("expected 3 requests to the host and 1 to the local store, got %d and %d", host.requests, local.requests)
+	}
+}
+
+func TestStore_OtherStoreName(t *testing.T) {
+	client := newTestClient(t, newTestStore(), "ocilayout", nil)
+	subjectReference, err := utils.ParseSubjectReference(testSubject)
+	if err != nil {
+		t.Fatalf("failed to parse subject: %v", err)
+	}
+
+	if _, err := client.GetBlobContent(context.Background(), subjectReference, digest.FromString("sbom")); err == nil {
+		t.Fatalf("expected error reading from a store the host does not serve")
+	}
+	if _, err := client.ListReferrers(context.Background(), subjectReference, nil, "", nil); err == nil {
+		t.Fatalf("expected error from the unavailable local store")
+	}
+}
+
+func TestServer_Stop(t *testing.T) {
+	server, err := Serve(newTestStore())
+	if err != nil {
+		t.Fatalf("failed to serve content: %v", err)
+	}
+	if _, err := os.Stat(server.SocketPath()); err != nil {
+		t.Fatalf("expected content socket, got %v", err)
+	}
+	server.Stop()
+	if _, err := os.Stat(server.SocketPath()); !os.IsNotExist(err) {
+		t.Fatalf("expected content socket to be removed, got %v", err)
+	}
+}
+
+// blockingStore blocks blob requests until released or canceled
+type blockingStore struct {
+	*testStore
+	started  chan struct{}
+	released chan struct{}
+}
+
+func (s *blockingStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+	close(s.started)
+	select {
+	case <-s.released:
+		return s.testStore.GetBlobContent(ctx, subjectReference, digest)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestServer_StopWaitsForRequestsInFlight(t *testing.T) {
+	testCases := []struct {
+		name        string
+		release     bool
+		expectError bool
+	}{
+		{name: "request completes", release: true},
+		{name: "request canceled after timeout", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defaultStopTimeout := stopTimeout
+			stopTimeout = 100 * time.Millisecond
+			defer func() { stopTimeout = defaultStopTimeout }()
+
+			store := &blockingStore{testStore: newTestStore(), started: make(chan struct{}), released: make(chan struct{})}
+			server, err := Serve(store)
+			if err != nil {
+				t.Fatalf("failed to serve content: %v", err)
+			}
+			storeConfig := config.StoreConfig{Store: config.StorePluginConfig{"name": "oras"}}
+			client, err := NewStore(server.SocketPath(), storeConfig, func() (referrerstore.ReferrerStore, error) {
+				return nil, fmt.Errorf("local store not available")
+			})
+			if err != nil {
+				t.Fatalf("failed to connect to content socket: %v", err)
+			}
+			defer client.Close()
+			subjectReference, _ := utils.ParseSubjectReference(testSubject)
+			blob := store.manifest.Blobs[0]
+
+			result := make(chan error, 1)
+			go func() {
+				_, err := client.GetBlobContent(context.Background(), subjectReference, blob.Digest)
+				result <- err
+			}()
+			<-store.started
+
+			stopped := make(chan struct{})
+			go func() {
+				server.Stop()
+				close(stopped)
+			}()
+			if tc.release {
+				close(store.released)
+			}
+
+			if err := <-result; tc.expectError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectError, err)
+			}
+			select {
+			case <-stopped:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("expected the server to stop")
+			}
+		})
+	}
+}
+
+func TestServeShared(t *testing.T) {
+	defer StopAllShared()
+	store := newTestStore()
+	server, err := ServeShared(store)
+	if err != nil {
+		t.Fatalf("failed to serve content: %v", err)
+	}
+	if shared, err := ServeShared(store); err != nil || shared != server {
+		t.Fatalf("expected the server of the store to be reused, got %v", err)
+	}
+	if other, err := ServeShared(newTestStore()); err != nil || other == server {
+		t.Fatalf("expected another store to be served by another server, got %v", err)
+	}
+
+	StopShared(store)
+	if _, err := os.Stat(server.SocketPath()); !os.IsNotExist(err) {
+		t.Fatalf("expected content socket to be removed, got %v", err)
+	}
+	restarted, err := ServeShared(store)
+	if err != nil || restarted == server {
+		t.Fatalf("expected a new server once the previous one is stopped, got %v", err)
+	}
+	if _, err := os.Stat(restarted.SocketPath()); err != nil {
+		t.Fatalf("expected content socket, got %v", err)
+	}
+}
@@ -24,4 +24,6 @@ const (
 	SubjectEnvKey         = "RATIFY_STORE_SUBJECT"
 	VersionEnvKey         = "RATIFY_STORE_VERSION"
 	ArgsEnvKey            = "RATIFY_STORE_ARGS"
+	// GRPCAddressEnvKey is the address a plugin built with the skeleton is served at over gRPC instead of running a single command
+	GRPCAddressEnvKey = "RATIFY_STORE_GRPC_ADDRESS"
 )
@@ -0,0 +1,207 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"google.golang.org/grpc"
+
+	storepb "github.com/deislabs/ratify/experimental/proto/v1/referrerstore"
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/config"
+	"github.com/deislabs/ratify/pkg/referrerstore/types"
+)
+
+// NextTokenKey is the key of the continuation token in the configuration of a ListReferrers request,
+// the plugin removes it before reading the store configuration
+const NextTokenKey = "nextToken"
+
+// GRPCStore is a store plugin running as a long-lived gRPC server, in-process or as a sidecar,
+// so reading from the store does not fork a plugin process
+type GRPCStore struct {
+	name      string
+	version   string
+	address   string
+	rawConfig config.StorePluginConfig
+	conn      *grpc.ClientConn
+	client    storepb.ReferrerStorePluginClient
+}
+
+// NewGRPCStore creates a store calling the plugin served at address, in the highest version supported by the plugin
+// that is compatible with version
+func NewGRPCStore(version string, storeConfig config.StorePluginConfig, address string) (*GRPCStore, error) {
+	storeName, ok := storeConfig[types.Name]
+	if !ok {
+		return nil, fmt.Errorf("failed to find store name in the stores config with key %s", "name")
+	}
+	conn, err := grpcplugin.Dial(address)
+	if err != nil {
+		return nil, err
+	}
+	version, _, err = grpcplugin.NegotiateVersion(context.Background(), conn, address, version)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("store plugin %s: %w", storeName, err)
+	}
+	return &GRPCStore{
+		name:      fmt.Sprintf("%s", storeName),
+		version:   version,
+		address:   address,
+		rawConfig: storeConfig,
+		conn:      conn,
+		client:    storepb.NewReferrerStorePluginClient(conn),
+	}, nil
+}
+
+func (sp *GRPCStore) Name() string {
+	return sp.name
+}
+
+func (sp *GRPCStore) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
+	storeConfig := config.StorePluginConfig{}
+	for key, value := range sp.rawConfig {
+		storeConfig[key] = value
+	}
+	if nextToken != "" {
+		storeConfig[NextTokenKey] = nextToken
+	}
+	configuration, err := grpcplugin.ToStruct(storeConfig)
+	if err != nil {
+		return referrerstore.ListReferrersResult{}, sp.wrapError(err)
+	}
+
+	stream, err := sp.client.ListReferrers(grpcplugin.WithVersion(ctx, sp.version), &storepb.ListReferrersRequest{
+		Subject:       grpcplugin.ToProtoDescriptor(subjectReference.Original, oci.Descriptor{Digest: subjectReference.Digest}),
+		ArtifactTypes: artifactTypes,
+		Configuration: configuration,
+	})
+	if err != nil {
+		return referrerstore.ListReferrersResult{}, sp.wrapError(err)
+	}
+
+	result := referrerstore.ListReferrersResult{}
+	for {
+		response, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return referrerstore.ListReferrersResult{}, sp.wrapError(err)
+		}
+		for _, artifact := range response.GetArtifacts() {
+			referrer, err := grpcplugin.FromProtoReferrer(artifact)
+			if err != nil {
+				return referrerstore.ListReferrersResult{}, sp.wrapError(err)
+			}
+			result.Referrers = append(result.Referrers, referrer)
+		}
+		result.NextToken = response.GetNextToken()
+	}
+}
+
+func (sp *GRPCStore) GetBlobContent(ctx context.Context, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+	configuration, err := grpcplugin.ToStruct(sp.rawConfig)
+	if err != nil {
+		return nil, sp.wrapError(err)
+	}
+	response, err := sp.client.GetBlobContent(grpcplugin.WithVersion(ctx, sp.version), &storepb.GetBlobContentRequest{
+		Artifact:      grpcplugin.ToProtoDescriptor(subjectReference.Original, oci.Descriptor{Digest: digest}),
+		Configuration: configuration,
+	})
+	if err != nil {
+		return nil, sp.wrapError(err)
+	}
+	if len(response.GetContent()) == 0 {
+		return nil, sp.wrapError(fmt.Errorf("no content returned for blob %s", digest))
+	}
+	return response.GetContent()[0], nil
+}
+
+// GetBlobReader returns a reader of the blob content sent by the plugin in a single message
+func (sp *GRPCStore) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	content, err := sp.GetBlobContent(ctx, subjectReference, digest)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (sp *GRPCStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
+	configuration, err := grpcplugin.ToStruct(sp.rawConfig)
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, sp.wrapError(err)
+	}
+	desc := referenceDesc.Descriptor
+	desc.ArtifactType = referenceDesc.ArtifactType
+	response, err := sp.client.GetReferenceManifest(grpcplugin.WithVersion(ctx, sp.version), &storepb.GetManifestRequest{
+		SubjectPath:   subjectReference.Original,
+		Referrer:      grpcplugin.ToProtoDescriptor(subjectReference.Original, desc),
+		Configuration: configuration,
+	})
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, sp.wrapError(err)
+	}
+	manifest, err := grpcplugin.FromProtoManifest(response.GetManifest())
+	if err != nil {
+		return ocispecs.ReferenceManifest{}, sp.wrapError(err)
+	}
+	return manifest, nil
+}
+
+func (sp *GRPCStore) GetSubjectDescriptor(ctx context.Context, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+	configuration, err := grpcplugin.ToStruct(sp.rawConfig)
+	if err != nil {
+		return nil, sp.wrapError(err)
+	}
+	response, err := sp.client.GetSubjectDescriptor(grpcplugin.WithVersion(ctx, sp.version), &storepb.GetSubjectDescriptorRequest{
+		Path:          subjectReference.Original,
+		Configuration: configuration,
+	})
+	if err != nil {
+		return nil, sp.wrapError(err)
+	}
+	desc, err := grpcplugin.FromProtoDescriptor(response.GetSubject())
+	if err != nil {
+		return nil, sp.wrapError(err)
+	}
+	return &ocispecs.SubjectDescriptor{Descriptor: desc}, nil
+}
+
+func (sp *GRPCStore) GetConfig() *config.StoreConfig {
+	return &config.StoreConfig{
+		Version: sp.version,
+		Store:   sp.rawConfig,
+	}
+}
+
+// Close closes the connection to the plugin
+func (sp *GRPCStore) Close() error {
+	return sp.conn.Close()
+}
+
+func (sp *GRPCStore) wrapError(err error) error {
+	return fmt.Errorf("store plugin %s at %s failed: %w", sp.name, sp.address, err)
+}
@@ -16,10 +16,11 @@ limitations under the License.
 package plugin
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 
 	"github.com/deislabs/ratify/pkg/common"
@@ -29,6 +30,7 @@ import (
 	"github.com/deislabs/ratify/pkg/referrerstore/config"
 	"github.com/deislabs/ratify/pkg/referrerstore/types"
 	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
 )
 
 // StorePlugin describes a store that is implemented by invoking the plugins
@@ -46,13 +48,44 @@ func NewStore(version string, storeConfig config.StorePluginConfig, pluginPaths
 		return nil, fmt.Errorf("failed to find store name in the stores config with key %s", "name")
 	}
 
-	return &StorePlugin{
+	executor, err := pluginCommon.NewExecutor(storeConfig[types.Persistent], storeConfig[types.MaxProcesses], nil)
+	if err != nil {
+		return nil, fmt.Errorf("store %s: %w", storeName, err)
+	}
+
+	sp := &StorePlugin{
 		name:      fmt.Sprintf("%s", storeName),
 		version:   version,
 		path:      pluginPaths,
 		rawConfig: storeConfig,
-		executor:  &pluginCommon.DefaultExecutor{Stderr: os.Stderr},
-	}, nil
+		executor:  executor,
+	}
+	if err := sp.negotiateVersion(context.Background()); err != nil {
+		return nil, fmt.Errorf("store %s: %w", storeName, err)
+	}
+	return sp, nil
+}
+
+// negotiateVersion sets the version of the commands sent to the plugin to the highest version it supports that is
+// compatible with the configured version
+func (sp *StorePlugin) negotiateVersion(ctx context.Context) error {
+	pluginPath, err := sp.executor.FindInPaths(sp.name, sp.path)
+	if err != nil {
+		// the plugin may be installed once the store is created, its version is then only checked by the plugin
+		logrus.Debugf("cannot negotiate the version of store plugin %s: %v", sp.name, err)
+		return nil
+	}
+
+	pluginArgs := ReferrerStorePluginArgs{
+		Command: pluginCommon.VersionCommand,
+		Version: sp.version,
+	}
+	version, _, err := pluginCommon.NegotiateVersion(ctx, sp.executor, pluginPath, pluginArgs.AsEnviron(), sp.version)
+	if err != nil {
+		return err
+	}
+	sp.version = version
+	return nil
 }
 
 func (sp *StorePlugin) ListReferrers(ctx context.Context, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (referrerstore.ListReferrersResult, error) {
@@ -126,6 +159,16 @@ func (sp *StorePlugin) GetBlobContent(ctx context.Context, subjectReference comm
 	return stdoutBytes, nil
 }
 
+// GetBlobReader returns a reader of the blob content written by the plugin to its standard output.
+// The plugin protocol returns the whole blob at once, so the content is held in memory.
+func (sp *StorePlugin) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	content, err := sp.GetBlobContent(ctx, subjectReference, digest)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
 func (sp *StorePlugin) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
 	pluginPath, err := sp.executor.FindInPaths(sp.name, sp.path)
 	if err != nil {
@@ -0,0 +1,173 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skel
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	storepb "github.com/deislabs/ratify/experimental/proto/v1/referrerstore"
+	"github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	sp "github.com/deislabs/ratify/pkg/referrerstore/plugin"
+	"github.com/deislabs/ratify/pkg/utils"
+)
+
+// ServeGRPC serves the store functions over gRPC at address until the server fails, so the plugin runs as a
+// long-lived process rather than being executed for each command. Requests sent in a version that is not one of
+// supportedVersions are rejected.
+func ServeGRPC(name, version string, listReferrers ListReferrers, getBlobContent GetBlobContent, getRefManifest GetReferenceManifest, getSubDesc GetSubjectDescriptor, supportedVersions []string, address string, opts ...plugin.MainOption) error {
+	server, err := newGRPCServer(name, version, listReferrers, getBlobContent, getRefManifest, getSubDesc, supportedVersions, opts...)
+	if err != nil {
+		return err
+	}
+	listener, err := grpcplugin.Listen(address)
+	if err != nil {
+		return err
+	}
+	return server.Serve(listener)
+}
+
+func newGRPCServer(name, version string, listReferrers ListReferrers, getBlobContent GetBlobContent, getRefManifest GetReferenceManifest, getSubDesc GetSubjectDescriptor, supportedVersions []string, opts ...plugin.MainOption) (*grpc.Server, error) {
+	info := plugin.NewPluginInfo(name, version, supportedVersions, plugin.NewMainOptions(opts...), plugin.FeatureGRPC)
+	server, err := grpcplugin.NewPluginServer(info)
+	if err != nil {
+		return nil, err
+	}
+	storepb.RegisterReferrerStorePluginServer(server, &grpcStore{
+		name:           name,
+		version:        version,
+		listReferrers:  listReferrers,
+		getBlobContent: getBlobContent,
+		getRefManifest: getRefManifest,
+		getSubDesc:     getSubDesc,
+	})
+	return server, nil
+}
+
+type grpcStore struct {
+	storepb.UnimplementedReferrerStorePluginServer
+	name           string
+	version        string
+	listReferrers  ListReferrers
+	getBlobContent GetBlobContent
+	getRefManifest GetReferenceManifest
+	getSubDesc     GetSubjectDescriptor
+}
+
+// getCmdArgs returns the arguments a plugin binary is invoked with for a request, along with the
+// continuation token removed from the configuration before it is passed as the store configuration
+func (s *grpcStore) getCmdArgs(subject string, configuration *structpb.Struct) (*CmdArgs, string, error) {
+	values := map[string]interface{}{}
+	if err := grpcplugin.FromStruct(configuration, &values); err != nil {
+		return nil, "", status.Errorf(codes.InvalidArgument, "invalid configuration: %v", err)
+	}
+	nextToken, _ := values[sp.NextTokenKey].(string)
+	delete(values, sp.NextTokenKey)
+	stdinData, err := json.Marshal(values)
+	if err != nil {
+		return nil, "", status.Errorf(codes.InvalidArgument, "invalid configuration: %v", err)
+	}
+	if err := validateConfig(stdinData); err != nil {
+		return nil, "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	subjectRef, err := utils.ParseSubjectReference(subject)
+	if err != nil {
+		return nil, "", status.Errorf(codes.InvalidArgument, "cannot parse subject reference %s: %v", subject, err)
+	}
+	return &CmdArgs{
+		Version:    s.version,
+		Subject:    subject,
+		StdinData:  stdinData,
+		subjectRef: subjectRef,
+	}, nextToken, nil
+}
+
+func (s *grpcStore) ListReferrers(request *storepb.ListReferrersRequest, stream storepb.ReferrerStorePlugin_ListReferrersServer) error {
+	cmdArgs, nextToken, err := s.getCmdArgs(request.GetSubject().GetRawPath(), request.GetConfiguration())
+	if err != nil {
+		return err
+	}
+	result, err := s.listReferrers(cmdArgs, cmdArgs.subjectRef, request.GetArtifactTypes(), nextToken, nil)
+	if err != nil {
+		return status.Errorf(codes.Internal, "plugin %s failed: %v", s.name, err)
+	}
+	if result == nil {
+		result = &referrerstore.ListReferrersResult{}
+	}
+
+	response := &storepb.ListReferrersResponse{
+		Subject:   request.GetSubject(),
+		NextToken: result.NextToken,
+	}
+	for _, referrer := range result.Referrers {
+		response.Artifacts = append(response.Artifacts, grpcplugin.ToProtoReferrer(cmdArgs.Subject, referrer))
+	}
+	return stream.Send(response)
+}
+
+func (s *grpcStore) GetBlobContent(ctx context.Context, request *storepb.GetBlobContentRequest) (*storepb.GetBlobContentResponse, error) {
+	cmdArgs, _, err := s.getCmdArgs(request.GetArtifact().GetRawPath(), request.GetConfiguration())
+	if err != nil {
+		return nil, err
+	}
+	desc, err := grpcplugin.FromProtoDescriptor(request.GetArtifact())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid artifact: %v", err)
+	}
+	content, err := s.getBlobContent(cmdArgs, cmdArgs.subjectRef, desc.Digest)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "plugin %s failed: %v", s.name, err)
+	}
+	return &storepb.GetBlobContentResponse{Artifact: request.GetArtifact(), Content: [][]byte{content}}, nil
+}
+
+func (s *grpcStore) GetReferenceManifest(ctx context.Context, request *storepb.GetManifestRequest) (*storepb.GetManifestResponse, error) {
+	cmdArgs, _, err := s.getCmdArgs(request.GetSubjectPath(), request.GetConfiguration())
+	if err != nil {
+		return nil, err
+	}
+	desc, err := grpcplugin.FromProtoDescriptor(request.GetReferrer())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid referrer: %v", err)
+	}
+	manifest, err := s.getRefManifest(cmdArgs, cmdArgs.subjectRef, desc.Digest)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "plugin %s failed: %v", s.name, err)
+	}
+	return &storepb.GetManifestResponse{Manifest: grpcplugin.ToProtoManifest(cmdArgs.Subject, manifest)}, nil
+}
+
+func (s *grpcStore) GetSubjectDescriptor(ctx context.Context, request *storepb.GetSubjectDescriptorRequest) (*storepb.GetSubjectDescriptorResponse, error) {
+	cmdArgs, _, err := s.getCmdArgs(request.GetPath(), request.GetConfiguration())
+	if err != nil {
+		return nil, err
+	}
+	desc, err := s.getSubDesc(cmdArgs, cmdArgs.subjectRef)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "plugin %s failed: %v", s.name, err)
+	}
+	if desc == nil {
+		return nil, status.Errorf(codes.NotFound, "plugin %s returned no descriptor for subject %s", s.name, cmdArgs.Subject)
+	}
+	return &storepb.GetSubjectDescriptorResponse{Subject: grpcplugin.ToProtoDescriptor(cmdArgs.Subject, desc.Descriptor)}, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/config"
+	"github.com/deislabs/ratify/pkg/referrerstore/plugin"
+	"github.com/deislabs/ratify/pkg/utils"
+)
+
+const testSubject = "localhost:5000/net-monitor:v1@sha256:a0fc570a245b09ed752c42d600ee3bb5b4f77bbd70d8898780b7ab43454530eb"
+
+func TestServeGRPC_RoundTrip(t *testing.T) {
+	referrer := ocispecs.ReferenceDescriptor{
+		Descriptor:   v1.Descriptor{MediaType: v1.MediaTypeImageManifest, Digest: digest.FromString("signature"), Size: 9},
+		ArtifactType: "test-type",
+	}
+	manifest := ocispecs.ReferenceManifest{
+		MediaType:    v1.MediaTypeImageManifest,
+		ArtifactType: "test-type",
+		Blobs:        []v1.Descriptor{{MediaType: "application/octet-stream", Digest: digest.FromString("blob"), Size: 4}},
+	}
+	subjectDesc := v1.Descriptor{MediaType: v1.MediaTypeImageManifest, Digest: digest.FromString("subject"), Size: 7}
+	checkArgs := func(args *CmdArgs) {
+		if string(args.StdinData) != `{"name":"grpc-test-case","some":"config"}` {
+			t.Fatalf("unexpected store config %s", args.StdinData)
+		}
+	}
+
+	listReferrers := func(args *CmdArgs, subjectReference common.Reference, artifactTypes []string, nextToken string, subjectDesc *ocispecs.SubjectDescriptor) (*referrerstore.ListReferrersResult, error) {
+		checkArgs(args)
+		if nextToken != "page1" || !reflect.DeepEqual(artifactTypes, []string{"test-type"}) {
+			t.Fatalf("unexpected arguments %v and %s", artifactTypes, nextToken)
+		}
+		return &referrerstore.ListReferrersResult{Referrers: []ocispecs.ReferenceDescriptor{referrer}, NextToken: "page2"}, nil
+	}
+	getBlobContent := func(args *CmdArgs, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+		checkArgs(args)
+		if digest != manifest.Blobs[0].Digest {
+			return nil, fmt.Errorf("blob %s not found", digest)
+		}
+		return []byte("blob"), nil
+	}
+	getRefManifest := func(args *CmdArgs, subjectReference common.Reference, digest digest.Digest) (ocispecs.ReferenceManifest, error) {
+		checkArgs(args)
+		if digest != referrer.Digest {
+			return ocispecs.ReferenceManifest{}, fmt.Errorf("manifest %s not found", digest)
+		}
+		return manifest, nil
+	}
+	getSubDesc := func(args *CmdArgs, subjectReference common.Reference) (*ocispecs.SubjectDescriptor, error) {
+		checkArgs(args)
+		return &ocispecs.SubjectDescriptor{Descriptor: subjectDesc}, nil
+	}
+
+	address := grpcplugin.UnixScheme + filepath.Join(t.TempDir(), "store.sock")
+	listener, err := grpcplugin.Listen(address)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server, err := newGRPCServer("grpc-test-case", "1.0.0", listReferrers, getBlobContent, getRefManifest, getSubDesc, []string{"1.0.0"})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	if _, err := plugin.NewGRPCStore("2.0.0", config.StorePluginConfig{"name": "grpc-test-case"}, address); err == nil {
+		t.Fatalf("expected error for a version the plugin does not support")
+	}
+
+	store, err := plugin.NewGRPCStore("1.0.0", config.StorePluginConfig{"name": "grpc-test-case", "some": "config"}, address)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	subjectReference, err := utils.ParseSubjectReference(testSubject)
+	if err != nil {
+		t.Fatalf("failed to parse subject: %v", err)
+	}
+
+	result, err := store.ListReferrers(ctx, subjectReference, []string{"test-type"}, "page1", nil)
+	if err != nil {
+		t.Fatalf("expected referrers, got %v", err)
+	}
+	if !reflect.DeepEqual(result, referrerstore.ListReferrersResult{Referrers: []ocispecs.ReferenceDescriptor{referrer}, NextToken: "page2"}) {
+		t.Fatalf("unexpected referrers %v", result)
+	}
+
+	actualManifest, err := store.GetReferenceManifest(ctx, subjectReference, referrer)
+	if err != nil || actualManifest.ArtifactType != manifest.ArtifactType || !reflect.DeepEqual(actualManifest.Blobs, manifest.Blobs) {
+		t.Fatalf("expected manifest %v, got %v and error %v", manifest, actualManifest, err)
+	}
+
+	reader, err := store.GetBlobReader(ctx, subjectReference, manifest.Blobs[0].Digest)
+	if err != nil {
+		t.Fatalf("expected blob, got %v", err)
+	}
+	content, _ := io.ReadAll(reader)
+	if string(content) != "blob" {
+		t.Fatalf("expected blob content, got %q", content)
+	}
+	if _, err := store.GetBlobContent(ctx, subjectReference, digest.FromString("missing")); err == nil {
+		t.Fatalf("expected error for a missing blob")
+	}
+
+	desc, err := store.GetSubjectDescriptor(ctx, subjectReference)
+	if err != nil || !reflect.DeepEqual(desc.Descriptor, subjectDesc) {
+		t.Fatalf("expected subject descriptor %v, got %v and error %v", subjectDesc, desc, err)
+	}
+}
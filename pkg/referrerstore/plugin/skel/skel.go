@@ -16,6 +16,7 @@ limitations under the License.
 package skel
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -55,13 +56,41 @@ type CmdArgs struct {
 }
 
 // PluginMain is the core "main" for a plugin which includes error handling.
-func PluginMain(name, version string, listReferrers ListReferrers, getBlobContent GetBlobContent, getRefManifest GetReferenceManifest, getSubDesc GetSubjectDescriptor, supportedVersions []string) {
+// Options such as plugin.WithPersistentMode advertise optional capabilities of the plugin.
+func PluginMain(name, version string, listReferrers ListReferrers, getBlobContent GetBlobContent, getRefManifest GetReferenceManifest, getSubDesc GetSubjectDescriptor, supportedVersions []string, opts ...plugin.MainOption) {
+	if address := os.Getenv(sp.GRPCAddressEnvKey); address != "" {
+		if err := ServeGRPC(name, version, listReferrers, getBlobContent, getRefManifest, getSubDesc, supportedVersions, address, opts...); err != nil {
+			log.Fatalf("failed to serve plugin %s at %s: %v", name, address, err)
+		}
+		return
+	}
+
+	if plugin.NewMainOptions(opts...).Persistent && os.Getenv(plugin.PersistentEnvKey) != "" {
+		stdout := os.Stdout
+		// anything the plugin prints would corrupt the responses written to stdout
+		os.Stdout = os.Stderr
+		err := plugin.ServePersistent(os.Stdin, stdout, os.Stderr, func(request plugin.PersistentRequest, stderr io.Writer) plugin.PersistentResponse {
+			out := &bytes.Buffer{}
+			e := (&pcontext{
+				GetEnviron: request.Getenv,
+				Stdin:      bytes.NewReader(request.Stdin),
+				Stdout:     out,
+				Stderr:     stderr,
+			}).pluginMainCore(name, version, listReferrers, getBlobContent, getRefManifest, getSubDesc, supportedVersions, opts...)
+			return plugin.PersistentResponse{Stdout: out.Bytes(), Error: e}
+		})
+		if err != nil {
+			log.Fatalf("persistent plugin %s failed: %v", name, err)
+		}
+		return
+	}
+
 	if e := (&pcontext{
 		GetEnviron: os.Getenv,
 		Stdin:      os.Stdin,
 		Stdout:     os.Stdout,
 		Stderr:     os.Stderr,
-	}).pluginMainCore(name, version, listReferrers, getBlobContent, getRefManifest, getSubDesc, supportedVersions); e != nil {
+	}).pluginMainCore(name, version, listReferrers, getBlobContent, getRefManifest, getSubDesc, supportedVersions, opts...); e != nil {
 		if err := e.Print(); err != nil {
 			log.Print("Error writing error result to stdout: ", err)
 		}
@@ -69,7 +98,15 @@ func PluginMain(name, version string, listReferrers ListReferrers, getBlobConten
 	}
 }
 
-func (c *pcontext) pluginMainCore(name, version string, listReferrers ListReferrers, getBlobContent GetBlobContent, getRefManifest GetReferenceManifest, getSubDesc GetSubjectDescriptor, supportedVersions []string) *plugin.Error {
+func (c *pcontext) pluginMainCore(name, version string, listReferrers ListReferrers, getBlobContent GetBlobContent, getRefManifest GetReferenceManifest, getSubDesc GetSubjectDescriptor, supportedVersions []string, opts ...plugin.MainOption) *plugin.Error {
+	if c.GetEnviron(sp.CommandEnvKey) == plugin.VersionCommand {
+		info := plugin.NewPluginInfo(name, version, supportedVersions, plugin.NewMainOptions(opts...), plugin.FeatureGRPC)
+		if err := info.Write(c.Stdout); err != nil {
+			return plugin.NewError(types.ErrIOFailure, "failed to write plugin output", err.Error())
+		}
+		return nil
+	}
+
 	cmd, cmdArgs, err := c.getCmdArgsFromEnv()
 	if err != nil {
 		return err
@@ -275,11 +312,9 @@ func (c *pcontext) getCmdArgsFromEnv() (string, *CmdArgs, *plugin.Error) {
 }
 
 func validateVersion(version string, supportedVersions []string) *plugin.Error {
-	for _, v := range supportedVersions {
-		// TODO check for compatibility using semversion
-		if v == version {
-			return nil
-		}
+	// the host negotiates one of the supported versions with the VERSION command
+	if plugin.IsVersionSupported(version, supportedVersions) {
+		return nil
 	}
 
 	return plugin.NewError(types.ErrVersionNotSupported, fmt.Sprintf("plugin doesn't support version %s", version), "")
@@ -17,11 +17,13 @@ package skel
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/deislabs/ratify/pkg/common"
+	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
 	"github.com/deislabs/ratify/pkg/ocispecs"
 	"github.com/deislabs/ratify/pkg/referrerstore"
 	"github.com/deislabs/ratify/pkg/referrerstore/plugin"
@@ -316,3 +318,64 @@ func TestPluginMain_ListReferrers_ErrorCases(t *testing.T) {
 		t.Fatalf("plugin execution expected to fail with error code %d for invalid arg", types.ErrArgsParsingFailure)
 	}
 }
+
+func TestPluginMain_VersionCommand_ReturnsInfo(t *testing.T) {
+	environment := map[string]string{
+		plugin.CommandEnvKey: pluginCommon.VersionCommand,
+		plugin.VersionEnvKey: "1.0.0",
+	}
+	stdout := &bytes.Buffer{}
+	pluginContext := &pcontext{
+		GetEnviron: func(key string) string { return environment[key] },
+		Stdin:      strings.NewReader(""),
+		Stdout:     stdout,
+		Stderr:     &bytes.Buffer{},
+	}
+
+	err := pluginContext.pluginMainCore("skel-test-case", "1.0.0", nil, nil, nil, nil, []string{"1.0.0"}, pluginCommon.WithMediaTypes(v1.MediaTypeImageManifest))
+	if err != nil {
+		t.Fatalf("plugin execution failed %v", err)
+	}
+
+	info := pluginCommon.PluginInfo{}
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		t.Fatalf("failed to parse plugin info %s: %v", stdout.String(), err)
+	}
+	if info.Name != "skel-test-case" || len(info.SupportedVersions) != 1 || info.SupportedVersions[0] != "1.0.0" {
+		t.Fatalf("unexpected plugin info %+v", info)
+	}
+	if len(info.MediaTypes) != 1 || info.MediaTypes[0] != v1.MediaTypeImageManifest {
+		t.Fatalf("expected media type %s, got %v", v1.MediaTypeImageManifest, info.MediaTypes)
+	}
+	if !info.HasFeature(pluginCommon.FeatureGRPC) || info.HasFeature(pluginCommon.FeaturePersistent) {
+		t.Fatalf("unexpected features %v", info.Features)
+	}
+}
+
+func TestPluginMain_VersionNotSupported_ReturnsError(t *testing.T) {
+	environment := map[string]string{
+		plugin.CommandEnvKey: plugin.GetBlobContentCommand,
+		plugin.VersionEnvKey: "2.0.0",
+		plugin.ArgsEnvKey:    "digest=sha256:a0fc570a245b09ed752c42d600ee3bb5b4f77bbd70d8898780b7ab43454530eb",
+		plugin.SubjectEnvKey: "localhost:5000/net-monitor:v1@sha256:a0fc570a245b09ed752c42d600ee3bb5b4f77bbd70d8898780b7ab43454530eb",
+	}
+	for version, expectErr := range map[string]bool{"2.0.0": true, "1.0": false, "1.0.0": false} {
+		environment[plugin.VersionEnvKey] = version
+		pluginContext := &pcontext{
+			GetEnviron: func(key string) string { return environment[key] },
+			Stdin:      strings.NewReader(testStdinData),
+			Stdout:     &bytes.Buffer{},
+			Stderr:     &bytes.Buffer{},
+		}
+		getBlobContent := func(args *CmdArgs, subjectReference common.Reference, digest digest.Digest) ([]byte, error) {
+			return []byte(digest.String()), nil
+		}
+		err := pluginContext.pluginMainCore("skel-test-case", "1.0.0", nil, getBlobContent, nil, nil, []string{"1.0.0"})
+		if expectErr != (err != nil) {
+			t.Fatalf("expected error %v for version %s, got %v", expectErr, version, err)
+		}
+		if err != nil && err.Code != types.ErrVersionNotSupported {
+			t.Fatalf("expected version not supported error, got %v", err)
+		}
+	}
+}
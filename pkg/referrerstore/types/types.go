@@ -24,10 +24,13 @@ import (
 )
 
 const (
-	SpecVersion string = "0.1.0"
-	Version     string = "version"
-	Name        string = "name"
-	Source      string = "source"
+	SpecVersion  string = "0.1.0"
+	Version      string = "version"
+	Name         string = "name"
+	Source       string = "source"
+	Address      string = "address"
+	Persistent   string = "persistent"
+	MaxProcesses string = "maxProcesses"
 )
 
 const (
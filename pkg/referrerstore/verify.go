@@ -0,0 +1,50 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referrerstore
+
+import (
+	"fmt"
+	"io"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// NewVerifyReader returns a reader of rc that reads at most the size of desc and fails at EOF if the content read
+// does not match the size and digest of desc
+func NewVerifyReader(rc io.ReadCloser, desc oci.Descriptor) io.ReadCloser {
+	return &verifyReader{rc: rc, desc: desc, verifier: content.NewVerifyReader(rc, desc)}
+}
+
+type verifyReader struct {
+	rc       io.ReadCloser
+	desc     oci.Descriptor
+	verifier *content.VerifyReader
+}
+
+func (r *verifyReader) Read(p []byte) (int, error) {
+	n, err := r.verifier.Read(p)
+	if err == io.EOF {
+		if verifyErr := r.verifier.Verify(); verifyErr != nil {
+			return n, fmt.Errorf("blob %s: %w", r.desc.Digest, verifyErr)
+		}
+	}
+	return n, err
+}
+
+func (r *verifyReader) Close() error {
+	return r.rc.Close()
+}
@@ -0,0 +1,59 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referrerstore
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+func TestNewVerifyReader(t *testing.T) {
+	blob := "blob content"
+	testCases := []struct {
+		name        string
+		content     string
+		expectedErr error
+	}{
+		{name: "matching content", content: blob},
+		{name: "tampered content", content: "blob c0ntent", expectedErr: content.ErrMismatchedDigest},
+		{name: "trailing content", content: blob + "!", expectedErr: content.ErrTrailingData},
+		{name: "truncated content", content: blob[:4], expectedErr: io.ErrUnexpectedEOF},
+	}
+
+	desc := oci.Descriptor{Digest: digest.FromString(blob), Size: int64(len(blob))}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := NewVerifyReader(io.NopCloser(strings.NewReader(tc.content)), desc)
+			defer reader.Close()
+			read, err := io.ReadAll(reader)
+			if tc.expectedErr == nil {
+				if err != nil || string(read) != blob {
+					t.Fatalf("expected the blob to be read, got %q and error %v", read, err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -84,6 +84,45 @@ func GetCertificatesFromPath(path string) ([]*x509.Certificate, error) {
 	return certs, nil
 }
 
+// GetDirectoriesToWatch returns the directories to watch for changes to the certificates under path.
+// For a file this is its parent directory, for a directory this is every directory in its tree.
+// Symbolic links are resolved so that mounted volumes, which swap a symlinked directory on update, are covered.
+func GetDirectoriesToWatch(path string) ([]string, error) {
+	path = ReplaceHomeShortcut(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		dirs := []string{filepath.Dir(path)}
+		if target, err := filepath.EvalSymlinks(path); err == nil && filepath.Dir(target) != dirs[0] {
+			dirs = append(dirs, filepath.Dir(target))
+		}
+		return dirs, nil
+	}
+
+	dirs := []string{path}
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info == nil {
+			return nil
+		}
+		if file != path && info.IsDir() {
+			dirs = append(dirs, file)
+		}
+		if isSymbolicLink(info) {
+			if target, err := filepath.EvalSymlinks(file); err == nil {
+				if targetInfo, err := os.Stat(target); err == nil && targetInfo.IsDir() {
+					dirs = append(dirs, target)
+				} else {
+					dirs = append(dirs, filepath.Dir(target))
+				}
+			}
+		}
+		return nil
+	})
+	return dirs, err
+}
+
 func isSymbolicLink(info fs.FileInfo) bool {
 	return info.Mode()&os.ModeSymlink != 0
 }
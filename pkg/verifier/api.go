@@ -32,6 +32,17 @@ type VerifierResult struct {
 	Extensions    interface{}      `json:"extensions,omitempty"`
 	NestedResults []VerifierResult `json:"nestedResults,omitempty"`
 	ArtifactType  string           `json:"artifactType,omitempty"`
+	// Error is the error of the plugin that failed to verify the reference
+	Error *PluginError `json:"error,omitempty"`
+}
+
+// PluginError describes the error of a verifier plugin, 0 is the code of errors without a structured error message
+type PluginError struct {
+	Code    uint   `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+	// Stderr is the tail of the stderr of the plugin process
+	Stderr string `json:"stderr,omitempty"`
 }
 
 // ReferenceVerifier is an interface that defines methods to verify a reference for a subject
@@ -23,6 +23,7 @@ import (
 	"strings"
 
 	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
 	"github.com/deislabs/ratify/pkg/featureflag"
 	"github.com/deislabs/ratify/pkg/verifier"
 	"github.com/deislabs/ratify/pkg/verifier/config"
@@ -69,8 +70,13 @@ func CreateVerifierFromConfig(verifierConfig config.VerifierConfig, configVersio
 				return nil, fmt.Errorf("failed to parse plugin source: %w", err)
 			}
 
+			signatureVerifier, err := CreateSourceVerifier(source, configVersion, pluginBinDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create verifier of plugin source: %w", err)
+			}
+
 			targetPath := path.Join(pluginBinDir[0], verifierNameStr)
-			err = pluginCommon.DownloadPlugin(source, targetPath)
+			err = pluginCommon.DownloadPlugin(source, targetPath, signatureVerifier)
 			if err != nil {
 				return nil, fmt.Errorf("failed to download plugin: %w", err)
 			}
@@ -80,6 +86,11 @@ func CreateVerifierFromConfig(verifierConfig config.VerifierConfig, configVersio
 		}
 	}
 
+	// a plugin served over gRPC runs as a long-lived server instead of being executed from the plugin directories
+	if address, ok := verifierConfig[types.Address].(string); ok && grpcplugin.IsAddress(address) {
+		return plugin.NewGRPCVerifier(configVersion, verifierConfig, address)
+	}
+
 	verifierFactory, ok := builtInVerifiers[verifierNameStr]
 	if ok {
 		return verifierFactory.Create(configVersion, verifierConfig)
@@ -88,6 +99,19 @@ func CreateVerifierFromConfig(verifierConfig config.VerifierConfig, configVersio
 	}
 }
 
+// CreateSourceVerifier returns the verifier checking the signatures of the plugin artifact of source, nil if the
+// source does not configure one
+func CreateSourceVerifier(source pluginCommon.PluginSource, configVersion string, pluginBinDir []string) (verifier.ReferenceVerifier, error) {
+	if source.Verifier == nil {
+		return nil, nil
+	}
+	// the verifier runs before the plugin is installed, so it cannot be downloaded itself
+	if _, ok := source.Verifier[types.Source]; ok {
+		return nil, fmt.Errorf("the verifier of a plugin source cannot specify a %s", types.Source)
+	}
+	return CreateVerifierFromConfig(config.VerifierConfig(source.Verifier), configVersion, pluginBinDir)
+}
+
 // TODO pointer to avoid copy
 // returns an array of verifiers from VerifiersConfig
 func CreateVerifiersFromConfig(verifiersConfig config.VerifiersConfig, defaultPluginPath string) ([]verifier.ReferenceVerifier, error) {
@@ -126,6 +150,6 @@ func CreateVerifiersFromConfig(verifiersConfig config.VerifiersConfig, defaultPl
 
 func validateVerifiersConfig(verifiersConfig *config.VerifiersConfig) error {
 	// TODO check for existence of plugin dirs
-	// TODO check if version is supported
-	return nil
+	// the version is negotiated with each plugin when it is created
+	return pluginCommon.ValidateVersion(verifiersConfig.Version)
 }
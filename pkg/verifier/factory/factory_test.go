@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/deislabs/ratify/pkg/common"
+	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
 	"github.com/deislabs/ratify/pkg/ocispecs"
 	"github.com/deislabs/ratify/pkg/referrerstore"
 
@@ -111,3 +112,28 @@ func TestCreateVerifiersFromConfig_PluginVerifiers_ReturnsExpected(t *testing.T)
 		t.Fatalf("type assertion failed expected a plugin in verifier")
 	}
 }
+
+func TestCreateSourceVerifier(t *testing.T) {
+	builtInVerifiers = map[string]VerifierFactory{
+		"test-verifier": &TestVerifierFactory{},
+	}
+
+	signatureVerifier, err := CreateSourceVerifier(pluginCommon.PluginSource{Artifact: "localhost/plugin:v1"}, "", nil)
+	if err != nil || signatureVerifier != nil {
+		t.Fatalf("expected no verifier without a verifier config, got %v and error %v", signatureVerifier, err)
+	}
+
+	source := pluginCommon.PluginSource{Artifact: "localhost/plugin:v1", Verifier: map[string]interface{}{"name": "test-verifier"}}
+	signatureVerifier, err = CreateSourceVerifier(source, "", nil)
+	if err != nil {
+		t.Fatalf("create source verifier failed with err %v", err)
+	}
+	if signatureVerifier.Name() != "test-verifier" {
+		t.Fatalf("expected to create test verifier")
+	}
+
+	source.Verifier["source"] = map[string]interface{}{"artifact": "localhost/verifier:v1"}
+	if _, err := CreateSourceVerifier(source, "", nil); err == nil {
+		t.Fatalf("expected error for a verifier downloaded from a source")
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"strings"
 
 	ratifyconfig "github.com/deislabs/ratify/config"
+	"github.com/deislabs/ratify/pkg/certificatecache"
 	"github.com/deislabs/ratify/pkg/common"
 	"github.com/deislabs/ratify/pkg/homedir"
 
@@ -152,7 +153,10 @@ func getVerifierService(conf *NotaryV2VerifierConfig) (notation.Verifier, error)
 	store := &trustStore{
 		certPaths:  conf.VerificationCerts,
 		certStores: conf.VerificationCertStores,
+		certCache:  certificatecache.Default(),
 	}
+	// trust store paths are loaded when the verifier is created so certificate expiry is reported at startup
+	store.certCache.LoadPaths(conf.VerificationCerts)
 
 	return notaryVerifier.New(&conf.TrustPolicyDoc, store, nil)
 }
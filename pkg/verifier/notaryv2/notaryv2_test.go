@@ -1,10 +1,12 @@
 package notaryv2
 
 import (
+	"bytes"
 	"context"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"fmt"
+	"io"
 	paths "path/filepath"
 	"reflect"
 	"testing"
@@ -115,6 +117,14 @@ func (s mockStore) GetBlobContent(ctx context.Context, subjectReference common.R
 	return s.refBlob, nil
 }
 
+func (s mockStore) GetBlobReader(ctx context.Context, subjectReference common.Reference, digest digest.Digest) (io.ReadCloser, error) {
+	content, err := s.GetBlobContent(ctx, subjectReference, digest)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
 func (s mockStore) GetReferenceManifest(ctx context.Context, subjectReference common.Reference, referenceDesc ocispecs.ReferenceDescriptor) (ocispecs.ReferenceManifest, error) {
 	if len(s.manifest.Blobs) == 0 {
 		return s.manifest, fmt.Errorf("invalid reference")
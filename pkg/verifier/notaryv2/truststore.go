@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/deislabs/ratify/pkg/certificatecache"
 	"github.com/deislabs/ratify/pkg/controllers"
 	"github.com/deislabs/ratify/pkg/utils"
 	"github.com/notaryproject/notation-go/verifier/truststore"
@@ -30,14 +31,16 @@ import (
 type trustStore struct {
 	certPaths  []string
 	certStores map[string][]string
+	// certCache serves parsed certificates, certificates are read from disk on each call if nil
+	certCache *certificatecache.CertificateCache
 }
 
 // trustStore implements GetCertificates API of X509TrustStore interface: [https://pkg.go.dev/github.com/notaryproject/notation-go@v1.0.0-rc.3/verifier/truststore#X509TrustStore]
 // Note: this api gets invoked when Ratify calls verify API, so the certificates
-// will be loaded for each signature verification.
+// are served from the certificate cache and only reloaded when the trust store paths or certificate stores change.
 // And this API must follow the Notation Trust Store spec: https://github.com/notaryproject/notaryproject/blob/main/specs/trust-store-trust-policy.md#trust-store
 func (s trustStore) GetCertificates(ctx context.Context, storeType truststore.Type, namedStore string) ([]*x509.Certificate, error) {
-	certs, err := s.getCertificatesInternal(ctx, storeType, namedStore, controllers.GetCertificatesMap())
+	certs, err := s.getCertificatesInternal(ctx, storeType, namedStore, s.getStoreCertificates())
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +64,7 @@ func (s trustStore) getCertificatesInternal(ctx context.Context, storeType trust
 		}
 	} else {
 		for _, path := range s.certPaths {
-			bundledCerts, err := utils.GetCertificatesFromPath(path)
+			bundledCerts, err := s.getCertificatesFromPath(path)
 			if err != nil {
 				return nil, err
 			}
@@ -72,6 +75,20 @@ func (s trustStore) getCertificatesInternal(ctx context.Context, storeType trust
 	return certs, nil
 }
 
+func (s trustStore) getStoreCertificates() map[string][]*x509.Certificate {
+	if s.certCache == nil {
+		return controllers.GetCertificatesMap()
+	}
+	return s.certCache.GetStoreCertificates()
+}
+
+func (s trustStore) getCertificatesFromPath(path string) ([]*x509.Certificate, error) {
+	if s.certCache == nil {
+		return utils.GetCertificatesFromPath(path)
+	}
+	return s.certCache.GetCertificatesFromPath(path)
+}
+
 // filterValidCerts keeps CA certificates and self-signed certs.
 func (s trustStore) filterValidCerts(certs []*x509.Certificate) ([]*x509.Certificate, error) {
 	filteredCerts := make([]*x509.Certificate, 0)
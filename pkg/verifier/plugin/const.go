@@ -20,4 +20,8 @@ const (
 	CommandEnvKey = "RATIFY_VERIFIER_COMMAND"
 	SubjectEnvKey = "RATIFY_VERIFIER_SUBJECT"
 	VersionEnvKey = "RATIFY_VERIFIER_VERSION"
+	// ContentSocketEnvKey is the path of the Unix socket the host serves the content of the referrer store on
+	ContentSocketEnvKey = "RATIFY_VERIFIER_CONTENT_SOCKET"
+	// GRPCAddressEnvKey is the address a plugin built with the skeleton is served at over gRPC instead of verifying a single reference
+	GRPCAddressEnvKey = "RATIFY_VERIFIER_GRPC_ADDRESS"
 )
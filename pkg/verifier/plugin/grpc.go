@@ -0,0 +1,137 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	verifierpb "github.com/deislabs/ratify/experimental/proto/v1/verifier"
+	"github.com/deislabs/ratify/pkg/common"
+	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
+	"github.com/deislabs/ratify/pkg/metrics"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/verifier"
+	"github.com/deislabs/ratify/pkg/verifier/config"
+	"github.com/deislabs/ratify/pkg/verifier/types"
+)
+
+// GRPCVerifier is a verifier plugin running as a long-lived gRPC server, in-process or as a sidecar,
+// so verifying a reference does not fork a plugin process
+type GRPCVerifier struct {
+	*VerifierPlugin
+	address string
+	conn    *grpc.ClientConn
+	client  verifierpb.VerifierPluginClient
+}
+
+// NewGRPCVerifier creates a verifier calling the plugin served at address, in the highest version supported by the
+// plugin that is compatible with version
+func NewGRPCVerifier(version string, verifierConfig config.VerifierConfig, address string) (*GRPCVerifier, error) {
+	base, err := newVerifierPlugin(version, verifierConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpcplugin.Dial(address)
+	if err != nil {
+		return nil, err
+	}
+	negotiated, info, err := grpcplugin.NegotiateVersion(context.Background(), conn, address, version)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("verifier plugin %s: %w", base.name, err)
+	}
+	base.version = negotiated
+	base.warnUndeclaredArtifactTypes(info)
+	return &GRPCVerifier{
+		VerifierPlugin: base,
+		address:        address,
+		conn:           conn,
+		client:         verifierpb.NewVerifierPluginClient(conn),
+	}, nil
+}
+
+// Verify sends the reference to the plugin along with the configuration a plugin binary reads from stdin
+func (vp *GRPCVerifier) Verify(ctx context.Context,
+	subjectReference common.Reference,
+	referenceDescriptor ocispecs.ReferenceDescriptor,
+	store referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
+	configuration, err := grpcplugin.ToStruct(config.PluginInputConfig{
+		Config:       vp.rawConfig,
+		StoreConfig:  *store.GetConfig(),
+		ReferencDesc: referenceDescriptor,
+	})
+	if err != nil {
+		return verifier.VerifierResult{IsSuccess: false}, fmt.Errorf("failed to encode configuration of verifier plugin %s: %w", vp.name, err)
+	}
+
+	response, err := vp.client.VerifyReference(grpcplugin.WithVersion(ctx, vp.version), &verifierpb.VerifyReferenceRequest{
+		Subject:       grpcplugin.ToProtoDescriptor(subjectReference.Original, oci.Descriptor{Digest: subjectReference.Digest}),
+		Reference:     grpcplugin.ToProtoReferrer(subjectReference.Original, referenceDescriptor),
+		Configuration: configuration,
+	})
+	if err != nil {
+		pluginErr := toPluginError(err)
+		metrics.ReportPluginError(ctx, vp.name, pluginErr.Code)
+		return verifier.VerifierResult{IsSuccess: false, Name: vp.name, Error: toVerifierError(pluginErr)}, fmt.Errorf("verifier plugin %s at %s failed: %w", vp.name, vp.address, pluginErr)
+	}
+
+	result := verifier.VerifierResult{
+		IsSuccess: response.GetValid(),
+		Name:      response.GetVerifierName(),
+		Message:   strings.Join(response.GetReasons(), "; "),
+	}
+	if len(response.GetData()) > 0 {
+		if result.Extensions, err = grpcplugin.FromExtensionData(response.GetData()[0].GetValues()); err != nil {
+			return verifier.VerifierResult{IsSuccess: false}, fmt.Errorf("verifier plugin %s: %w", vp.name, err)
+		}
+	}
+	return result, nil
+}
+
+// toPluginError returns the error of a failed call as the error of an executed plugin. The code is sent by plugins
+// built with the skeleton, and mapped from the gRPC status for other plugins.
+func toPluginError(err error) *pluginCommon.Error {
+	if pluginErr, ok := grpcplugin.PluginError(err); ok {
+		return pluginErr
+	}
+	st := status.Convert(err)
+	code := types.ErrUnknown
+	switch st.Code() {
+	case codes.InvalidArgument:
+		code = types.ErrArgsParsingFailure
+	case codes.Unimplemented:
+		code = types.ErrUnknownCommand
+	case codes.Unavailable, codes.DeadlineExceeded:
+		code = types.ErrIOFailure
+	case codes.Unknown, codes.Internal:
+		code = types.ErrPluginCmdFailure
+	}
+	return pluginCommon.NewError(code, st.Message(), fmt.Sprintf("gRPC status %s", st.Code()))
+}
+
+// Close closes the connection to the plugin
+func (vp *GRPCVerifier) Close() error {
+	return vp.conn.Close()
+}
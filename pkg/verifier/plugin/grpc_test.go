@@ -0,0 +1,64 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
+	"github.com/deislabs/ratify/pkg/verifier/types"
+)
+
+func TestToPluginError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected pluginCommon.Error
+	}{
+		{
+			name:     "skeleton error",
+			err:      grpcplugin.StatusError(codes.Internal, pluginCommon.NewError(types.ErrPluginCmdFailure, "plugin failed", "details")),
+			expected: pluginCommon.Error{Code: types.ErrPluginCmdFailure, Msg: "plugin failed", Details: "details"},
+		},
+		{
+			name:     "invalid argument",
+			err:      status.Error(codes.InvalidArgument, "bad subject"),
+			expected: pluginCommon.Error{Code: types.ErrArgsParsingFailure, Msg: "bad subject", Details: "gRPC status InvalidArgument"},
+		},
+		{
+			name:     "unavailable",
+			err:      status.Error(codes.Unavailable, "connection refused"),
+			expected: pluginCommon.Error{Code: types.ErrIOFailure, Msg: "connection refused", Details: "gRPC status Unavailable"},
+		},
+		{
+			name:     "unmapped",
+			err:      status.Error(codes.PermissionDenied, "denied"),
+			expected: pluginCommon.Error{Code: types.ErrUnknown, Msg: "denied", Details: "gRPC status PermissionDenied"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := toPluginError(tc.err); *actual != tc.expected {
+				t.Fatalf("expected error %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -18,18 +18,20 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/deislabs/ratify/pkg/common"
 	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/metrics"
 	"github.com/deislabs/ratify/pkg/ocispecs"
 	"github.com/deislabs/ratify/pkg/referrerstore"
-	rc "github.com/deislabs/ratify/pkg/referrerstore/config"
+	"github.com/deislabs/ratify/pkg/referrerstore/orchestrator"
 	"github.com/deislabs/ratify/pkg/verifier"
 	"github.com/deislabs/ratify/pkg/verifier/config"
 	"github.com/deislabs/ratify/pkg/verifier/types"
+	"github.com/sirupsen/logrus"
 )
 
 // VerifierPlugin describes a verifier that is implemented by invoking the plugins
@@ -45,6 +47,17 @@ type VerifierPlugin struct {
 
 // NewVerifier creates a new verifier from the given configuration
 func NewVerifier(version string, verifierConfig config.VerifierConfig, pluginPaths []string) (verifier.ReferenceVerifier, error) {
+	vp, err := newVerifierPlugin(version, verifierConfig, pluginPaths)
+	if err != nil {
+		return nil, err
+	}
+	if err := vp.negotiateVersion(context.Background()); err != nil {
+		return nil, fmt.Errorf("verifier %s: %w", vp.name, err)
+	}
+	return vp, nil
+}
+
+func newVerifierPlugin(version string, verifierConfig config.VerifierConfig, pluginPaths []string) (*VerifierPlugin, error) {
 	verifierName, ok := verifierConfig[types.Name]
 	if !ok {
 		return nil, fmt.Errorf("failed to find verifier name in the verifier config with key %s", "name")
@@ -64,6 +77,15 @@ func NewVerifier(version string, verifierConfig config.VerifierConfig, pluginPat
 		artifactTypes = append(artifactTypes, "*")
 	}
 
+	policy, err := pluginCommon.ParseExecutionPolicy(verifierConfig[types.ExecutionPolicy])
+	if err != nil {
+		return nil, fmt.Errorf("verifier %s: %w", verifierName, err)
+	}
+	executor, err := pluginCommon.NewExecutor(verifierConfig[types.Persistent], verifierConfig[types.MaxProcesses], policy)
+	if err != nil {
+		return nil, fmt.Errorf("verifier %s: %w", verifierName, err)
+	}
+
 	return &VerifierPlugin{
 		name:             fmt.Sprintf("%s", verifierName),
 		version:          version,
@@ -71,10 +93,54 @@ func NewVerifier(version string, verifierConfig config.VerifierConfig, pluginPat
 		rawConfig:        verifierConfig,
 		artifactTypes:    artifactTypes,
 		nestedReferences: nestedReferences,
-		executor:         &pluginCommon.DefaultExecutor{Stderr: os.Stderr},
+		executor:         executor,
 	}, nil
 }
 
+// negotiateVersion sets the version of the commands sent to the plugin to the highest version it supports that is
+// compatible with the configured version, and warns about artifact types the plugin does not declare
+func (vp *VerifierPlugin) negotiateVersion(ctx context.Context) error {
+	pluginPath, err := vp.executor.FindInPaths(vp.name, vp.path)
+	if err != nil {
+		// the plugin may be installed once the verifier is created, its version is then only checked by the plugin
+		logrus.Debugf("cannot negotiate the version of verifier plugin %s: %v", vp.name, err)
+		return nil
+	}
+
+	pluginArgs := VerifierPluginArgs{
+		Command: pluginCommon.VersionCommand,
+		Version: vp.version,
+	}
+	version, info, err := pluginCommon.NegotiateVersion(ctx, vp.executor, pluginPath, pluginArgs.AsEnviron(), vp.version)
+	if err != nil {
+		return err
+	}
+	vp.version = version
+	vp.warnUndeclaredArtifactTypes(info)
+	return nil
+}
+
+// warnUndeclaredArtifactTypes warns about the configured artifact types the plugin does not declare in its info
+func (vp *VerifierPlugin) warnUndeclaredArtifactTypes(info *pluginCommon.PluginInfo) {
+	if info == nil || len(info.ArtifactTypes) == 0 {
+		return
+	}
+	for _, at := range vp.artifactTypes {
+		if at != "*" && !contains(info.ArtifactTypes, at) {
+			logrus.Warnf("verifier plugin %s is configured for artifact type %s, which it does not declare in %v", vp.name, at, info.ArtifactTypes)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func (vp *VerifierPlugin) CanVerify(ctx context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
 	for _, at := range vp.artifactTypes {
 		if at == "*" || at == referenceDescriptor.ArtifactType {
@@ -92,10 +158,9 @@ func (vp *VerifierPlugin) Verify(ctx context.Context,
 	subjectReference common.Reference,
 	referenceDescriptor ocispecs.ReferenceDescriptor,
 	store referrerstore.ReferrerStore) (verifier.VerifierResult, error) {
-	referrerStoreConfig := store.GetConfig()
-	vr, err := vp.verifyReference(ctx, subjectReference, referenceDescriptor, referrerStoreConfig)
+	vr, err := vp.verifyReference(ctx, subjectReference, referenceDescriptor, store)
 	if err != nil {
-		return verifier.VerifierResult{IsSuccess: false}, err
+		return verifier.VerifierResult{IsSuccess: false, Name: vp.name, Error: toVerifierError(err)}, err
 	}
 
 	return *vr, nil
@@ -105,7 +170,7 @@ func (vp *VerifierPlugin) verifyReference(
 	ctx context.Context,
 	subjectReference common.Reference,
 	referenceDescriptor ocispecs.ReferenceDescriptor,
-	referrerStoreConfig *rc.StoreConfig) (*verifier.VerifierResult, error) {
+	store referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
 	pluginPath, err := vp.executor.FindInPaths(vp.name, vp.path)
 	if err != nil {
 		return nil, err
@@ -117,9 +182,18 @@ func (vp *VerifierPlugin) verifyReference(
 		SubjectReference: subjectReference.String(),
 	}
 
+	// the plugin reads content through the store of the host, sharing its credentials and caches.
+	// The content server of the store is shared by all verifications rather than started for each of them.
+	contentServer, err := orchestrator.ServeShared(store)
+	if err != nil {
+		logrus.Warnf("failed to serve content to verifier plugin %s, the plugin creates its own store: %v", vp.name, err)
+	} else {
+		pluginArgs.ContentSocket = contentServer.SocketPath()
+	}
+
 	inputConfig := config.PluginInputConfig{
 		Config:       vp.rawConfig,
-		StoreConfig:  *referrerStoreConfig,
+		StoreConfig:  *store.GetConfig(),
 		ReferencDesc: referenceDescriptor,
 	}
 
@@ -130,6 +204,12 @@ func (vp *VerifierPlugin) verifyReference(
 
 	stdoutBytes, err := vp.executor.ExecutePlugin(ctx, pluginPath, nil, verifierConfigBytes, pluginArgs.AsEnviron())
 	if err != nil {
+		var code uint
+		var pluginErr *pluginCommon.Error
+		if errors.As(err, &pluginErr) {
+			code = pluginErr.Code
+		}
+		metrics.ReportPluginError(ctx, vp.name, code)
 		return nil, err
 	}
 
@@ -141,6 +221,21 @@ func (vp *VerifierPlugin) verifyReference(
 	return result, nil
 }
 
+// toVerifierError returns the error of a failed plugin execution as reported in the verifier result, nil for other
+// errors
+func toVerifierError(err error) *verifier.PluginError {
+	var pluginErr *pluginCommon.Error
+	if !errors.As(err, &pluginErr) {
+		return nil
+	}
+	return &verifier.PluginError{
+		Code:    pluginErr.Code,
+		Message: pluginErr.Msg,
+		Details: pluginErr.Details,
+		Stderr:  pluginErr.Stderr,
+	}
+}
+
 func (vp *VerifierPlugin) GetNestedReferences() []string {
 	return vp.nestedReferences
 }
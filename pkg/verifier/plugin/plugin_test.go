@@ -17,12 +17,18 @@ package plugin
 
 import (
 	"context"
+	"errors"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/deislabs/ratify/pkg/common"
+	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
 	"github.com/deislabs/ratify/pkg/ocispecs"
 	sm "github.com/deislabs/ratify/pkg/referrerstore/mocks"
+	"github.com/deislabs/ratify/pkg/referrerstore/orchestrator"
+	"github.com/deislabs/ratify/pkg/verifier"
 )
 
 const (
@@ -85,6 +91,7 @@ func TestVerify_IsSuccessTrue_Expected(t *testing.T) {
 			commandCheck := false
 			versionCheck := false
 			subjectCheck := false
+			contentSocketCheck := false
 			for _, env := range environ {
 				if strings.Contains(env, CommandEnvKey) && strings.Contains(env, VerifyCommand) {
 					commandCheck = true
@@ -92,6 +99,10 @@ func TestVerify_IsSuccessTrue_Expected(t *testing.T) {
 					versionCheck = true
 				} else if strings.Contains(env, SubjectEnvKey) && strings.Contains(env, "localhost") {
 					subjectCheck = true
+				} else if socket := strings.TrimPrefix(env, ContentSocketEnvKey+"="); socket != env {
+					// the content socket is served while the plugin runs
+					_, err := os.Stat(socket)
+					contentSocketCheck = err == nil
 				}
 			}
 
@@ -107,6 +118,10 @@ func TestVerify_IsSuccessTrue_Expected(t *testing.T) {
 				t.Fatalf("missing subject env")
 			}
 
+			if !contentSocketCheck {
+				t.Fatalf("missing content socket env")
+			}
+
 			verifierResult := ` {"isSuccess":true}`
 			return []byte(verifierResult), nil
 		},
@@ -141,6 +156,50 @@ func TestVerify_IsSuccessTrue_Expected(t *testing.T) {
 	}
 }
 
+// TestVerify_SharesContentServer tests that the verifications reading from a store are served by the same content server
+func TestVerify_SharesContentServer(t *testing.T) {
+	defer orchestrator.StopAllShared()
+	var sockets []string
+	testExecutor := &TestExecutor{
+		find: func(plugin string, paths []string) (string, error) {
+			return testPath, nil
+		},
+		execute: func(ctx context.Context, pluginPath string, cmdArgs []string, stdinData []byte, environ []string) ([]byte, error) {
+			for _, env := range environ {
+				if socket := strings.TrimPrefix(env, ContentSocketEnvKey+"="); socket != env {
+					sockets = append(sockets, socket)
+				}
+			}
+			return []byte(`{"isSuccess":true}`), nil
+		},
+	}
+	verifierPlugin := &VerifierPlugin{
+		name:          "test-plugin",
+		artifactTypes: []string{"test-type"},
+		version:       "1.0.0",
+		executor:      testExecutor,
+		rawConfig:     map[string]interface{}{"name": "test-plugin"},
+	}
+
+	store := &sm.TestStore{}
+	for i := 0; i < 2; i++ {
+		if _, err := verifierPlugin.Verify(context.Background(), common.Reference{Original: "localhost"}, ocispecs.ReferenceDescriptor{ArtifactType: "test-type"}, store); err != nil {
+			t.Fatalf("plugin execution failed %v", err)
+		}
+	}
+	if len(sockets) != 2 || sockets[0] != sockets[1] {
+		t.Fatalf("expected both verifications to be served the same content socket, got %v", sockets)
+	}
+	if _, err := os.Stat(sockets[0]); err != nil {
+		t.Fatalf("expected the content socket to be served after the verifications, got %v", err)
+	}
+
+	orchestrator.StopShared(store)
+	if _, err := os.Stat(sockets[0]); !os.IsNotExist(err) {
+		t.Fatalf("expected the content socket to be removed once the store is no longer used, got %v", err)
+	}
+}
+
 func TestVerify_IsSuccessFalse_Expected(t *testing.T) {
 	testPlugin := "test-plugin"
 	testExecutor := &TestExecutor{
@@ -217,3 +276,104 @@ func TestVerify_IsSuccessFalse_Expected(t *testing.T) {
 		t.Fatal("plugin expected to return isSuccess as false but got as true")
 	}
 }
+
+func TestVerify_PluginError_Expected(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		expectError *verifier.PluginError
+	}{
+		{
+			name:        "plugin error",
+			err:         &pluginCommon.Error{Code: 8, Msg: "plugin command VERIFY failed", Details: "invalid signature", Stderr: "panic: oops"},
+			expectError: &verifier.PluginError{Code: 8, Message: "plugin command VERIFY failed", Details: "invalid signature", Stderr: "panic: oops"},
+		},
+		{
+			name: "other error",
+			err:  errors.New("context deadline exceeded"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifierPlugin := &VerifierPlugin{
+				name:          testPlugin,
+				artifactTypes: []string{"test-type"},
+				version:       "1.0.0",
+				executor: &TestExecutor{
+					find: func(plugin string, paths []string) (string, error) {
+						return testPath, nil
+					},
+					execute: func(ctx context.Context, pluginPath string, cmdArgs []string, stdinData []byte, environ []string) ([]byte, error) {
+						return nil, tt.err
+					},
+				},
+				rawConfig: map[string]interface{}{"name": testPlugin},
+			}
+
+			result, err := verifierPlugin.Verify(context.Background(), common.Reference{Original: "localhost"}, ocispecs.ReferenceDescriptor{ArtifactType: "test-type"}, &sm.TestStore{})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if result.IsSuccess || result.Name != testPlugin || !reflect.DeepEqual(result.Error, tt.expectError) {
+				t.Fatalf("expected failed result with error %+v, got %+v", tt.expectError, result)
+			}
+		})
+	}
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	testCases := []struct {
+		name      string
+		findErr   error
+		output    string
+		execErr   error
+		expected  string
+		expectErr bool
+	}{
+		{name: "same version", output: `{"name":"test-plugin","supportedVersions":["0.1.0","1.0.0"]}`, expected: "1.0.0"},
+		{name: "older version", output: `{"name":"test-plugin","supportedVersions":["0.9.0","1.0.0"]}`, expected: "1.0.0"},
+		{name: "incompatible plugin", output: `{"name":"test-plugin","supportedVersions":["2.0.0"]}`, expectErr: true},
+		{name: "invalid output", output: `not json`, expectErr: true},
+		{name: "plugin without version command", execErr: pluginCommon.NewError(4, "missing env variables [RATIFY_VERIFIER_SUBJECT]", ""), expected: "1.1.0"},
+		{name: "plugin failure", execErr: errors.New("exec format error"), expected: "1.1.0"},
+		{name: "plugin not installed", findErr: errors.New("not found"), expected: "1.1.0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vp := &VerifierPlugin{
+				name:          testPlugin,
+				version:       "1.1.0",
+				artifactTypes: []string{"test-type"},
+				executor: &TestExecutor{
+					find: func(plugin string, paths []string) (string, error) {
+						return testPath, tc.findErr
+					},
+					execute: func(ctx context.Context, pluginPath string, cmdArgs []string, stdinData []byte, environ []string) ([]byte, error) {
+						if !containsEnv(environ, CommandEnvKey+"="+pluginCommon.VersionCommand) {
+							t.Fatalf("expected the %s command", pluginCommon.VersionCommand)
+						}
+						return []byte(tc.output), tc.execErr
+					},
+				},
+			}
+			err := vp.negotiateVersion(context.Background())
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if err == nil && vp.version != tc.expected {
+				t.Fatalf("expected version %s, got %s", tc.expected, vp.version)
+			}
+		})
+	}
+}
+
+func containsEnv(environ []string, env string) bool {
+	for _, e := range environ {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skel
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	verifierpb "github.com/deislabs/ratify/experimental/proto/v1/verifier"
+	"github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
+	"github.com/deislabs/ratify/pkg/utils"
+	"github.com/deislabs/ratify/pkg/verifier/types"
+)
+
+// ServeGRPC serves verifyReference over gRPC at address until the server fails, so the plugin runs as a
+// long-lived process rather than being executed for each reference. Requests sent in a version that is not one of
+// supportedVersions are rejected.
+func ServeGRPC(name, version string, verifyReference VerifyReference, supportedVersions []string, address string, opts ...plugin.MainOption) error {
+	server, err := newGRPCServer(name, version, verifyReference, supportedVersions, opts...)
+	if err != nil {
+		return err
+	}
+	listener, err := grpcplugin.Listen(address)
+	if err != nil {
+		return err
+	}
+	return server.Serve(listener)
+}
+
+func newGRPCServer(name, version string, verifyReference VerifyReference, supportedVersions []string, opts ...plugin.MainOption) (*grpc.Server, error) {
+	info := plugin.NewPluginInfo(name, version, supportedVersions, plugin.NewMainOptions(opts...), plugin.FeatureGRPC, plugin.FeatureContentSocket)
+	server, err := grpcplugin.NewPluginServer(info)
+	if err != nil {
+		return nil, err
+	}
+	verifierpb.RegisterVerifierPluginServer(server, &grpcVerifier{
+		name:            name,
+		version:         version,
+		verifyReference: verifyReference,
+	})
+	return server, nil
+}
+
+type grpcVerifier struct {
+	verifierpb.UnimplementedVerifierPluginServer
+	name            string
+	version         string
+	verifyReference VerifyReference
+	// stores are kept for the lifetime of the plugin
+	stores storeCache
+}
+
+func (s *grpcVerifier) VerifyReference(ctx context.Context, request *verifierpb.VerifyReferenceRequest) (*verifierpb.VerifyReferenceResponse, error) {
+	stdinData, err := request.GetConfiguration().MarshalJSON()
+	if err != nil {
+		return nil, grpcplugin.StatusError(codes.InvalidArgument, plugin.NewError(types.ErrConfigParsingFailure, fmt.Sprintf("invalid configuration: %v", err), ""))
+	}
+	input, pluginErr := validateAndGetConfig(stdinData)
+	if pluginErr != nil {
+		return nil, grpcplugin.StatusError(codes.InvalidArgument, pluginErr)
+	}
+	subject := request.GetSubject().GetRawPath()
+	subjectRef, err := utils.ParseSubjectReference(subject)
+	if err != nil {
+		return nil, grpcplugin.StatusError(codes.InvalidArgument, plugin.NewError(types.ErrArgsParsingFailure, fmt.Sprintf("cannot parse subject reference %s: %v", subject, err), ""))
+	}
+	store, err := s.stores.get("", s.version, input)
+	if err != nil {
+		return nil, grpcplugin.StatusError(codes.FailedPrecondition, plugin.NewError(types.ErrArgsParsingFailure, fmt.Sprintf("create store from input config failed with error %v", err), ""))
+	}
+
+	cmdArgs := &CmdArgs{
+		Version:    s.version,
+		Subject:    subject,
+		StdinData:  stdinData,
+		subjectRef: subjectRef,
+	}
+	result, err := s.verifyReference(cmdArgs, subjectRef, input.ReferencDesc, store)
+	if err != nil {
+		return nil, grpcplugin.StatusError(codes.Internal, plugin.NewError(types.ErrPluginCmdFailure, fmt.Sprintf("plugin %s failed", s.name), err.Error()))
+	}
+
+	response := &verifierpb.VerifyReferenceResponse{
+		VerifierName: result.Name,
+		Subject:      subject,
+		Reference:    request.GetReference().GetDescriptor_(),
+		Valid:        result.IsSuccess,
+	}
+	if result.Message != "" {
+		response.Reasons = []string{result.Message}
+	}
+	extensions, err := grpcplugin.ToExtensionData(result.Extensions)
+	if err != nil {
+		return nil, grpcplugin.StatusError(codes.Internal, plugin.NewError(types.ErrIOFailure, fmt.Sprintf("plugin %s failed to write its output", s.name), err.Error()))
+	}
+	if extensions != nil {
+		response.Data = []*verifierpb.VerifyReferenceResponse_ExtensionData{{Values: extensions}}
+	}
+	return response, nil
+}
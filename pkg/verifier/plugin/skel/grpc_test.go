@@ -0,0 +1,219 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skel
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	verifierpb "github.com/deislabs/ratify/experimental/proto/v1/verifier"
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/common/plugin/grpcplugin"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	sp "github.com/deislabs/ratify/pkg/referrerstore/plugin"
+	"github.com/deislabs/ratify/pkg/utils"
+	"github.com/deislabs/ratify/pkg/verifier"
+	"github.com/deislabs/ratify/pkg/verifier/config"
+	"github.com/deislabs/ratify/pkg/verifier/plugin"
+	"github.com/deislabs/ratify/pkg/verifier/types"
+)
+
+const testSubject = "localhost:5000/net-monitor:v1@sha256:a0fc570a245b09ed752c42d600ee3bb5b4f77bbd70d8898780b7ab43454530eb"
+
+func serveTestVerifier(t *testing.T, verifyReference VerifyReference) string {
+	address := grpcplugin.UnixScheme + filepath.Join(t.TempDir(), "verifier.sock")
+	listener, err := grpcplugin.Listen(address)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server, err := newGRPCServer("grpc-test-case", "1.0.0", verifyReference, []string{"1.0.0"})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return address
+}
+
+func TestServeGRPC_VerifyReference(t *testing.T) {
+	referenceDesc := ocispecs.ReferenceDescriptor{
+		Descriptor:   oci.Descriptor{MediaType: oci.MediaTypeImageManifest, Digest: digest.FromString("signature"), Size: 9},
+		ArtifactType: "test-type",
+	}
+	var stores []referrerstore.ReferrerStore
+	address := serveTestVerifier(t, func(args *CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+		if subjectReference.Digest.String() != "sha256:a0fc570a245b09ed752c42d600ee3bb5b4f77bbd70d8898780b7ab43454530eb" {
+			t.Fatalf("unexpected subject %v", subjectReference)
+		}
+		if !reflect.DeepEqual(referenceDescriptor, referenceDesc) {
+			t.Fatalf("expected reference %v, got %v", referenceDesc, referenceDescriptor)
+		}
+		if _, ok := referrerStore.(*sp.StorePlugin); !ok || referrerStore.Name() != "test-store" {
+			t.Fatalf("expected store created from the store config, got %T", referrerStore)
+		}
+		stores = append(stores, referrerStore)
+		return &verifier.VerifierResult{
+			Name:       "grpc-test-case",
+			IsSuccess:  true,
+			Message:    "signature verified",
+			Extensions: map[string]interface{}{"issuer": "ratify"},
+		}, nil
+	})
+
+	verifierConfig := config.VerifierConfig{types.Name: "grpc-test-case", types.Address: address}
+	vp, err := plugin.NewGRPCVerifier("1.0.0", verifierConfig, address)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+	defer vp.Close()
+
+	subjectReference, err := utils.ParseSubjectReference(testSubject)
+	if err != nil {
+		t.Fatalf("failed to parse subject: %v", err)
+	}
+	store, err := sp.NewStore("1.0.0", map[string]interface{}{"name": "test-store"}, []string{"/tmp/ratify/plugins"})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		result, err := vp.Verify(context.Background(), subjectReference, referenceDesc, store)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		expected := verifier.VerifierResult{
+			Name:       "grpc-test-case",
+			IsSuccess:  true,
+			Message:    "signature verified",
+			Extensions: map[string]interface{}{"issuer": "ratify"},
+		}
+		if !reflect.DeepEqual(result, expected) {
+			t.Fatalf("expected result %v, got %v", expected, result)
+		}
+	}
+	if len(stores) != 2 || stores[0] != stores[1] {
+		t.Fatalf("expected the store to be reused across requests")
+	}
+}
+
+func TestServeGRPC_VerifyReference_Errors(t *testing.T) {
+	address := serveTestVerifier(t, func(args *CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+		return nil, fmt.Errorf("simulated error")
+	})
+	subjectReference, _ := utils.ParseSubjectReference(testSubject)
+	store, _ := sp.NewStore("1.0.0", map[string]interface{}{"name": "test-store"}, nil)
+
+	testCases := []struct {
+		name           string
+		verifierConfig config.VerifierConfig
+		expected       verifier.PluginError
+	}{
+		{
+			name:           "plugin failure",
+			verifierConfig: config.VerifierConfig{types.Name: "grpc-test-case"},
+			expected:       verifier.PluginError{Code: types.ErrPluginCmdFailure, Message: "plugin grpc-test-case failed", Details: "simulated error"},
+		},
+		{
+			name:           "missing verifier name",
+			verifierConfig: config.VerifierConfig{types.Name: ""},
+			expected:       verifier.PluginError{Code: types.ErrInvalidVerifierConfig, Message: "missing verifier name"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vp, err := plugin.NewGRPCVerifier("1.0.0", tc.verifierConfig, address)
+			if err != nil {
+				t.Fatalf("failed to create verifier: %v", err)
+			}
+			defer vp.Close()
+			result, err := vp.Verify(context.Background(), subjectReference, ocispecs.ReferenceDescriptor{}, store)
+			if err == nil || result.IsSuccess {
+				t.Fatalf("expected error, got %v", result)
+			}
+			// the error is reported like the error of an executed plugin
+			if result.Error == nil || *result.Error != tc.expected {
+				t.Fatalf("expected plugin error %+v, got %+v", tc.expected, result.Error)
+			}
+		})
+	}
+}
+
+func TestServeGRPC_VersionNegotiation(t *testing.T) {
+	address := serveTestVerifier(t, func(args *CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+		return &verifier.VerifierResult{Name: "grpc-test-case", IsSuccess: true}, nil
+	})
+	subjectReference, _ := utils.ParseSubjectReference(testSubject)
+	store, _ := sp.NewStore("1.0.0", map[string]interface{}{"name": "test-store"}, nil)
+	verifierConfig := config.VerifierConfig{types.Name: "grpc-test-case"}
+
+	if _, err := plugin.NewGRPCVerifier("2.0.0", verifierConfig, address); err == nil {
+		t.Fatalf("expected error for a version the plugin does not support")
+	}
+
+	// a newer minor version is negotiated down to the version supported by the plugin
+	vp, err := plugin.NewGRPCVerifier("1.1.0", verifierConfig, address)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+	defer vp.Close()
+	if result, err := vp.Verify(context.Background(), subjectReference, ocispecs.ReferenceDescriptor{}, store); err != nil || !result.IsSuccess {
+		t.Fatalf("expected the verification to succeed, got %v and error %v", result, err)
+	}
+
+	// the plugin rejects requests in versions it does not support
+	conn, err := grpcplugin.Dial(address)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	configuration, _ := grpcplugin.ToStruct(config.PluginInputConfig{Config: verifierConfig})
+	_, err = verifierpb.NewVerifierPluginClient(conn).VerifyReference(grpcplugin.WithVersion(context.Background(), "1.1.0"), &verifierpb.VerifyReferenceRequest{Configuration: configuration})
+	if pluginErr, ok := grpcplugin.PluginError(err); status.Code(err) != codes.FailedPrecondition || !ok || pluginErr.Code != types.ErrVersionNotSupported {
+		t.Fatalf("expected the request to be rejected, got %v", err)
+	}
+}
+
+func TestNewGRPCVerifier_WithoutVersionService(t *testing.T) {
+	address := grpcplugin.UnixScheme + filepath.Join(t.TempDir(), "verifier.sock")
+	listener, err := grpcplugin.Listen(address)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	// a server implementing only the protos keeps the configured version
+	server := grpcplugin.NewServer()
+	verifierpb.RegisterVerifierPluginServer(server, &grpcVerifier{name: "grpc-test-case", version: "1.0.0"})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	vp, err := plugin.NewGRPCVerifier("2.0.0", config.VerifierConfig{types.Name: "grpc-test-case"}, address)
+	if err != nil {
+		t.Fatalf("expected the verifier to be created, got %v", err)
+	}
+	vp.Close()
+}
@@ -0,0 +1,163 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/common/plugin"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/verifier"
+	"github.com/deislabs/ratify/pkg/verifier/config"
+	"github.com/deislabs/ratify/pkg/verifier/factory"
+	vp "github.com/deislabs/ratify/pkg/verifier/plugin"
+	"github.com/deislabs/ratify/pkg/verifier/types"
+)
+
+// Plugin describes a verifier plugin built with the skeleton. The same plugin runs as a binary executed by Ratify with
+// Main, or in the process of Ratify once registered as a built-in verifier with Register.
+type Plugin struct {
+	Name              string
+	Version           string
+	VerifyReference   VerifyReference
+	SupportedVersions []string
+	Options           []plugin.MainOption
+}
+
+var _ factory.VerifierFactory = Plugin{}
+
+// Main is the "main" of the plugin binary
+func (p Plugin) Main() {
+	PluginMain(p.Name, p.Version, p.VerifyReference, p.SupportedVersions, p.Options...)
+}
+
+// Register registers the plugin as a built-in verifier, so that verifiers configured with the name of the plugin
+// verify references in the process of Ratify instead of executing the plugin binary
+func (p Plugin) Register() {
+	factory.Register(p.Name, p)
+}
+
+// Create creates a verifier running the plugin in-process from the verifier config
+func (p Plugin) Create(version string, verifierConfig config.VerifierConfig) (verifier.ReferenceVerifier, error) {
+	// the version is negotiated like with the VERSION command of a plugin binary
+	version, err := plugin.HighestCompatibleVersion(version, p.SupportedVersions)
+	if err != nil {
+		return nil, fmt.Errorf("verifier %s: %w", p.Name, err)
+	}
+
+	var nestedReferences []string
+	if vs, ok := verifierConfig[types.NestedReferences]; ok {
+		nestedReferences = strings.Split(fmt.Sprintf("%s", vs), ",")
+	}
+
+	artifactTypes := []string{"*"}
+	if at, ok := verifierConfig[types.ArtifactTypes]; ok {
+		artifactTypes = strings.Split(fmt.Sprintf("%s", at), ",")
+	}
+
+	return &inProcessVerifier{
+		plugin:           p,
+		version:          version,
+		rawConfig:        verifierConfig,
+		artifactTypes:    artifactTypes,
+		nestedReferences: nestedReferences,
+	}, nil
+}
+
+// inProcessVerifier passes the references to verify to the plugin as the skeleton of the plugin binary would, with
+// the store of the host
+type inProcessVerifier struct {
+	plugin           Plugin
+	version          string
+	rawConfig        config.VerifierConfig
+	artifactTypes    []string
+	nestedReferences []string
+}
+
+func (v *inProcessVerifier) Name() string {
+	return v.plugin.Name
+}
+
+func (v *inProcessVerifier) CanVerify(ctx context.Context, referenceDescriptor ocispecs.ReferenceDescriptor) bool {
+	for _, at := range v.artifactTypes {
+		if at == "*" || at == referenceDescriptor.ArtifactType {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *inProcessVerifier) Verify(ctx context.Context,
+	subjectReference common.Reference,
+	referenceDescriptor ocispecs.ReferenceDescriptor,
+	store referrerstore.ReferrerStore) (result verifier.VerifierResult, err error) {
+	stdinData, err := json.Marshal(config.PluginInputConfig{
+		Config:       v.rawConfig,
+		StoreConfig:  *store.GetConfig(),
+		ReferencDesc: referenceDescriptor,
+	})
+	if err != nil {
+		return verifier.VerifierResult{IsSuccess: false}, err
+	}
+
+	// a panic would end the plugin process, it must not end Ratify
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("verifier %s panicked: %v", v.plugin.Name, r)
+			result = verifier.VerifierResult{IsSuccess: false, Name: v.plugin.Name, Error: commandFailure(err)}
+		}
+	}()
+
+	cmdArgs := &CmdArgs{
+		Version:    v.version,
+		Subject:    subjectReference.String(),
+		subjectRef: subjectReference,
+		StdinData:  stdinData,
+	}
+	vr, err := v.plugin.VerifyReference(cmdArgs, subjectReference, referenceDescriptor, store)
+	if err != nil {
+		return verifier.VerifierResult{IsSuccess: false, Name: v.plugin.Name, Error: commandFailure(err)}, err
+	}
+	if vr == nil {
+		return verifier.VerifierResult{IsSuccess: false}, fmt.Errorf("verifier %s returned no result", v.plugin.Name)
+	}
+
+	// the result holds the fields a plugin binary returns to Ratify
+	return verifier.VerifierResult{
+		IsSuccess:  vr.IsSuccess,
+		Message:    vr.Message,
+		Name:       vr.Name,
+		Extensions: vr.Extensions,
+	}, nil
+}
+
+// commandFailure returns the error the skeleton of the plugin binary would return for a failed verification
+func commandFailure(err error) *verifier.PluginError {
+	return &verifier.PluginError{
+		Code:    types.ErrPluginCmdFailure,
+		Message: fmt.Sprintf("plugin command %s failed", vp.VerifyCommand),
+		Details: err.Error(),
+	}
+}
+
+func (v *inProcessVerifier) GetNestedReferences() []string {
+	return v.nestedReferences
+}
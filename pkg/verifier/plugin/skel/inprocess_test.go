@@ -0,0 +1,158 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/ocispecs"
+	"github.com/deislabs/ratify/pkg/referrerstore"
+	sm "github.com/deislabs/ratify/pkg/referrerstore/mocks"
+	"github.com/deislabs/ratify/pkg/utils"
+	"github.com/deislabs/ratify/pkg/verifier"
+	"github.com/deislabs/ratify/pkg/verifier/config"
+	"github.com/deislabs/ratify/pkg/verifier/types"
+)
+
+func TestPlugin_Create(t *testing.T) {
+	testPlugin := Plugin{Name: "in-process", Version: "1.0.0", SupportedVersions: []string{"1.0.0", "1.1.0"}}
+
+	tests := []struct {
+		name          string
+		version       string
+		config        config.VerifierConfig
+		expectErr     bool
+		expectVersion string
+		canVerify     bool
+	}{
+		{
+			name:          "compatible version",
+			version:       "1.2.0",
+			config:        config.VerifierConfig{"name": "in-process"},
+			expectVersion: "1.1.0",
+			canVerify:     true,
+		},
+		{
+			name:      "incompatible version",
+			version:   "2.0.0",
+			config:    config.VerifierConfig{"name": "in-process"},
+			expectErr: true,
+		},
+		{
+			name:          "other artifact type",
+			version:       "1.0.0",
+			config:        config.VerifierConfig{"name": "in-process", "artifactTypes": "other-type"},
+			expectVersion: "1.0.0",
+			canVerify:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := testPlugin.Create(tt.version, tt.config)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to create verifier: %v", err)
+			}
+			if version := v.(*inProcessVerifier).version; version != tt.expectVersion {
+				t.Fatalf("expected version %s, got %s", tt.expectVersion, version)
+			}
+			if canVerify := v.CanVerify(context.Background(), ocispecs.ReferenceDescriptor{ArtifactType: "test-type"}); canVerify != tt.canVerify {
+				t.Fatalf("expected CanVerify %v, got %v", tt.canVerify, canVerify)
+			}
+		})
+	}
+}
+
+func TestInProcessVerifier_Verify(t *testing.T) {
+	subject := "localhost:5000/net-monitor:v1@sha256:a0fc570a245b09ed752c42d600ee3bb5b4f77bbd70d8898780b7ab43454530eb"
+	subjectReference, err := utils.ParseSubjectReference(subject)
+	if err != nil {
+		t.Fatalf("failed to parse subject: %v", err)
+	}
+	store := sm.NewMemoryStore()
+
+	testPlugin := Plugin{
+		Name:              "in-process",
+		Version:           "1.0.0",
+		SupportedVersions: []string{"1.0.0"},
+		VerifyReference: func(args *CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+			if args.Subject != subjectReference.String() || args.Version != "1.0.0" {
+				return nil, fmt.Errorf("unexpected args %+v", args)
+			}
+			if referrerStore != store {
+				return nil, fmt.Errorf("expected the store of the host")
+			}
+			var input config.PluginInputConfig
+			if err := json.Unmarshal(args.StdinData, &input); err != nil {
+				return nil, err
+			}
+			if input.Config["key"] == "panic" {
+				panic("test panic")
+			}
+			return &verifier.VerifierResult{Name: "in-process", IsSuccess: input.Config["key"] == "value", Subject: "dropped"}, nil
+		},
+	}
+
+	tests := []struct {
+		name          string
+		config        config.VerifierConfig
+		expectErr     bool
+		expectSuccess bool
+	}{
+		{
+			name:          "config passed",
+			config:        config.VerifierConfig{"name": "in-process", "key": "value"},
+			expectSuccess: true,
+		},
+		{
+			name:      "panic recovered",
+			config:    config.VerifierConfig{"name": "in-process", "key": "panic"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := testPlugin.Create("1.0.0", tt.config)
+			if err != nil {
+				t.Fatalf("failed to create verifier: %v", err)
+			}
+			result, err := v.Verify(context.Background(), subjectReference, ocispecs.ReferenceDescriptor{ArtifactType: "test-type"}, store)
+			if tt.expectErr {
+				if err == nil || result.IsSuccess || result.Error == nil || result.Error.Code != types.ErrPluginCmdFailure {
+					t.Fatalf("expected a failed verification with an error, got %+v %v", result, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to verify: %v", err)
+			}
+			if result.IsSuccess != tt.expectSuccess || result.Name != "in-process" || result.Subject != "" {
+				t.Fatalf("unexpected result %+v", result)
+			}
+		})
+	}
+}
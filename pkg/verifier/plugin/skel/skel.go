@@ -16,19 +16,23 @@ limitations under the License.
 package skel
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/deislabs/ratify/pkg/common"
 	"github.com/deislabs/ratify/pkg/common/plugin"
 	"github.com/deislabs/ratify/pkg/ocispecs"
 	"github.com/deislabs/ratify/pkg/referrerstore"
+	"github.com/deislabs/ratify/pkg/referrerstore/blobcache"
 	storeConfig "github.com/deislabs/ratify/pkg/referrerstore/config"
 	"github.com/deislabs/ratify/pkg/referrerstore/factory"
+	"github.com/deislabs/ratify/pkg/referrerstore/orchestrator"
 	"github.com/deislabs/ratify/pkg/utils"
 	"github.com/deislabs/ratify/pkg/verifier"
 	"github.com/deislabs/ratify/pkg/verifier/config"
@@ -41,6 +45,8 @@ type pcontext struct {
 	Stdin      io.Reader
 	Stdout     io.Writer
 	Stderr     io.Writer
+	// stores are reused across the requests of a persistent process, nil if the process serves a single request
+	stores *storeCache
 }
 
 type VerifyReference func(args *CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error)
@@ -54,13 +60,45 @@ type CmdArgs struct {
 }
 
 // PluginMain is the core "main" for a plugin which includes error handling.
-func PluginMain(name, version string, verifyReference VerifyReference, supportedVersions []string) {
+// Options such as plugin.WithPersistentMode advertise optional capabilities of the plugin.
+func PluginMain(name, version string, verifyReference VerifyReference, supportedVersions []string, opts ...plugin.MainOption) {
+	// stores created by the plugin share the blob cache of the ratify process, which evicts it
+	blobcache.DisableMaintenance()
+	if address := os.Getenv(vp.GRPCAddressEnvKey); address != "" {
+		if err := ServeGRPC(name, version, verifyReference, supportedVersions, address, opts...); err != nil {
+			log.Fatalf("failed to serve plugin %s at %s: %v", name, address, err)
+		}
+		return
+	}
+
+	if plugin.NewMainOptions(opts...).Persistent && os.Getenv(plugin.PersistentEnvKey) != "" {
+		stdout := os.Stdout
+		// anything the plugin prints would corrupt the responses written to stdout
+		os.Stdout = os.Stderr
+		stores := &storeCache{}
+		err := plugin.ServePersistent(os.Stdin, stdout, os.Stderr, func(request plugin.PersistentRequest, stderr io.Writer) plugin.PersistentResponse {
+			out := &bytes.Buffer{}
+			e := (&pcontext{
+				GetEnviron: request.Getenv,
+				Stdin:      bytes.NewReader(request.Stdin),
+				Stdout:     out,
+				Stderr:     stderr,
+				stores:     stores,
+			}).pluginMainCore(name, version, verifyReference, supportedVersions, opts...)
+			return plugin.PersistentResponse{Stdout: out.Bytes(), Error: e}
+		})
+		if err != nil {
+			log.Fatalf("persistent plugin %s failed: %v", name, err)
+		}
+		return
+	}
+
 	if e := (&pcontext{
 		GetEnviron: os.Getenv,
 		Stdin:      os.Stdin,
 		Stdout:     os.Stdout,
 		Stderr:     os.Stderr,
-	}).pluginMainCore(name, version, verifyReference, supportedVersions); e != nil {
+	}).pluginMainCore(name, version, verifyReference, supportedVersions, opts...); e != nil {
 		if err := e.Print(); err != nil {
 			log.Print("Error writing error response to stdout: ", err)
 		}
@@ -68,7 +106,15 @@ func PluginMain(name, version string, verifyReference VerifyReference, supported
 	}
 }
 
-func (pc *pcontext) pluginMainCore(name, version string, verifyReference VerifyReference, supportedVersions []string) *plugin.Error {
+func (pc *pcontext) pluginMainCore(name, version string, verifyReference VerifyReference, supportedVersions []string, opts ...plugin.MainOption) *plugin.Error {
+	if pc.GetEnviron(vp.CommandEnvKey) == plugin.VersionCommand {
+		info := plugin.NewPluginInfo(name, version, supportedVersions, plugin.NewMainOptions(opts...), plugin.FeatureGRPC, plugin.FeatureContentSocket)
+		if err := info.Write(pc.Stdout); err != nil {
+			return plugin.NewError(types.ErrIOFailure, "failed to write plugin output", err.Error())
+		}
+		return nil
+	}
+
 	cmd, cmdArgs, err := pc.getCmdArgsFromEnv()
 	if err != nil {
 		return err
@@ -83,23 +129,19 @@ func (pc *pcontext) pluginMainCore(name, version string, verifyReference VerifyR
 		return err
 	}
 
-	// The below is a workaround to be able to use built-in referrer store plugins from within verifier plugins
-	storeConfigs := storeConfig.StoresConfig{
-		Version:       version,
-		PluginBinDirs: input.StoreConfig.PluginBinDirs,
-		Stores:        []storeConfig.StorePluginConfig{input.StoreConfig.Store},
+	var store referrerstore.ReferrerStore
+	var storeErr error
+	if pc.stores != nil {
+		store, storeErr = pc.stores.get(pc.GetEnviron(vp.ContentSocketEnvKey), version, input)
+	} else {
+		store, storeErr = createStore(pc.GetEnviron(vp.ContentSocketEnvKey), version, input)
 	}
-	stores, storeErr := factory.CreateStoresFromConfig(storeConfigs, "")
-	if storeErr != nil || stores == nil || len(stores) == 0 {
+	if storeErr != nil {
 		return plugin.NewError(types.ErrArgsParsingFailure, fmt.Sprintf("create store from input config failed with error %v", storeErr), "")
 	}
-	store := stores[0]
-
-	// This is the original implementation for initialization of a referrer store which does not support built-ins
-	//store, serr := rp.NewStore(input.StoreConfig.Version, input.StoreConfig.Store, input.StoreConfig.PluginBinDirs)
-	//if serr != nil {
-	//	return plugin.NewError(types.ErrArgsParsingFailure, fmt.Sprintf("create store from the input config failed with error %v", serr), "")
-	//}
+	if closer, ok := store.(io.Closer); ok && pc.stores == nil {
+		defer closer.Close()
+	}
 
 	switch cmd {
 	case vp.VerifyCommand:
@@ -120,6 +162,58 @@ func (pc *pcontext) pluginMainCore(name, version string, verifyReference VerifyR
 	}
 }
 
+// createStore returns a store reading content from the host if it serves a content socket, the store is
+// created by the plugin otherwise
+func createStore(contentSocket string, version string, input *config.PluginInputConfig) (referrerstore.ReferrerStore, error) {
+	createLocal := func() (referrerstore.ReferrerStore, error) {
+		// The below is a workaround to be able to use built-in referrer store plugins from within verifier plugins
+		storeConfigs := storeConfig.StoresConfig{
+			Version:       version,
+			PluginBinDirs: input.StoreConfig.PluginBinDirs,
+			Stores:        []storeConfig.StorePluginConfig{input.StoreConfig.Store},
+		}
+		stores, err := factory.CreateStoresFromConfig(storeConfigs, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(stores) == 0 {
+			return nil, fmt.Errorf("no store created from the input config")
+		}
+		return stores[0], nil
+	}
+
+	if contentSocket == "" {
+		return createLocal()
+	}
+	return orchestrator.NewStore(contentSocket, input.StoreConfig, createLocal)
+}
+
+// storeCache keeps the stores of a long-lived plugin process, keyed by the content socket they read from and
+// their serialized configuration
+type storeCache struct {
+	stores sync.Map
+}
+
+func (c *storeCache) get(contentSocket string, version string, input *config.PluginInputConfig) (referrerstore.ReferrerStore, error) {
+	storeConfigBytes, err := json.Marshal(input.StoreConfig)
+	if err != nil {
+		return nil, err
+	}
+	key := contentSocket + "\n" + string(storeConfigBytes)
+	if store, ok := c.stores.Load(key); ok {
+		return store.(referrerstore.ReferrerStore), nil
+	}
+	store, err := createStore(contentSocket, version, input)
+	if err != nil {
+		return nil, err
+	}
+	actual, loaded := c.stores.LoadOrStore(key, store)
+	if closer, ok := store.(io.Closer); ok && loaded {
+		closer.Close()
+	}
+	return actual.(referrerstore.ReferrerStore), nil
+}
+
 func (pc *pcontext) getCmdArgsFromEnv() (string, *CmdArgs, *plugin.Error) {
 	argsMissing := make([]string, 0)
 
@@ -167,11 +261,9 @@ func (pc *pcontext) getCmdArgsFromEnv() (string, *CmdArgs, *plugin.Error) {
 }
 
 func validateVersion(version string, supportedVersions []string) *plugin.Error {
-	for _, v := range supportedVersions {
-		// TODO check for compatibility using semversion
-		if v == version {
-			return nil
-		}
+	// the host negotiates one of the supported versions with the VERSION command
+	if plugin.IsVersionSupported(version, supportedVersions) {
+		return nil
 	}
 
 	return plugin.NewError(types.ErrVersionNotSupported, fmt.Sprintf("plugin doesn't support version %s", version), "")
@@ -17,14 +17,19 @@ package skel
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/deislabs/ratify/pkg/common"
+	pluginCommon "github.com/deislabs/ratify/pkg/common/plugin"
 	"github.com/deislabs/ratify/pkg/ocispecs"
 	"github.com/deislabs/ratify/pkg/referrerstore"
+	sm "github.com/deislabs/ratify/pkg/referrerstore/mocks"
+	"github.com/deislabs/ratify/pkg/referrerstore/orchestrator"
 	sp "github.com/deislabs/ratify/pkg/referrerstore/plugin"
 	"github.com/deislabs/ratify/pkg/verifier/plugin"
 	"github.com/deislabs/ratify/pkg/verifier/types"
@@ -120,6 +125,95 @@ func TestPluginMain_VerifyReference_CanUseBuiltinStores(t *testing.T) {
 	}
 }
 
+func TestPluginMain_VerifyReference_ReadsContentFromHost(t *testing.T) {
+	server, serr := orchestrator.Serve(&sm.TestStore{})
+	if serr != nil {
+		t.Fatalf("failed to serve content: %v", serr)
+	}
+	defer server.Stop()
+
+	verifyReference := func(args *CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+		if _, ok := referrerStore.(*orchestrator.Store); !ok {
+			t.Fatalf("expected store reading content from the host, got %T", referrerStore)
+		}
+		if _, err := referrerStore.GetReferenceManifest(context.Background(), subjectReference, referenceDescriptor); err != nil {
+			t.Fatalf("expected manifest from the host, got %v", err)
+		}
+
+		return &verifier.VerifierResult{IsSuccess: true}, nil
+	}
+
+	environment := map[string]string{
+		plugin.CommandEnvKey:       plugin.VerifyCommand,
+		plugin.VersionEnvKey:       "1.0.0",
+		plugin.SubjectEnvKey:       "localhost:5000/net-monitor:v1@sha256:a0fc570a245b09ed752c42d600ee3bb5b4f77bbd70d8898780b7ab43454530eb",
+		plugin.ContentSocketEnvKey: server.SocketPath(),
+	}
+
+	stdinData := `{ "storeConfig" : {"store": {"name":"testStore"}}, "config": {"name": "skel-test-case", "some":"config"}, "referenceDesc": {"artifactType": "test-type"}}`
+	stdout := &bytes.Buffer{}
+	pluginContext := &pcontext{
+		GetEnviron: func(key string) string { return environment[key] },
+		Stdin:      strings.NewReader(stdinData),
+		Stdout:     stdout,
+		Stderr:     &bytes.Buffer{},
+	}
+
+	err := pluginContext.pluginMainCore("skel-test-case", "1.0.0", verifyReference, []string{"1.0.0"})
+	if err != nil {
+		t.Fatalf("plugin execution failed %v", err)
+	}
+	if out := stdout.String(); !strings.Contains(out, `"isSuccess":true`) {
+		t.Fatalf("plugin execution failed. expected %v actual %v", "isSuccess: true", out)
+	}
+}
+
+// TestPluginMain_Persistent_ReusesStores tests that the requests of a persistent process reuse the stores of previous requests
+func TestPluginMain_Persistent_ReusesStores(t *testing.T) {
+	var sockets []string
+	for i := 0; i < 2; i++ {
+		server, err := orchestrator.Serve(&sm.TestStore{})
+		if err != nil {
+			t.Fatalf("failed to serve content: %v", err)
+		}
+		defer server.Stop()
+		sockets = append(sockets, server.SocketPath())
+	}
+
+	var stores []referrerstore.ReferrerStore
+	verifyReference := func(args *CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
+		stores = append(stores, referrerStore)
+		return &verifier.VerifierResult{IsSuccess: true}, nil
+	}
+
+	cache := &storeCache{}
+	for _, socket := range []string{sockets[0], sockets[0], sockets[1]} {
+		environment := map[string]string{
+			plugin.CommandEnvKey:       plugin.VerifyCommand,
+			plugin.VersionEnvKey:       "1.0.0",
+			plugin.SubjectEnvKey:       "localhost:5000/net-monitor:v1@sha256:a0fc570a245b09ed752c42d600ee3bb5b4f77bbd70d8898780b7ab43454530eb",
+			plugin.ContentSocketEnvKey: socket,
+		}
+		pluginContext := &pcontext{
+			GetEnviron: func(key string) string { return environment[key] },
+			Stdin:      strings.NewReader(`{ "storeConfig" : {"store": {"name":"testStore"}}, "config": {"name": "skel-test-case"}, "referenceDesc": {"artifactType": "test-type"}}`),
+			Stdout:     &bytes.Buffer{},
+			Stderr:     &bytes.Buffer{},
+			stores:     cache,
+		}
+		if err := pluginContext.pluginMainCore("skel-test-case", "1.0.0", verifyReference, []string{"1.0.0"}); err != nil {
+			t.Fatalf("plugin execution failed %v", err)
+		}
+	}
+
+	if len(stores) != 3 || stores[0] != stores[1] {
+		t.Fatalf("expected the store to be reused across requests")
+	}
+	if stores[2] == stores[0] {
+		t.Fatalf("expected a store per content socket")
+	}
+}
+
 func TestPluginMain_ErrorCases(t *testing.T) {
 	verifyReference := func(args *CmdArgs, subjectReference common.Reference, referenceDescriptor ocispecs.ReferenceDescriptor, referrerStore referrerstore.ReferrerStore) (*verifier.VerifierResult, error) {
 		return nil, fmt.Errorf("simulated error")
@@ -191,3 +285,38 @@ func TestPluginMain_ErrorCases(t *testing.T) {
 		t.Fatalf("plugin execution expected to fail with error code %d for cmd failure", types.ErrPluginCmdFailure)
 	}
 }
+
+func TestPluginMain_VersionCommand_ReturnsInfo(t *testing.T) {
+	environment := map[string]string{
+		plugin.CommandEnvKey: pluginCommon.VersionCommand,
+		plugin.VersionEnvKey: "1.0.0",
+	}
+	stdout := &bytes.Buffer{}
+	pluginContext := &pcontext{
+		GetEnviron: func(key string) string { return environment[key] },
+		Stdin:      strings.NewReader(""),
+		Stdout:     stdout,
+		Stderr:     &bytes.Buffer{},
+	}
+
+	err := pluginContext.pluginMainCore("skel-test-case", "1.2.0", nil, []string{"0.1.0", "1.0.0"}, pluginCommon.WithPersistentMode(), pluginCommon.WithArtifactTypes("test-type"))
+	if err != nil {
+		t.Fatalf("plugin execution failed %v", err)
+	}
+
+	info := pluginCommon.PluginInfo{}
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		t.Fatalf("failed to parse plugin info %s: %v", stdout.String(), err)
+	}
+	if info.Name != "skel-test-case" || info.Version != "1.2.0" || !reflect.DeepEqual(info.SupportedVersions, []string{"0.1.0", "1.0.0"}) {
+		t.Fatalf("unexpected plugin info %+v", info)
+	}
+	if !reflect.DeepEqual(info.ArtifactTypes, []string{"test-type"}) {
+		t.Fatalf("expected artifact types [test-type], got %v", info.ArtifactTypes)
+	}
+	for _, feature := range []string{pluginCommon.FeatureGRPC, pluginCommon.FeatureContentSocket, pluginCommon.FeaturePersistent} {
+		if !info.HasFeature(feature) {
+			t.Fatalf("expected feature %s, got %v", feature, info.Features)
+		}
+	}
+}
@@ -29,6 +29,10 @@ const (
 	ArtifactTypes    string = "artifactTypes"
 	NestedReferences string = "nestedReferences"
 	Source           string = "source"
+	Address          string = "address"
+	Persistent       string = "persistent"
+	MaxProcesses     string = "maxProcesses"
+	ExecutionPolicy  string = "executionPolicy"
 )
 
 const (
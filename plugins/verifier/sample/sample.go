@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/deislabs/ratify/pkg/common"
+	"github.com/deislabs/ratify/pkg/common/plugin"
 	"github.com/deislabs/ratify/pkg/ocispecs"
 	"github.com/deislabs/ratify/pkg/referrerstore"
 	"github.com/deislabs/ratify/pkg/verifier"
@@ -36,7 +37,7 @@ type PluginInputConfig struct {
 }
 
 func main() {
-	skel.PluginMain("sample", "1.0.0", VerifyReference, []string{"1.0.0"})
+	skel.PluginMain("sample", "1.0.0", VerifyReference, []string{"1.0.0"}, plugin.WithPersistentMode())
 }
 
 func parseInput(stdin []byte) (*PluginConfig, error) {
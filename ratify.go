@@ -0,0 +1,103 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ratify is the entrypoint of the ratify binary and of custom distributions of it. A distribution compiles
+// verifiers and stores into the binary, where they run in-process instead of being executed as plugins:
+//
+//	func main() {
+//		ratify.NewBuilder().
+//			WithVerifierPlugin(sbomverifier.Plugin).
+//			Main()
+//	}
+//
+// The ratifygen command generates the registration of plugin packages, gated by a build tag if requested.
+package ratify
+
+import (
+	"os"
+
+	"github.com/deislabs/ratify/cmd/ratify/cmd"
+	sf "github.com/deislabs/ratify/pkg/referrerstore/factory"
+	vf "github.com/deislabs/ratify/pkg/verifier/factory"
+	"github.com/deislabs/ratify/pkg/verifier/plugin/skel"
+
+	// the built-in policy provider, stores and verifiers of every distribution
+	_ "github.com/deislabs/ratify/pkg/policyprovider/configpolicy"
+	_ "github.com/deislabs/ratify/pkg/referrerstore/ocilayout"
+	_ "github.com/deislabs/ratify/pkg/referrerstore/oras"
+	_ "github.com/deislabs/ratify/pkg/verifier/notaryv2"
+)
+
+type namedVerifierFactory struct {
+	name    string
+	factory vf.VerifierFactory
+}
+
+type namedStoreFactory struct {
+	name    string
+	factory sf.StoreFactory
+}
+
+// Builder collects the verifiers and stores compiled into a distribution
+type Builder struct {
+	verifiers []namedVerifierFactory
+	stores    []namedStoreFactory
+}
+
+// NewBuilder returns a builder of a distribution with the built-in verifiers and stores only
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// WithVerifier adds a verifier created by factory for verifiers configured with name
+func (b *Builder) WithVerifier(name string, factory vf.VerifierFactory) *Builder {
+	b.verifiers = append(b.verifiers, namedVerifierFactory{name: name, factory: factory})
+	return b
+}
+
+// WithVerifierPlugin adds a verifier plugin built with the skeleton, which then verifies references in-process
+func (b *Builder) WithVerifierPlugin(plugin skel.Plugin) *Builder {
+	return b.WithVerifier(plugin.Name, plugin)
+}
+
+// WithStore adds a store created by factory for stores configured with name
+func (b *Builder) WithStore(name string, factory sf.StoreFactory) *Builder {
+	b.stores = append(b.stores, namedStoreFactory{name: name, factory: factory})
+	return b
+}
+
+// Register registers the verifiers and stores added to the builder. Like the registration of the built-in ones, it
+// panics if a name is already registered.
+func (b *Builder) Register() {
+	for _, verifier := range b.verifiers {
+		vf.Register(verifier.name, verifier.factory)
+	}
+	for _, store := range b.stores {
+		sf.Register(store.name, store.factory)
+	}
+}
+
+// Main registers the verifiers and stores added to the builder and runs the ratify CLI
+func (b *Builder) Main() {
+	b.Register()
+	Main()
+}
+
+// Main runs the ratify CLI with the registered verifiers and stores
+func Main() {
+	if err := cmd.Root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}